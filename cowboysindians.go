@@ -3,10 +3,12 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"math/rand"
 	"time"
 
+	"github.com/onorton/cowboysindians/event"
 	"github.com/onorton/cowboysindians/item"
 	"github.com/onorton/cowboysindians/message"
 	"github.com/onorton/cowboysindians/npc"
@@ -20,11 +22,21 @@ import (
 	"sort"
 )
 
-const windowWidth = 100
-const windowHeight = 25
 const saveFilename = "game.json"
 const worldSaveFilename = "game_world.json"
 
+var layout ui.Layout
+
+// Size of the gang that rides out to meet a traveller
+const minBandits = 2
+const maxBandits = 4
+
+// Animals a traveller might run into on the road
+var wildlife = []string{"mountain lion", "rattlesnake"}
+
+// Places a trader might pull their wagon over
+const wagonSites = 5
+
 func check(e error) {
 	if e != nil {
 		panic(e)
@@ -32,12 +44,16 @@ func check(e error) {
 }
 
 type GameState struct {
-	PlayerIndex int
-	Time        int
-	Viewer      *worldmap.Viewer
-	Npcs        []*npc.Npc
-	Player      *player.Player
-	Target      string
+	PlayerIndex  int
+	Time         int
+	Viewer       *worldmap.Viewer
+	Overview     *worldmap.Overview
+	Weather      *worldmap.Weather
+	Train        *worldmap.Train
+	Stagecoaches []*worldmap.Stagecoach
+	Npcs         []*npc.Npc
+	Player       *player.Player
+	Target       string
 }
 
 func save(state GameState, m *worldmap.Map) {
@@ -52,6 +68,22 @@ func save(state GameState, m *worldmap.Map) {
 	check(err)
 	buffer.WriteString(fmt.Sprintf("\"Viewer\":%s,\n", viewerValue))
 
+	overviewValue, err := json.Marshal(state.Overview)
+	check(err)
+	buffer.WriteString(fmt.Sprintf("\"Overview\":%s,\n", overviewValue))
+
+	weatherValue, err := json.Marshal(state.Weather)
+	check(err)
+	buffer.WriteString(fmt.Sprintf("\"Weather\":%s,\n", weatherValue))
+
+	trainValue, err := json.Marshal(state.Train)
+	check(err)
+	buffer.WriteString(fmt.Sprintf("\"Train\":%s,\n", trainValue))
+
+	stagecoachesValue, err := json.Marshal(state.Stagecoaches)
+	check(err)
+	buffer.WriteString(fmt.Sprintf("\"Stagecoaches\":%s,\n", stagecoachesValue))
+
 	npcsValue, err := json.Marshal(state.Npcs)
 	check(err)
 	buffer.WriteString(fmt.Sprintf("\"Npcs\":%s,\n", npcsValue))
@@ -89,6 +121,158 @@ func load() GameState {
 
 }
 
+// Places of interest on the world map that the map itself does not track
+func landmarks(npcs []*npc.Npc) []worldmap.Landmark {
+	l := make([]worldmap.Landmark, 0)
+	for _, npc := range npcs {
+		if !npc.IsDead() && len(npc.GetBounties().Bounties()) > 0 {
+			x, y := npc.GetCoordinates()
+			l = append(l, worldmap.NewLandmark(x, y, '$', fmt.Sprintf("%d bounties", len(npc.GetBounties().Bounties()))))
+		}
+	}
+	return l
+}
+
+// Brings out the team, driver, shotgun messenger and any passengers of a coach that has come into view
+func crewStagecoach(crewing worldmap.Crewing, m *worldmap.Map) []*npc.Npc {
+	arrivals := make([]*npc.Npc, 0)
+	team := make([]worldmap.Creature, 0, len(crewing.Team))
+	for _, location := range crewing.Team {
+		horse := npc.NewMount("horse", location.X, location.Y, m)
+		team = append(team, horse)
+		arrivals = append(arrivals, horse)
+	}
+
+	onBoard := []string{"stagecoach driver", "shotgun messenger"}
+	for i := 0; i < crewing.Passengers; i++ {
+		onBoard = append(onBoard, "stagecoach passenger")
+	}
+	crew := make([]worldmap.Creature, 0, len(onBoard))
+	for _, npcType := range onBoard {
+		n := npc.NewNpc(npcType, crewing.Coach.X, crewing.Coach.Y, m, nil, nil, nil)
+		crew = append(crew, n)
+		arrivals = append(arrivals, n)
+	}
+	m.Board(crewing, team, crew)
+	return arrivals
+}
+
+// Raises the alarm over a stagecoach that the player has held up, or brings out the gang of bandits that robbed it
+func stagecoachHoldUp(holdUp worldmap.HoldUp, p *player.Player, m *worldmap.Map) []*npc.Npc {
+	arrivals := make([]*npc.Npc, 0, len(holdUp.Positions))
+	if holdUp.ByBandits {
+		for _, location := range holdUp.Positions {
+			bandit := npc.NewEnemy("bandit", location.X, location.Y, m)
+			arrivals = append(arrivals, bandit)
+			if mount := bandit.Mount(); mount != nil {
+				arrivals = append(arrivals, mount)
+			}
+		}
+		if len(arrivals) > 0 {
+			// The leader carries off the takings
+			arrivals[0].AddMoney(holdUp.Stolen)
+			event.Alarm(event.NewStagecoachRobbery(arrivals[0], holdUp.Stolen, holdUp.Location, holdUp.Destination))
+		}
+		message.Enqueue(fmt.Sprintf("Word comes that bandits have held up the stagecoach to %s.", holdUp.Destination))
+		return arrivals
+	}
+
+	event.Alarm(event.NewStagecoachRobbery(p, 0, holdUp.Location, holdUp.Destination))
+	return arrivals
+}
+
+// Travels along the roads to a town, passing the time on the way, until the player gets there or runs into someone.
+// Returns everyone who turned up on the way and whether any time passed.
+func fastTravel(destination worldmap.Town, p *player.Player, m *worldmap.Map, state *GameState) ([]*npc.Npc, bool) {
+	if m.Underground() {
+		message.PrintMessage("You can't travel from underground.")
+		return nil, false
+	}
+	if p.OverEncumbered() {
+		message.PrintMessage("You are too encumbered to travel.")
+		return nil, false
+	}
+	if m.EnemiesInSight() {
+		message.PrintMessage("You can't travel with enemies nearby.")
+		return nil, false
+	}
+	route, ok := m.Journey(destination)
+	if !ok {
+		message.PrintMessage(fmt.Sprintf("There is no road from here to %s.", destination.Name))
+		return nil, false
+	}
+
+	message.Enqueue(fmt.Sprintf("You set off for %s.", destination.Name))
+	arrivals := make([]*npc.Npc, 0)
+	encounter := worldmap.NoEncounter
+	reached := len(route) - 1
+	for i := 0; i < len(route); i += p.Pace() {
+		state.Time++
+		if state.Time%npc.RestockInterval == 0 {
+			for _, n := range state.Npcs {
+				n.Restock()
+			}
+		}
+
+		if !p.Journey() {
+			message.Enqueue("You can go no further.")
+			reached = i
+			break
+		}
+
+		m.UpdateWeather()
+		for _, location := range m.UpdateTrain() {
+			arrivals = append(arrivals, npc.NewEnemy("express guard", location.X, location.Y, m))
+		}
+		for _, holdUp := range m.UpdateStagecoaches() {
+			arrivals = append(arrivals, stagecoachHoldUp(holdUp, p, m)...)
+		}
+
+		if encounter = m.RandomEncounter(); encounter != worldmap.NoEncounter {
+			reached = i
+			break
+		}
+	}
+
+	m.TeleportPlayer(route[reached].X, route[reached].Y)
+	m.UpdateLighting(state.Time)
+
+	switch encounter {
+	case worldmap.Bandits:
+		message.Enqueue("Bandits ride out to meet you on the road!")
+		for _, location := range m.EncounterPositions(minBandits + rand.Intn(maxBandits-minBandits+1)) {
+			bandit := npc.NewEnemy("bandit", location.X, location.Y, m)
+			arrivals = append(arrivals, bandit)
+			if mount := bandit.Mount(); mount != nil {
+				arrivals = append(arrivals, mount)
+			}
+		}
+	case worldmap.Wildlife:
+		animal := wildlife[rand.Intn(len(wildlife))]
+		message.Enqueue(fmt.Sprintf("You come across a %s on the road.", animal))
+		for _, location := range m.EncounterPositions(1 + rand.Intn(2)) {
+			arrivals = append(arrivals, npc.NewNpc(animal, location.X, location.Y, m, nil, nil, nil))
+		}
+	case worldmap.Trader:
+		for _, location := range m.EncounterPositions(wagonSites) {
+			if wagon, ok := m.PitchWagon(location.X, location.Y); ok {
+				message.Enqueue("You meet a trader's wagon on the road.")
+				arrivals = append(arrivals, npc.NewNpc("trader", location.X, location.Y, m, &destination, &wagon, nil))
+				break
+			}
+		}
+		// The trader keeps moving if there's no room to pull the wagon over
+		if len(arrivals) == 0 {
+			message.Enqueue("A trader's wagon rattles past you on the road.")
+		}
+	default:
+		if reached == len(route)-1 {
+			message.Enqueue(fmt.Sprintf("You arrive in %s.", destination.Name))
+		}
+	}
+	return arrivals, true
+}
+
 // Combine enemies and player into same slice
 func allCreatures(npcs []*npc.Npc, p *player.Player) []worldmap.Creature {
 	all := make([]worldmap.Creature, len(npcs)+1)
@@ -108,10 +292,36 @@ func printStatus(status []string) {
 	for _, stat := range status {
 		statusString += stat + " "
 	}
-	ui.WriteText(0, windowHeight+1, statusString)
+	ui.WriteText(0, layout.StatusY, statusString)
 
 }
 
+// Prints stats one per line followed by the inventory in the side panel
+func printPanel(p *player.Player, stats []string) {
+	cells := make([]ui.Cell, 0, layout.PanelWidth*layout.ViewerHeight)
+	for y := 0; y < layout.ViewerHeight; y++ {
+		for x := layout.PanelX; x < layout.PanelX+layout.PanelWidth; x++ {
+			cells = append(cells, ui.NewCell(x, y))
+		}
+	}
+	ui.ClearCells(cells)
+
+	for i, stat := range stats {
+		ui.WriteText(layout.PanelX, i, stat)
+	}
+	p.PrintInventoryAt(layout.PanelX, len(stats)+1)
+}
+
+// Recomputes where everything is drawn after the terminal changes size
+func resize(width, height int, m *worldmap.Map) {
+	layout = ui.NewLayout(width, height)
+	message.SetWindowSize(layout.Width, layout.MessageY)
+	if m != nil {
+		m.ResizeViewer(layout.ViewerWidth, layout.ViewerHeight)
+	}
+	ui.ClearScreen()
+}
+
 func printOpeningText(name string) {
 	beginning := 4
 	ui.WriteTextCentred(beginning, "You wake up bruised. You feel a dull pain in your head.")
@@ -123,11 +333,51 @@ func printOpeningText(name string) {
 	ui.GetInput()
 }
 
+// Prints every action in the game context alongside its keys, across as many columns as needed
+func printHelp() {
+	ui.ClearScreen()
+	ui.WriteTextCentred(0, "Controls")
+	lines := ui.HelpLines(ui.GameContext)
+	columnWidth := layout.Width / 3
+	rows := layout.Height - 2
+	for i, line := range lines {
+		ui.WriteText((i/rows)*columnWidth, 2+i%rows, line)
+	}
+	ui.GetInput()
+	ui.ClearScreen()
+}
+
 func main() {
-	ui.Init(windowWidth)
+	telnetAddr := flag.String("telnet", "", "address to listen on for a telnet connection to play over, instead of the terminal")
+	flag.Parse()
+
+	var backend ui.Backend
+	var err error
+	if *telnetAddr != "" {
+		fmt.Printf("Waiting for a connection on %s\n", *telnetAddr)
+		backend, err = ui.ListenANSI(*telnetAddr)
+	} else {
+		backend, err = ui.NewTermbox()
+	}
+	check(err)
+
+	ui.Init(backend, backend)
 	defer ui.Close()
 	item.LoadAllData()
-	message.SetWindowSize(windowWidth, windowHeight)
+	var worldMap *worldmap.Map
+	width, height := ui.Size()
+	resize(width, height, worldMap)
+	ui.SetResizeHandler(func(width, height int) {
+		resize(width, height, worldMap)
+	})
+	if err := ui.LoadKeymap(); err != nil {
+		conflicts, ok := err.(ui.ConflictError)
+		if !ok {
+			panic(err)
+		}
+		message.PrintMessage(conflicts.Error())
+		ui.GetInput()
+	}
 	state := GameState{}
 	rand.Seed(time.Now().UTC().UnixNano())
 
@@ -150,7 +400,7 @@ func main() {
 		p, npcs := world.GenerateWorld(worldSaveFilename)
 		state.Player = p
 		x, y := state.Player.GetCoordinates()
-		state.Viewer = worldmap.NewViewer(x, y, windowWidth, windowHeight)
+		state.Viewer = worldmap.NewViewer(x, y, layout.ViewerWidth, layout.ViewerHeight)
 		state.Npcs = npcs
 		state.Time = 1
 		state.PlayerIndex = 0
@@ -170,8 +420,15 @@ func main() {
 	npcs := state.Npcs
 
 	all := allCreatures(npcs, player)
-	worldMap := worldmap.NewMap(worldSaveFilename, state.Viewer, state.Player, all)
+	worldMap = worldmap.NewMap(worldSaveFilename, state.Viewer, state.Overview, state.Weather, state.Train, state.Stagecoaches, state.Player, all)
+	state.Overview = worldMap.Overview()
+	state.Weather = worldMap.Weather()
+	state.Train = worldMap.Train()
+	state.Stagecoaches = worldMap.Stagecoaches()
+	// The terminal may be a different size to when the game was saved
+	worldMap.ResizeViewer(layout.ViewerWidth, layout.ViewerHeight)
 	worldMap.LoadActiveChunks()
+	worldMap.UpdateLighting(state.Time)
 
 	// Initial action is nothing
 	action := ui.NoAction
@@ -205,13 +462,32 @@ func main() {
 					break
 				}
 
+				// Sleeping players can't act, so their turn ends straight away
+				if player.Asleep() {
+					continue
+				}
+
 				for {
 					worldMap.Render()
 					stats := player.GetStats()
 					stats = append([]string{fmt.Sprintf("T:%d", state.Time)}, stats...)
-					printStatus(stats)
-					if inventory {
-						player.PrintInventory()
+					if weather := worldMap.WeatherStatus(); weather != "" {
+						stats = append(stats, weather)
+					}
+					if timeOfDay := worldMap.TimeStatus(); timeOfDay != "" {
+						stats = append(stats, timeOfDay)
+					}
+					if depth := worldMap.DepthStatus(); depth != "" {
+						stats = append(stats, depth)
+					}
+					// Wide terminals have room for the stats and inventory beside the map
+					if layout.HasPanel() {
+						printPanel(player, stats)
+					} else {
+						printStatus(stats)
+						if inventory {
+							player.PrintInventory()
+						}
 					}
 					if action == ui.NoAction {
 						action = ui.GetInput()
@@ -250,6 +526,8 @@ func main() {
 							endTurn = player.ToggleCrouch()
 						case ui.RangedAttack:
 							endTurn = player.RangedAttack()
+						case ui.Throw:
+							endTurn = player.Throw()
 						case ui.PickUpItem:
 							endTurn = player.PickupItem()
 						case ui.DropItem:
@@ -268,6 +546,10 @@ func main() {
 							endTurn = player.ConsumeItem()
 						case ui.Mount:
 							endTurn = player.ToggleMount()
+						case ui.Descend:
+							endTurn = player.TakeStairs(1)
+						case ui.Ascend:
+							endTurn = player.TakeStairs(-1)
 						case ui.Talk:
 							player.Talk()
 						case ui.Read:
@@ -276,6 +558,23 @@ func main() {
 							endTurn = player.Use()
 						case ui.Pickpocket:
 							endTurn = player.Pickpocket()
+						case ui.Help:
+							printHelp()
+						case ui.WorldMap:
+							if destination, ok := worldMap.ShowOverview(landmarks(npcs)); ok {
+								arrivals, travelled := fastTravel(destination, player, worldMap, &state)
+								for _, n := range arrivals {
+									worldMap.AddCreature(n)
+									all = append(all, n)
+									npcs = append(npcs, n)
+								}
+								state.Npcs = npcs
+								endTurn = travelled
+							}
+						case ui.Sleep:
+							endTurn = player.Sleep()
+						case ui.Advance:
+							player.Advance()
 						}
 						action = ui.NoAction
 					}
@@ -288,14 +587,42 @@ func main() {
 				if c.IsDead() {
 					continue
 				}
-				cX, cY := c.GetCoordinates()
-				if !worldMap.InActiveChunks(cX, cY) {
+				if !worldMap.IsActive(c) {
 					continue
 				}
 				c.Update()
 			}
 		}
 
+		worldMap.UpdateFire()
+		worldMap.UpdateWeather()
+
+		// Guards come out of the express car when the train is held up
+		for _, location := range worldMap.UpdateTrain() {
+			guard := npc.NewEnemy("express guard", location.X, location.Y, worldMap)
+			worldMap.AddCreature(guard)
+			all = append(all, guard)
+			npcs = append(npcs, guard)
+			state.Npcs = npcs
+		}
+
+		for _, crewing := range worldMap.UncrewedStagecoaches() {
+			for _, n := range crewStagecoach(crewing, worldMap) {
+				all = append(all, n)
+				npcs = append(npcs, n)
+			}
+			state.Npcs = npcs
+		}
+
+		for _, holdUp := range worldMap.UpdateStagecoaches() {
+			for _, n := range stagecoachHoldUp(holdUp, player, worldMap) {
+				worldMap.AddCreature(n)
+				all = append(all, n)
+				npcs = append(npcs, n)
+			}
+			state.Npcs = npcs
+		}
+
 		// Remove dead enemies, npcs and mounts
 		for i, c := range all {
 			if npc, ok := c.(*npc.Npc); ok && npc.IsDead() {
@@ -303,7 +630,11 @@ func main() {
 				worldMap.DeleteCreature(npc)
 				all = append(all[:i], all[i+1:]...)
 				if npc.GetID() == state.Target {
-					message.PrintMessage(fmt.Sprintf("%s is dead! You have been avenged.", npc.GetName().FullName()))
+					if npc.Starving() {
+						message.PrintMessage(fmt.Sprintf("%s has starved to death. You will never have your revenge.", npc.GetName().FullName()))
+					} else {
+						message.PrintMessage(fmt.Sprintf("%s is dead! You have been avenged.", npc.GetName().FullName()))
+					}
 					ui.GetInput()
 					quit = true
 				}
@@ -326,5 +657,13 @@ func main() {
 			break
 		}
 		state.Time++
+		worldMap.UpdateLighting(state.Time)
+
+		// Shops restock and their prices settle on a schedule
+		if state.Time%npc.RestockInterval == 0 {
+			for _, n := range npcs {
+				n.Restock()
+			}
+		}
 	}
 }
@@ -40,6 +40,23 @@ type TheftEvent struct {
 	location    worldmap.Coordinates
 }
 
+// RobberyEvent is raised when a bank is robbed, sounding an alarm across town
+type RobberyEvent struct {
+	id          string
+	perpetrator worldmap.Creature
+	stolen      int
+	location    worldmap.Coordinates
+}
+
+// StagecoachRobberyEvent is raised when a stagecoach is held up, and reported in the town it was bound for
+type StagecoachRobberyEvent struct {
+	id          string
+	perpetrator worldmap.Creature
+	stolen      int
+	location    worldmap.Coordinates
+	reportedIn  string
+}
+
 type PickpocketEvent struct {
 	id          string
 	perpetrator worldmap.Creature
@@ -114,6 +131,65 @@ func (e TheftEvent) Location() worldmap.Coordinates {
 	return e.location
 }
 
+func (e RobberyEvent) Id() string {
+	return e.id
+}
+
+func (e RobberyEvent) Perpetrator() string {
+	return e.perpetrator.GetID()
+}
+
+func (e RobberyEvent) PerpetratorName() string {
+	return e.perpetrator.GetName().FullName()
+}
+
+func (e RobberyEvent) Crime() string {
+	return "Bank robbery"
+}
+
+func (e RobberyEvent) Value() int {
+	return 5 * e.stolen
+}
+
+// Everyone hears of it at once through the alarm, rather than by seeing it for themselves
+func (e RobberyEvent) Witness(world *worldmap.Map, c worldmap.Creature) {}
+
+func (e RobberyEvent) Location() worldmap.Coordinates {
+	return e.location
+}
+
+func (e StagecoachRobberyEvent) Id() string {
+	return e.id
+}
+
+func (e StagecoachRobberyEvent) Perpetrator() string {
+	return e.perpetrator.GetID()
+}
+
+func (e StagecoachRobberyEvent) PerpetratorName() string {
+	return e.perpetrator.GetName().FullName()
+}
+
+func (e StagecoachRobberyEvent) Crime() string {
+	return "Stagecoach robbery"
+}
+
+func (e StagecoachRobberyEvent) Value() int {
+	return 20000 + 5*e.stolen
+}
+
+// Word reaches the whole territory at once when the coach gets in, rather than by anyone seeing it for themselves
+func (e StagecoachRobberyEvent) Witness(world *worldmap.Map, c worldmap.Creature) {}
+
+func (e StagecoachRobberyEvent) Location() worldmap.Coordinates {
+	return e.location
+}
+
+// ReportedIn is the town whose sheriff takes up the case
+func (e StagecoachRobberyEvent) ReportedIn() string {
+	return e.reportedIn
+}
+
 func (e PickpocketEvent) Id() string {
 	return e.id
 }
@@ -168,6 +244,14 @@ func NewTheft(perpetrator worldmap.Creature, item *item.Item, location worldmap.
 	return TheftEvent{xid.New().String(), perpetrator, item, location}
 }
 
+func NewRobbery(perpetrator worldmap.Creature, stolen int, location worldmap.Coordinates) RobberyEvent {
+	return RobberyEvent{xid.New().String(), perpetrator, stolen, location}
+}
+
+func NewStagecoachRobbery(perpetrator worldmap.Creature, stolen int, location worldmap.Coordinates, reportedIn string) StagecoachRobberyEvent {
+	return StagecoachRobberyEvent{xid.New().String(), perpetrator, stolen, location, reportedIn}
+}
+
 func NewPickpocket(perpetrator worldmap.Creature, item *item.Item, location worldmap.Coordinates) PickpocketEvent {
 	return PickpocketEvent{xid.New().String(), perpetrator, item, location}
 }
@@ -183,10 +267,20 @@ func Emit(e Event) {
 	}
 }
 
+// Alarm spreads word of a crime that everyone hears of, such as a robbery, without waiting for witnesses
+func Alarm(crime CrimeEvent) {
+	Emit(WitnessedCrimeEvent{crime})
+}
+
 func Subscribe(s subscriber) {
 	subscribers = append(subscribers, s)
 }
 
+// Reset forgets every subscriber, for when the world they belong to is thrown away
+func Reset() {
+	subscribers = make([]subscriber, 0)
+}
+
 type subscriber interface {
 	ProcessEvent(Event)
 }
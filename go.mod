@@ -3,9 +3,12 @@ module github.com/onorton/cowboysindians
 go 1.19
 
 require (
-	github.com/mattn/go-runewidth v0.0.9 // indirect
-	github.com/nsf/termbox-go v1.1.1 // indirect
-	github.com/rs/xid v1.4.0 // indirect
+	github.com/nsf/termbox-go v1.1.1
+	github.com/rs/xid v1.4.0
 	github.com/sirupsen/logrus v1.9.0
+)
+
+require (
+	github.com/mattn/go-runewidth v0.0.9 // indirect
 	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
 )
@@ -5,13 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 
-	termbox "github.com/nsf/termbox-go"
 	"github.com/onorton/cowboysindians/ui"
 )
 
 type Icon struct {
 	icon   rune
-	colour termbox.Attribute
+	colour ui.Colour
 }
 
 func (i Icon) Render() ui.Element {
@@ -23,10 +22,10 @@ func MergeIcons(f, b Icon) ui.Element {
 }
 
 func CreatePlayerIcon() Icon {
-	return Icon{'@', termbox.ColorWhite}
+	return Icon{'@', ui.ColourWhite}
 }
 
-func NewIcon(icon rune, colour termbox.Attribute) Icon {
+func NewIcon(icon rune, colour ui.Colour) Icon {
 	return Icon{icon, colour}
 }
 
@@ -59,7 +58,7 @@ func (i *Icon) UnmarshalJSON(data []byte) error {
 
 	type iconJson struct {
 		Icon   rune
-		Colour termbox.Attribute
+		Colour ui.Colour
 	}
 
 	var v iconJson
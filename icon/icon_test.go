@@ -3,8 +3,6 @@ package icon
 import (
 	"encoding/json"
 	"testing"
-
-	termbox "github.com/nsf/termbox-go"
 )
 
 type marshallingPair struct {
@@ -33,7 +31,7 @@ var unmarshallingTests = []unmarshallingPair{
 	{"{\"Icon\":126,\"Colour\":255}", Icon{126, 255}},
 }
 
-func Testmarshalling(t *testing.T) {
+func TestMarshalling(t *testing.T) {
 
 	for _, pair := range marshallingTests {
 
@@ -67,7 +65,7 @@ func TestUnmarshalling(t *testing.T) {
 			)
 		}
 
-		if i.colour != termbox.Attribute(pair.icon.colour) {
+		if i.colour != pair.icon.colour {
 			t.Error(
 				"For", "Colour",
 				"expected", pair.icon.colour,
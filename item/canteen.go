@@ -0,0 +1,26 @@
+package item
+
+// CanteenComponent holds up to Capacity sips of water, each quenching thirst by Quench.
+// It is drunk from one sip at a time and refilled at water.
+type CanteenComponent struct {
+	Capacity int
+	Sips     int
+	Quench   int
+}
+
+// Drink takes a sip from the canteen, returning false if it is empty
+func (cc *CanteenComponent) Drink() bool {
+	if cc.Sips <= 0 {
+		return false
+	}
+	cc.Sips--
+	return true
+}
+
+func (cc *CanteenComponent) Refill() {
+	cc.Sips = cc.Capacity
+}
+
+func (cc *CanteenComponent) Full() bool {
+	return cc.Sips >= cc.Capacity
+}
@@ -0,0 +1,46 @@
+package item
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCanteenRunsDryAfterLastSip(t *testing.T) {
+	canteen := &CanteenComponent{2, 2, 100}
+
+	for i := 0; i < 2; i++ {
+		if !canteen.Drink() {
+			t.Fatalf("Expected sip %d to be drunk", i+1)
+		}
+	}
+	if canteen.Drink() {
+		t.Error("Expected an empty canteen to have nothing left to drink")
+	}
+}
+
+func TestCanteenRefillsToCapacity(t *testing.T) {
+	canteen := &CanteenComponent{3, 0, 100}
+
+	canteen.Refill()
+
+	if !canteen.Full() || canteen.Sips != 3 {
+		t.Errorf("Expected canteen to hold 3 sips but held %d", canteen.Sips)
+	}
+}
+
+func TestCanteenKeepsWhatIsLeftWhenUnmarshalled(t *testing.T) {
+	components := UnmarshalComponents(map[string]interface{}{"canteen": map[string]interface{}{"Capacity": 3, "Sips": 1, "Quench": 100}})
+
+	canteen := components["canteen"].(*CanteenComponent)
+	if canteen.Sips != 1 || canteen.Capacity != 3 {
+		t.Errorf("Expected canteen with 1 of 3 sips but was %d of %d", canteen.Sips, canteen.Capacity)
+	}
+
+	data, err := json.Marshal(canteen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{\"Capacity\":3,\"Sips\":1,\"Quench\":100}" {
+		t.Errorf("Unexpected marshalled canteen %s", data)
+	}
+}
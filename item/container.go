@@ -0,0 +1,145 @@
+package item
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ContainerComponent holds other items, such as a chest or saddlebags.
+// Lockable containers are locked and unlocked by the same keys as doors.
+type ContainerComponent struct {
+	items    []*Item
+	capacity float64
+	lockable bool
+	locked   bool
+	key      int32
+}
+
+// Weight of everything inside the container
+func (cc *ContainerComponent) Weight() float64 {
+	weight := 0.0
+	for _, itm := range cc.items {
+		weight += itm.GetWeight()
+	}
+	return weight
+}
+
+// Fits returns true if there is room left in the container for an item
+func (cc *ContainerComponent) Fits(itm *Item) bool {
+	return cc.Weight()+itm.GetWeight() <= cc.capacity
+}
+
+func (cc *ContainerComponent) Put(itm *Item) {
+	// Money is kept in one pile
+	if itm.GetName() == "money" {
+		for _, existing := range cc.items {
+			if existing.GetName() == "money" {
+				existing.v += itm.GetValue()
+				return
+			}
+		}
+	}
+	cc.items = append(cc.items, itm)
+}
+
+// Take removes an item with the given key from the container
+func (cc *ContainerComponent) Take(key rune) *Item {
+	for i, itm := range cc.items {
+		if itm.GetKey() == key {
+			cc.items = append(cc.items[:i], cc.items[i+1:]...)
+			return itm
+		}
+	}
+	return nil
+}
+
+func (cc *ContainerComponent) GetItems() map[rune][]*Item {
+	items := make(map[rune][]*Item)
+	for _, itm := range cc.items {
+		items[itm.GetKey()] = append(items[itm.GetKey()], itm)
+	}
+	return items
+}
+
+func (cc *ContainerComponent) Lockable() bool {
+	return cc.lockable
+}
+
+func (cc *ContainerComponent) Locked() bool {
+	return cc.locked
+}
+
+func (cc *ContainerComponent) ToggleLocked() {
+	cc.locked = !cc.locked
+}
+
+// Lock gives a container a lock that only fitting keys open
+func (cc *ContainerComponent) Lock(key int32) {
+	cc.lockable = true
+	cc.locked = true
+	cc.key = key
+}
+
+func (cc *ContainerComponent) KeyFits(key KeyComponent) bool {
+	if !cc.lockable {
+		return false
+	}
+	if key.KeyType() == -1 {
+		return true
+	}
+	return key.KeyType() == cc.key
+}
+
+func (cc *ContainerComponent) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	keys := []string{"Items", "Capacity", "Lockable", "Locked", "Key"}
+
+	containerValues := map[string]interface{}{
+		"Items":    cc.items,
+		"Capacity": cc.capacity,
+		"Lockable": cc.lockable,
+		"Locked":   cc.locked,
+		"Key":      cc.key,
+	}
+
+	for i, key := range keys {
+		jsonValue, err := json.Marshal(containerValues[key])
+		if err != nil {
+			return nil, err
+		}
+		buffer.WriteString(fmt.Sprintf("\"%s\":%s", key, jsonValue))
+		if i < len(keys)-1 {
+			buffer.WriteString(",")
+		}
+	}
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (cc *ContainerComponent) UnmarshalJSON(data []byte) error {
+
+	type containerJson struct {
+		Items    []*Item
+		Capacity float64
+		Lockable bool
+		Locked   bool
+		Key      int32
+	}
+	v := containerJson{}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	cc.items = v.Items
+	if cc.items == nil {
+		cc.items = make([]*Item, 0)
+	}
+	cc.capacity = v.Capacity
+	cc.lockable = v.Lockable
+	cc.locked = v.Locked
+	cc.key = v.Key
+	return nil
+}
@@ -0,0 +1,87 @@
+package item
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/onorton/cowboysindians/icon"
+)
+
+func newChest() *Item {
+	return &Item{"chest", "", icon.NewIcon(9632, 6), 40, 1000, map[string]component{"container": &ContainerComponent{make([]*Item, 0), 10, false, false, 0}}}
+}
+
+func TestContainerCapacity(t *testing.T) {
+	chest := newChest()
+	cc := chest.Component("container").(*ContainerComponent)
+
+	gem := &Item{"gem", "", icon.NewIcon(42, 4), 2, 2000, map[string]component{}}
+	barrel := &Item{"barrel", "", icon.NewIcon(111, 8), 30, 200, map[string]component{}}
+	if !cc.Fits(gem) {
+		t.Error("Gem should have fit in the chest")
+	}
+	cc.Put(gem)
+	if cc.Fits(barrel) {
+		t.Error("Barrel should not have fit in the chest")
+	}
+	if chest.GetWeight() != 42 {
+		t.Errorf("Chest should have weighed 42 with a gem inside but weighed %f", chest.GetWeight())
+	}
+	if cc.Take(gem.GetKey()) != gem || len(cc.GetItems()) != 0 {
+		t.Error("Gem should have been taken out of the chest")
+	}
+}
+
+func TestContainerKeepsMoneyTogether(t *testing.T) {
+	cc := newChest().Component("container").(*ContainerComponent)
+	cc.Put(Money(100))
+	cc.Put(Money(250))
+
+	money := cc.GetItems()[Money(0).GetKey()]
+	if len(money) != 1 || money[0].GetValue() != 350 {
+		t.Errorf("Chest should have held one pile of $3.50 but held %v", money)
+	}
+}
+
+func TestContainerLock(t *testing.T) {
+	cc := newChest().Component("container").(*ContainerComponent)
+	if cc.KeyFits(KeyComponent{-1, 0.1}) {
+		t.Error("Nothing should fit a container without a lock")
+	}
+
+	cc.Lock(5)
+	if !cc.Locked() {
+		t.Error("Container should have been locked")
+	}
+	if !cc.KeyFits(KeyComponent{5, 1}) || !cc.KeyFits(KeyComponent{-1, 0.1}) {
+		t.Error("Matching key and lockpick should have fit the lock")
+	}
+	if cc.KeyFits(KeyComponent{4, 1}) {
+		t.Error("Key for another lock should not have fit")
+	}
+}
+
+func TestContainerMarshalling(t *testing.T) {
+	chest := newChest()
+	cc := chest.Component("container").(*ContainerComponent)
+	cc.Put(Money(500))
+	cc.Lock(3)
+
+	result, err := json.Marshal(chest)
+	if err != nil {
+		t.Fatal("Failed when marshalling", err)
+	}
+
+	unmarshalled := Item{}
+	if err := json.Unmarshal(result, &unmarshalled); err != nil {
+		t.Fatal("Failed when unmarshalling", string(result), err)
+	}
+
+	container := unmarshalled.Component("container").(*ContainerComponent)
+	if !container.Locked() || container.key != 3 || container.capacity != 10 {
+		t.Errorf("Expected a locked container with key 3 and capacity 10 but got %+v", container)
+	}
+	if len(container.items) != 1 || container.items[0].GetValue() != 500 {
+		t.Errorf("Expected container to hold $5.00 but it held %v", container.items)
+	}
+}
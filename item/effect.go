@@ -29,6 +29,11 @@ func NewOngoingEffect(effect int) *Effect {
 	return &Effect{effect, false, -1, false, false, true}
 }
 
+// NewPermanentMaxEffect changes the maximum of an attribute for good
+func NewPermanentMaxEffect(effect int) *Effect {
+	return &Effect{effect, true, 1, false, true, false}
+}
+
 func (e *Effect) Update(value, max int) (int, int) {
 	if !e.Expired() {
 		if e.duration > 0 {
@@ -57,6 +62,15 @@ func (e *Effect) Expired() bool {
 	return e.duration == 0
 }
 
+// Ongoing returns true if the effect applies every turn until it is removed
+func (e *Effect) Ongoing() bool {
+	return e.compounded && e.duration < 0
+}
+
+func (e *Effect) Value() int {
+	return e.effect
+}
+
 func (e *Effect) MarshalJSON() ([]byte, error) {
 	buffer := bytes.NewBufferString("{")
 
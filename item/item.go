@@ -7,6 +7,7 @@ import (
 	"hash/fnv"
 	"io/ioutil"
 	"math/rand"
+	"sort"
 
 	"github.com/onorton/cowboysindians/icon"
 	"github.com/onorton/cowboysindians/ui"
@@ -117,9 +118,16 @@ func Choose(probabilities map[string]float64) string {
 			}
 		}
 	}
-	items := make([]string, 0)
+	// Names are sorted so that the same item is chosen for the same random number
+	names := make([]string, 0, len(probabilities))
+	for name := range probabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	for name, probability := range probabilities {
+	items := make([]string, 0)
+	for _, name := range names {
+		probability := probabilities[name]
 		count := int(probability * max)
 		for i := 0; i < count; i++ {
 			items = append(items, name)
@@ -185,13 +193,24 @@ func (item *Item) GetKey() rune {
 }
 
 func (item *Item) GetWeight() float64 {
+	if item.HasComponent("container") {
+		return item.w + item.Component("container").(*ContainerComponent).Weight()
+	}
 	return item.w
 }
 
 func (item *Item) GetValue() int {
+	if item.HasComponent("weapon") {
+		return item.Component("weapon").(WeaponComponent).valueInCondition(item.v)
+	}
 	return item.v
 }
 
+// Value a weapon has lost to wear
+func (item *Item) WearValue() int {
+	return item.v - item.GetValue()
+}
+
 func NewNormalItem(name string) *Item {
 	item := normalItemData[name]
 
@@ -206,6 +225,42 @@ func (bc BreakableComponent) Broken() bool {
 	return rand.Float64() < bc.Chance
 }
 
+// BedComponent is something that can be slept on. Rest is the fatigue recovered each turn.
+type BedComponent struct {
+	Rest int
+}
+
+// TreatmentComponent treats the wounds listed when used
+type TreatmentComponent struct {
+	Wounds []string
+}
+
+// RepairComponent restores a weapon's condition by Amount when used, but no further than Limit
+type RepairComponent struct {
+	Amount int
+	Limit  int
+}
+
+// ExplosiveComponent goes off Fuse turns after being thrown, hurting everything within Radius.
+// Explosives that Breach blow through walls and doors, and those that Ignite set everything around alight.
+type ExplosiveComponent struct {
+	Fuse    int
+	Radius  int
+	Damage  Damage
+	Effects Effects
+	Breach  bool
+	Ignites bool
+}
+
+func (tc TreatmentComponent) Treats(wound string) bool {
+	for _, w := range tc.Wounds {
+		if w == wound {
+			return true
+		}
+	}
+	return false
+}
+
 type KeyComponent struct {
 	Key    int32
 	Chance float64
@@ -344,11 +399,58 @@ func UnmarshalComponents(cs map[string]interface{}) map[string]component {
 			component = key
 		case "usable":
 			component = tag{}
+		case "alarm":
+			component = tag{}
 		case "breakable":
 			var breakable BreakableComponent
 			err := json.Unmarshal(componentJson, &breakable)
 			check(err)
 			component = breakable
+		case "bed":
+			var bed BedComponent
+			err := json.Unmarshal(componentJson, &bed)
+			check(err)
+			component = bed
+		case "treatment":
+			var treatment TreatmentComponent
+			err := json.Unmarshal(componentJson, &treatment)
+			check(err)
+			component = treatment
+		case "explosive":
+			var explosive ExplosiveComponent
+			err := json.Unmarshal(componentJson, &explosive)
+			check(err)
+			component = explosive
+		case "throwable":
+			component = tag{}
+		case "flammable":
+			component = tag{}
+		case "repair":
+			var repair RepairComponent
+			err := json.Unmarshal(componentJson, &repair)
+			check(err)
+			component = repair
+		case "light":
+			var light LightComponent
+			err := json.Unmarshal(componentJson, &light)
+			check(err)
+			component = &light
+		case "mining":
+			component = tag{}
+		case "campfire":
+			component = tag{}
+		case "container":
+			var container ContainerComponent
+			err := json.Unmarshal(componentJson, &container)
+			check(err)
+			// Contents change, so every container needs its own
+			component = &container
+		case "canteen":
+			var canteen CanteenComponent
+			err := json.Unmarshal(componentJson, &canteen)
+			check(err)
+			// Every canteen holds its own water
+			component = &canteen
 		}
 		components[key] = component
 	}
@@ -377,6 +479,14 @@ func (item *Item) TransferOwner(newOwner string) {
 	}
 }
 
+// ChangeOwner gives an owned item to someone else, such as when it is bought or sold
+func (item *Item) ChangeOwner(newOwner string) {
+	if item.HasComponent("corpse") {
+		return
+	}
+	item.owner = newOwner
+}
+
 func (item *Item) TryBreaking() bool {
 	if item.HasComponent("breakable") {
 		if item.Component("breakable").(BreakableComponent).Broken() {
@@ -0,0 +1,20 @@
+package item
+
+// LightComponent lights up everything within Radius tiles of it while it is Lit.
+type LightComponent struct {
+	Radius int
+	Lit    bool
+}
+
+// Toggle lights it if it is out and puts it out if it is lit
+func (lc *LightComponent) Toggle() {
+	lc.Lit = !lc.Lit
+}
+
+// LightRadius is how far around it the item lights up, which is not at all unless it is a light that is lit
+func (item *Item) LightRadius() int {
+	if light, ok := item.Component("light").(*LightComponent); ok && light.Lit {
+		return light.Radius
+	}
+	return 0
+}
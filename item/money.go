@@ -0,0 +1,45 @@
+package item
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Most money anyone can ask for at once, in dollars, far beyond anything there is to be had
+const maxDollars = 1000000
+
+// ParseMoney parses an amount of money in dollars and cents, with or without a dollar sign, into cents.
+// Only whole dollars with up to two decimal places are accepted, and the amount has to be more than nothing.
+func ParseMoney(input string) (int, bool) {
+	dollars, cents, _ := strings.Cut(strings.TrimPrefix(input, "$"), ".")
+	if (dollars == "" && cents == "") || len(cents) > 2 || !digits(dollars) || !digits(cents) {
+		return 0, false
+	}
+
+	amount := 0
+	if dollars != "" {
+		d, err := strconv.Atoi(dollars)
+		if err != nil || d > maxDollars {
+			return 0, false
+		}
+		amount = d * 100
+	}
+	if cents != "" {
+		c, _ := strconv.Atoi(cents)
+		// A single digit is tenths of a dollar
+		if len(cents) == 1 {
+			c *= 10
+		}
+		amount += c
+	}
+	return amount, amount > 0
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package item
+
+import (
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int
+		ok       bool
+	}{
+		{"12", 1200, true},
+		{"$12.50", 1250, true},
+		{"12.5", 1250, true},
+		{".05", 5, true},
+		{"1000000", 100000000, true},
+		{"0", 0, false},
+		{"0.00", 0, false},
+		{"-5", 0, false},
+		{"$-5.00", 0, false},
+		{"+5", 0, false},
+		{"12.505", 0, false},
+		{"1000001", 0, false},
+		{"99999999999999999999", 0, false},
+		{"NaN", 0, false},
+		{"inf", 0, false},
+		{"-Inf", 0, false},
+		{"1e30", 0, false},
+		{"0x10", 0, false},
+		{"", 0, false},
+		{"$", 0, false},
+		{".", 0, false},
+	}
+
+	for _, testCase := range testCases {
+		amount, ok := ParseMoney(testCase.input)
+		if amount != testCase.expected || ok != testCase.ok {
+			t.Errorf("Expected %q to parse as %d, %t but was %d, %t", testCase.input, testCase.expected, testCase.ok, amount, ok)
+		}
+	}
+}
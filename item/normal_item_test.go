@@ -115,3 +115,14 @@ func TestUnmarshalling(t *testing.T) {
 	}
 
 }
+
+func TestLightComponentUnmarshalling(t *testing.T) {
+	components := UnmarshalComponents(map[string]interface{}{"light": map[string]interface{}{"Radius": 6, "Lit": true}, "mining": map[string]interface{}{}})
+
+	if light := components["light"].(*LightComponent); light.Radius != 6 || !light.Lit {
+		t.Errorf("Expected a lit light with a radius of 6 but was %+v", *light)
+	}
+	if _, ok := components["mining"]; !ok {
+		t.Error("Expected mining component to be present")
+	}
+}
@@ -46,11 +46,12 @@ func fetchWeaponData() {
 }
 
 type WeaponComponent struct {
-	Range    int
-	Type     WeaponType
-	Capacity *WeaponCapacity
-	Damage   Damage
-	Effects  Effects
+	Range     int
+	Type      WeaponType
+	Capacity  *WeaponCapacity
+	Damage    Damage
+	Effects   Effects
+	Condition *WeaponCondition
 }
 
 type Damage struct {
@@ -82,6 +83,16 @@ type WeaponCapacity struct {
 	loaded   int
 }
 
+// Condition of a weapon out of MaxCondition, which wears down with use
+type WeaponCondition struct {
+	condition int
+}
+
+const MaxCondition = 100
+
+// Chance of a weapon wearing each time it is used
+const wearChance = 0.5
+
 func NewWeapon(name string) *Item {
 	weapon := weaponData[name]
 
@@ -159,6 +170,36 @@ func (weaponCapacity *WeaponCapacity) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func (weaponCondition *WeaponCondition) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+
+	conditionValue, err := json.Marshal(weaponCondition.condition)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer.WriteString(fmt.Sprintf("\"Condition\":%s", conditionValue))
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (weaponCondition *WeaponCondition) UnmarshalJSON(data []byte) error {
+
+	type weaponConditionJson struct {
+		Condition int
+	}
+	var v weaponConditionJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	weaponCondition.condition = v.Condition
+
+	return nil
+}
+
 func (damage *Damage) UnmarshalJSON(data []byte) error {
 
 	type damageJson struct {
@@ -183,6 +224,11 @@ func (weapon WeaponComponent) Ranged() bool {
 	return weapon.Range > 0
 }
 
+// UsesPowder returns true for firearms, which rain can soak
+func (weapon WeaponComponent) UsesPowder() bool {
+	return weapon.Ranged() && weapon.Type != NoAmmo && weapon.Type != Bow
+}
+
 func (weapon WeaponComponent) MaxDamage() int {
 	return weapon.Damage.max()
 }
@@ -218,4 +264,69 @@ func (weapon WeaponComponent) Fire() {
 	if weapon.Capacity != nil && weapon.Capacity.loaded > 0 {
 		weapon.Capacity.loaded--
 	}
+	weapon.Wear()
+}
+
+// Wear has a chance of lowering the weapon's condition after it has been fired or swung
+func (weapon WeaponComponent) Wear() {
+	if weapon.Condition != nil && weapon.Condition.condition > 0 && rand.Float64() < wearChance {
+		weapon.Condition.condition--
+	}
+}
+
+// Condition of the weapon out of 100. Weapons without a condition never wear.
+func (weapon WeaponComponent) GetCondition() int {
+	if weapon.Condition == nil {
+		return MaxCondition
+	}
+	return weapon.Condition.condition
+}
+
+// Repair restores the weapon's condition by amount, up to limit
+func (weapon WeaponComponent) Repair(amount, limit int) {
+	if weapon.Condition != nil && weapon.Condition.condition < limit {
+		weapon.Condition.condition += amount
+		if weapon.Condition.condition > limit {
+			weapon.Condition.condition = limit
+		}
+	}
+}
+
+func (weapon WeaponComponent) NeedsRepair() bool {
+	return weapon.GetCondition() < MaxCondition
+}
+
+// Firearms in poor condition can jam, more often the worse they get
+func (weapon WeaponComponent) Jams() bool {
+	if !weapon.Ranged() || weapon.Condition == nil {
+		return false
+	}
+	return rand.Float64() < jamChance(weapon.Condition.condition)
+}
+
+func jamChance(condition int) float64 {
+	if condition >= 80 {
+		return 0
+	}
+	return float64(80-condition) / 160
+}
+
+func (weapon WeaponComponent) ConditionDescription() string {
+	switch condition := weapon.GetCondition(); {
+	case condition >= 90:
+		return "pristine"
+	case condition >= 70:
+		return "good"
+	case condition >= 40:
+		return "worn"
+	case condition > 0:
+		return "battered"
+	default:
+		return "ruined"
+	}
+}
+
+// Worn weapons are worth less, down to a quarter of their value
+func (weapon WeaponComponent) valueInCondition(value int) int {
+	return value * (25 + weapon.GetCondition()*3/4) / 100
 }
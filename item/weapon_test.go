@@ -14,11 +14,11 @@ type weaponMarshallingPair struct {
 }
 
 var weaponMarshallingTests = []weaponMarshallingPair{
-	{Item{"shotgun", "bandit", icon.NewIcon(115, 3), 20, 5000, map[string]component{"weapon": WeaponComponent{4, Shotgun, &WeaponCapacity{2, 1}, Damage{4, 1, 0}, Effects{}}}}, "{\"Name\":\"shotgun\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":115,\"Colour\":3},\"Weight\":20,\"Value\":5000,\"Components\":{\"weapon\":{\"Range\":4,\"Type\":2,\"Capacity\":{\"Capacity\":2,\"Loaded\":1},\"Damage\":{\"Dice\":4,\"Number\":1,\"Bonus\":0},\"Effects\":{}}}}"},
-	{Item{"pistol", "bandit", icon.NewIcon(112, 1), 10, 6000, map[string]component{"weapon": WeaponComponent{10, Pistol, &WeaponCapacity{6, 6}, Damage{4, 1, -1}, Effects{}}}}, "{\"Name\":\"pistol\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":112,\"Colour\":1},\"Weight\":10,\"Value\":6000,\"Components\":{\"weapon\":{\"Range\":10,\"Type\":1,\"Capacity\":{\"Capacity\":6,\"Loaded\":6},\"Damage\":{\"Dice\":4,\"Number\":1,\"Bonus\":-1},\"Effects\":{}}}}"},
-	{Item{"sawn-off shotgun", "bandit", icon.NewIcon(115, 4), 15, 3000, map[string]component{"weapon": WeaponComponent{3, Shotgun, &WeaponCapacity{2, 0}, Damage{6, 1, 0}, Effects{}}}}, "{\"Name\":\"sawn-off shotgun\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":115,\"Colour\":4},\"Weight\":15,\"Value\":3000,\"Components\":{\"weapon\":{\"Range\":3,\"Type\":2,\"Capacity\":{\"Capacity\":2,\"Loaded\":0},\"Damage\":{\"Dice\":6,\"Number\":1,\"Bonus\":0},\"Effects\":{}}}}"},
-	{Item{"baseball bat", "bandit", icon.NewIcon(98, 8), 10, 200, map[string]component{"weapon": WeaponComponent{0, NoAmmo, nil, Damage{6, 1, 0}, Effects{}}}}, "{\"Name\":\"baseball bat\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":98,\"Colour\":8},\"Weight\":10,\"Value\":200,\"Components\":{\"weapon\":{\"Range\":0,\"Type\":0,\"Capacity\":null,\"Damage\":{\"Dice\":6,\"Number\":1,\"Bonus\":0},\"Effects\":{}}}}"},
-	{Item{"poisoned knife", "bandit", icon.NewIcon(107, 8), 2, 1000, map[string]component{"weapon": WeaponComponent{0, NoAmmo, nil, Damage{6, 1, 2}, Effects{"hp": []Effect{Effect{-5, false, 2, false, true, false}}}}}}, "{\"Name\":\"poisoned knife\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":107,\"Colour\":8},\"Weight\":2,\"Value\":1000,\"Components\":{\"weapon\":{\"Range\":0,\"Type\":0,\"Capacity\":null,\"Damage\":{\"Dice\":6,\"Number\":1,\"Bonus\":2},\"Effects\":{\"hp\":[{\"Effect\":-5,\"OnMax\":false,\"Duration\":2,\"Activated\":false,\"Permanent\":true,\"Compounded\":false}]}}}}"},
+	{Item{"shotgun", "bandit", icon.NewIcon(115, 3), 20, 5000, map[string]component{"weapon": WeaponComponent{4, Shotgun, &WeaponCapacity{2, 1}, Damage{4, 1, 0}, Effects{}, &WeaponCondition{87}}}}, "{\"Name\":\"shotgun\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":115,\"Colour\":3},\"Weight\":20,\"Value\":5000,\"Components\":{\"weapon\":{\"Range\":4,\"Type\":2,\"Capacity\":{\"Capacity\":2,\"Loaded\":1},\"Damage\":{\"Dice\":4,\"Number\":1,\"Bonus\":0},\"Effects\":{},\"Condition\":{\"Condition\":87}}}}"},
+	{Item{"pistol", "bandit", icon.NewIcon(112, 1), 10, 6000, map[string]component{"weapon": WeaponComponent{10, Pistol, &WeaponCapacity{6, 6}, Damage{4, 1, -1}, Effects{}, nil}}}, "{\"Name\":\"pistol\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":112,\"Colour\":1},\"Weight\":10,\"Value\":6000,\"Components\":{\"weapon\":{\"Range\":10,\"Type\":1,\"Capacity\":{\"Capacity\":6,\"Loaded\":6},\"Damage\":{\"Dice\":4,\"Number\":1,\"Bonus\":-1},\"Effects\":{},\"Condition\":null}}}"},
+	{Item{"sawn-off shotgun", "bandit", icon.NewIcon(115, 4), 15, 3000, map[string]component{"weapon": WeaponComponent{3, Shotgun, &WeaponCapacity{2, 0}, Damage{6, 1, 0}, Effects{}, nil}}}, "{\"Name\":\"sawn-off shotgun\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":115,\"Colour\":4},\"Weight\":15,\"Value\":3000,\"Components\":{\"weapon\":{\"Range\":3,\"Type\":2,\"Capacity\":{\"Capacity\":2,\"Loaded\":0},\"Damage\":{\"Dice\":6,\"Number\":1,\"Bonus\":0},\"Effects\":{},\"Condition\":null}}}"},
+	{Item{"baseball bat", "bandit", icon.NewIcon(98, 8), 10, 200, map[string]component{"weapon": WeaponComponent{0, NoAmmo, nil, Damage{6, 1, 0}, Effects{}, nil}}}, "{\"Name\":\"baseball bat\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":98,\"Colour\":8},\"Weight\":10,\"Value\":200,\"Components\":{\"weapon\":{\"Range\":0,\"Type\":0,\"Capacity\":null,\"Damage\":{\"Dice\":6,\"Number\":1,\"Bonus\":0},\"Effects\":{},\"Condition\":null}}}"},
+	{Item{"poisoned knife", "bandit", icon.NewIcon(107, 8), 2, 1000, map[string]component{"weapon": WeaponComponent{0, NoAmmo, nil, Damage{6, 1, 2}, Effects{"hp": []Effect{Effect{-5, false, 2, false, true, false}}}, nil}}}, "{\"Name\":\"poisoned knife\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":107,\"Colour\":8},\"Weight\":2,\"Value\":1000,\"Components\":{\"weapon\":{\"Range\":0,\"Type\":0,\"Capacity\":null,\"Damage\":{\"Dice\":6,\"Number\":1,\"Bonus\":2},\"Effects\":{\"hp\":[{\"Effect\":-5,\"OnMax\":false,\"Duration\":2,\"Activated\":false,\"Permanent\":true,\"Compounded\":false}]},\"Condition\":null}}}"},
 }
 
 type weaponUnmarshallingPair struct {
@@ -27,11 +27,11 @@ type weaponUnmarshallingPair struct {
 }
 
 var weaponUnmarshallingTests = []weaponUnmarshallingPair{
-	{"{\"Name\":\"shotgun\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":115,\"Colour\":3},\"Weight\":20,\"Value\":5000,\"Components\":{\"weapon\":{\"Range\":4,\"Type\":2,\"Capacity\":{\"Capacity\":2,\"Loaded\":1},\"Damage\":{\"Dice\":4,\"Number\":1,\"Bonus\":0}}}}", Item{"shotgun", "bandit", icon.NewIcon(115, 3), 20, 5000, map[string]component{"weapon": WeaponComponent{4, Shotgun, &WeaponCapacity{2, 1}, Damage{4, 1, 0}, Effects{}}}}},
-	{"{\"Name\":\"pistol\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":112,\"Colour\":1},\"Weight\":10,\"Value\":6000,\"Components\":{\"weapon\":{\"Range\":10,\"Type\":1,\"Capacity\":{\"Capacity\":6,\"Loaded\":6},\"Damage\":{\"Dice\":4,\"Number\":1,\"Bonus\":-1}}}}", Item{"pistol", "bandit", icon.NewIcon(112, 1), 10, 6000, map[string]component{"weapon": WeaponComponent{10, Pistol, &WeaponCapacity{6, 6}, Damage{4, 1, -1}, Effects{}}}}},
-	{"{\"Name\":\"sawn-off shotgun\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":115,\"Colour\":4},\"Weight\":15,\"Value\":3000,\"Components\":{\"weapon\":{\"Range\":3,\"Type\":2,\"Capacity\":{\"Capacity\":2,\"Loaded\":0},\"Damage\":{\"Dice\":6,\"Number\":1,\"Bonus\":0}}}}", Item{"sawn-off shotgun", "bandit", icon.NewIcon(115, 4), 15, 3000, map[string]component{"weapon": WeaponComponent{3, Shotgun, &WeaponCapacity{2, 0}, Damage{6, 1, 0}, Effects{}}}}},
-	{"{\"Name\":\"baseball bat\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":98,\"Colour\":8},\"Weight\":10,\"Value\":200,\"Components\":{\"weapon\":{\"Range\":0,\"Type\":0,\"Capacity\":null,\"Damage\":{\"Dice\":6,\"Number\":1,\"Bonus\":0}}}}", Item{"baseball bat", "bandit", icon.NewIcon(98, 8), 10, 200, map[string]component{"weapon": WeaponComponent{0, NoAmmo, nil, Damage{6, 1, 0}, Effects{}}}}},
-	{"{\"Name\":\"poisoned knife\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":107,\"Colour\":8},\"Weight\":2,\"Value\":1000,\"Components\":{\"weapon\":{\"Range\":0,\"Type\":0,\"Capacity\":null,\"Damage\":{\"Dice\":6,\"Number\":1,\"Bonus\":2},\"Effects\":{\"hp\":[{\"Effect\":-5,\"OnMax\":false,\"Duration\":2,\"Activated\":false,\"Permanent\":true,\"Compounded\":false}]}}}}", Item{"poisoned knife", "bandit", icon.NewIcon(107, 8), 2, 1000, map[string]component{"weapon": WeaponComponent{0, NoAmmo, nil, Damage{6, 1, 2}, Effects{"hp": []Effect{Effect{-5, false, 2, false, true, false}}}}}}},
+	{"{\"Name\":\"shotgun\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":115,\"Colour\":3},\"Weight\":20,\"Value\":5000,\"Components\":{\"weapon\":{\"Range\":4,\"Type\":2,\"Capacity\":{\"Capacity\":2,\"Loaded\":1},\"Damage\":{\"Dice\":4,\"Number\":1,\"Bonus\":0},\"Condition\":{\"Condition\":87}}}}", Item{"shotgun", "bandit", icon.NewIcon(115, 3), 20, 5000, map[string]component{"weapon": WeaponComponent{4, Shotgun, &WeaponCapacity{2, 1}, Damage{4, 1, 0}, Effects{}, &WeaponCondition{87}}}}},
+	{"{\"Name\":\"pistol\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":112,\"Colour\":1},\"Weight\":10,\"Value\":6000,\"Components\":{\"weapon\":{\"Range\":10,\"Type\":1,\"Capacity\":{\"Capacity\":6,\"Loaded\":6},\"Damage\":{\"Dice\":4,\"Number\":1,\"Bonus\":-1}}}}", Item{"pistol", "bandit", icon.NewIcon(112, 1), 10, 6000, map[string]component{"weapon": WeaponComponent{10, Pistol, &WeaponCapacity{6, 6}, Damage{4, 1, -1}, Effects{}, nil}}}},
+	{"{\"Name\":\"sawn-off shotgun\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":115,\"Colour\":4},\"Weight\":15,\"Value\":3000,\"Components\":{\"weapon\":{\"Range\":3,\"Type\":2,\"Capacity\":{\"Capacity\":2,\"Loaded\":0},\"Damage\":{\"Dice\":6,\"Number\":1,\"Bonus\":0}}}}", Item{"sawn-off shotgun", "bandit", icon.NewIcon(115, 4), 15, 3000, map[string]component{"weapon": WeaponComponent{3, Shotgun, &WeaponCapacity{2, 0}, Damage{6, 1, 0}, Effects{}, nil}}}},
+	{"{\"Name\":\"baseball bat\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":98,\"Colour\":8},\"Weight\":10,\"Value\":200,\"Components\":{\"weapon\":{\"Range\":0,\"Type\":0,\"Capacity\":null,\"Damage\":{\"Dice\":6,\"Number\":1,\"Bonus\":0}}}}", Item{"baseball bat", "bandit", icon.NewIcon(98, 8), 10, 200, map[string]component{"weapon": WeaponComponent{0, NoAmmo, nil, Damage{6, 1, 0}, Effects{}, nil}}}},
+	{"{\"Name\":\"poisoned knife\",\"Owner\":\"bandit\",\"Icon\":{\"Icon\":107,\"Colour\":8},\"Weight\":2,\"Value\":1000,\"Components\":{\"weapon\":{\"Range\":0,\"Type\":0,\"Capacity\":null,\"Damage\":{\"Dice\":6,\"Number\":1,\"Bonus\":2},\"Effects\":{\"hp\":[{\"Effect\":-5,\"OnMax\":false,\"Duration\":2,\"Activated\":false,\"Permanent\":true,\"Compounded\":false}]}}}}", Item{"poisoned knife", "bandit", icon.NewIcon(107, 8), 2, 1000, map[string]component{"weapon": WeaponComponent{0, NoAmmo, nil, Damage{6, 1, 2}, Effects{"hp": []Effect{Effect{-5, false, 2, false, true, false}}}, nil}}}},
 }
 
 func TestWeaponMarshalling(t *testing.T) {
@@ -135,6 +135,14 @@ func TestWeaponUnmarshalling(t *testing.T) {
 			)
 		}
 
+		if weapon.components["weapon"].(WeaponComponent).GetCondition() != pair.weapon.components["weapon"].(WeaponComponent).GetCondition() {
+			t.Error(
+				"For", "Weapon Condition",
+				"expected", pair.weapon.components["weapon"].(WeaponComponent).GetCondition(),
+				"got", weapon.components["weapon"].(WeaponComponent).GetCondition(),
+			)
+		}
+
 		if weapon.components["weapon"].(WeaponComponent).Damage != pair.weapon.components["weapon"].(WeaponComponent).Damage {
 			t.Error(
 				"For", "Damage",
@@ -172,3 +180,59 @@ func TestWeaponUnmarshalling(t *testing.T) {
 		}
 	}
 }
+
+var weaponValueTests = []struct {
+	condition int
+	value     int
+}{
+	{100, 6000},
+	{60, 4200},
+	{0, 1500},
+}
+
+func TestWeaponValueInCondition(t *testing.T) {
+	for _, test := range weaponValueTests {
+		weapon := Item{"pistol", "", icon.NewIcon(112, 1), 10, 6000, map[string]component{"weapon": WeaponComponent{10, Pistol, &WeaponCapacity{6, 6}, Damage{4, 1, -1}, Effects{}, &WeaponCondition{test.condition}}}}
+		if weapon.GetValue() != test.value {
+			t.Error(
+				"For", test.condition,
+				"expected", test.value,
+				"got", weapon.GetValue(),
+			)
+		}
+	}
+}
+
+var jamChanceTests = []struct {
+	condition int
+	chance    float64
+}{
+	{100, 0},
+	{80, 0},
+	{40, 0.25},
+	{0, 0.5},
+}
+
+func TestJamChance(t *testing.T) {
+	for _, test := range jamChanceTests {
+		if chance := jamChance(test.condition); chance != test.chance {
+			t.Error(
+				"For", test.condition,
+				"expected", test.chance,
+				"got", chance,
+			)
+		}
+	}
+}
+
+func TestWeaponRepair(t *testing.T) {
+	weapon := WeaponComponent{10, Pistol, &WeaponCapacity{6, 6}, Damage{4, 1, -1}, Effects{}, &WeaponCondition{70}}
+	weapon.Repair(25, 80)
+	if weapon.GetCondition() != 80 {
+		t.Error("For", "Repair", "expected", 80, "got", weapon.GetCondition())
+	}
+	weapon.Repair(25, 80)
+	if weapon.GetCondition() != 80 {
+		t.Error("For", "Repair past limit", "expected", 80, "got", weapon.GetCondition())
+	}
+}
@@ -39,6 +39,11 @@ func PrintMessages() {
 	ui.WriteText(0, Mq.windowHeight, m)
 }
 
+// Discards any messages that have not been printed yet
+func Clear() {
+	Mq.queue = new(structs.Queue)
+}
+
 // Prints single message immediately to message bar
 func PrintMessage(m string) {
 
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/npc"
+)
+
+// How much of a need everything a creature is carrying would meet
+func carried(inventory []*item.Item, need string) int {
+	total := 0
+	for _, itm := range inventory {
+		if consumable, ok := itm.Component("consumable").(item.ConsumableComponent); ok {
+			for _, effect := range consumable.Effects[need] {
+				total -= effect.Value()
+			}
+		}
+	}
+	return total
+}
+
+func eatEverything(n *npc.Npc) {
+	for _, itm := range append([]*item.Item{}, n.Inventory()...) {
+		if itm.HasComponent("consumable") {
+			n.RemoveItem(itm)
+		}
+	}
+}
+
+func TestTownsfolkBuyFoodAndDrinkToLastUntilTheyRestock(t *testing.T) {
+	item.LoadAllData()
+	townsman := npc.NewNpc("townsman", 5, 5, nil, nil, nil, nil)
+	for _, need := range []string{"hunger", "thirst"} {
+		if got := carried(townsman.Inventory(), need); got < npc.RestockInterval {
+			t.Errorf("Expected a new townsman to carry enough for %d turns of %s but had enough for %d", npc.RestockInterval, need, got)
+		}
+	}
+
+	eatEverything(townsman)
+	money := townsman.GetMoney()
+	townsman.Restock()
+	for _, need := range []string{"hunger", "thirst"} {
+		if got := carried(townsman.Inventory(), need); got < npc.RestockInterval {
+			t.Errorf("Expected a townsman to buy enough for %d turns of %s but bought enough for %d", npc.RestockInterval, need, got)
+		}
+	}
+	if townsman.GetMoney() >= money {
+		t.Error("Expected a townsman to pay for their food and drink")
+	}
+}
+
+func TestTownsfolkWithoutMoneyGoHungry(t *testing.T) {
+	item.LoadAllData()
+	townsman := npc.NewNpc("townsman", 5, 5, nil, nil, nil, nil)
+	eatEverything(townsman)
+	townsman.RemoveMoney(townsman.GetMoney())
+	townsman.Restock()
+	for _, need := range []string{"hunger", "thirst"} {
+		if got := carried(townsman.Inventory(), need); got > 0 {
+			t.Errorf("Expected a townsman with no money to go without, but they had enough for %d turns of %s", got, need)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package npc
 
 import (
+	"math/rand"
+
 	"github.com/onorton/cowboysindians/item"
 	"github.com/onorton/cowboysindians/worldmap"
 )
@@ -61,6 +63,15 @@ func (a ConsumeAction) execute() {
 	a.c.(holdsItems).RemoveItem(a.con)
 }
 
+type SleepAction struct {
+	c    hasNeeds
+	rest int
+}
+
+func (a SleepAction) execute() {
+	a.c.rest(a.rest)
+}
+
 type OpenAction struct {
 	world *worldmap.Map
 	x, y  int
@@ -70,6 +81,20 @@ func (a OpenAction) execute() {
 	a.world.ToggleDoor(a.x, a.y, true)
 }
 
+// Chance of a bucket of water putting out the fire it is thrown on
+const douseChance = 0.5
+
+type DouseAction struct {
+	world *worldmap.Map
+	x, y  int
+}
+
+func (a DouseAction) execute() {
+	if rand.Float64() < douseChance {
+		a.world.Extinguish(a.x, a.y)
+	}
+}
+
 type RangedAttackAction struct {
 	c     hasAi
 	world *worldmap.Map
@@ -78,10 +103,21 @@ type RangedAttackAction struct {
 
 func (a RangedAttackAction) execute() {
 	itemUser := a.c.(usesItems)
-	itemUser.Weapon().Fire()
-	coverPenalty := 0
+	weapon := itemUser.Weapon()
+	// A jam wastes the round and the turn
+	if weapon.Jams() {
+		weapon.Fire()
+		return
+	}
+	weapon.Fire()
+	cX, cY := a.c.GetCoordinates()
+	if weapon.UsesPowder() && a.world.PowderWet(cX, cY) {
+		return
+	}
+	tX, tY := a.t.GetCoordinates()
+	coverPenalty := a.world.StormPenalty(cX, cY, tX, tY)
 	if a.world.TargetBehindCover(a.c, a.t) {
-		coverPenalty = 5
+		coverPenalty += 5
 	}
 	itemUser.rangedAttack(a.t, -coverPenalty)
 }
@@ -104,6 +140,13 @@ type MoveAction struct {
 }
 
 func (a MoveAction) execute() {
+	// A broken leg can give way
+	if w, ok := a.h.(hasWounds); ok && w.stumbles() {
+		return
+	}
+	if a.world.StuckInMud(a.x, a.y) || a.world.SlowGoing(a.x, a.y) {
+		return
+	}
 	c := a.h.(worldmap.Creature)
 	a.world.MoveCreature(c, a.x, a.y)
 }
@@ -117,6 +160,9 @@ type MountedMoveAction struct {
 func (a MountedMoveAction) execute() {
 	c := a.r.(worldmap.Creature)
 	a.r.Mount().Move()
+	if a.world.StuckInMud(a.x, a.y) || a.world.SlowGoing(a.x, a.y) {
+		return
+	}
 	a.world.MoveCreature(c, a.x, a.y)
 }
 
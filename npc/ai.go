@@ -46,6 +46,16 @@ type holdsItems interface {
 	RemoveItem(*item.Item)
 }
 
+type hasNeeds interface {
+	need(string) *worldmap.Attribute
+	rest(int)
+}
+
+type hasWounds interface {
+	wounded(string) bool
+	stumbles() bool
+}
+
 type usesItems interface {
 	wieldItem() bool
 	wearArmour() bool
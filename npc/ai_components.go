@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"math/rand"
 
 	"github.com/onorton/cowboysindians/event"
@@ -55,6 +56,12 @@ func newSensesComponent(attributes map[string]interface{}, otherData map[string]
 		return isWeakComponent{attributes["Threshold"].(float64)}
 	case "hasMount":
 		return hasMountComponent{}
+	case "needs":
+		return needsComponent{}
+	case "fire":
+		return fireComponent{}
+	case "weather":
+		return weatherComponent{}
 	case "wait":
 		currentWait := 0
 		return waitComponent{&currentWait, int(attributes["time"].(float64)), attributes["conditions"].(map[string]interface{})}
@@ -75,6 +82,16 @@ func newActionComponent(attributes map[string]interface{}, otherData map[string]
 		return fleeComponent{[]worldmap.Creature{}}
 	case "consume":
 		return consumeComponent{attributes["Attribute"].(string)}
+	case "sleep":
+		return sleepComponent{}
+	case "treat":
+		return treatComponent{}
+	case "douse":
+		return douseComponent{}
+	case "escapeFire":
+		return escapeFireComponent{}
+	case "shelter":
+		return shelterComponent{}
 	case "waypoint":
 		l := otherData["location"].(worldmap.Coordinates)
 		switch attributes["waypointType"] {
@@ -100,6 +117,10 @@ func newActionComponent(attributes map[string]interface{}, otherData map[string]
 			}
 			return waypointComponent{worldmap.NewPatrol(points)}
 		}
+	case "posse":
+		p := posseComponent{*(otherData["town"].(*worldmap.Town)), &alarm{}}
+		event.Subscribe(p)
+		return p
 	case "moveRandomly":
 		return moveRandomlyComponent{}
 	case "chase":
@@ -138,7 +159,12 @@ func (c bountiesComponent) ProcessEvent(e event.Event) {
 		{
 			crime := ev.Crime
 			location := crime.Location()
-			if location.X >= c.t.TownArea.X1() && location.X <= c.t.TownArea.X2() && location.Y >= c.t.TownArea.Y1() && location.Y <= c.t.TownArea.Y2() {
+			inTown := location.X >= c.t.TownArea.X1() && location.X <= c.t.TownArea.X2() && location.Y >= c.t.TownArea.Y1() && location.Y <= c.t.TownArea.Y2()
+			// Hold-ups out on the road are taken up by the sheriff of the town the coach was bound for
+			if robbery, ok := crime.(event.StagecoachRobberyEvent); ok {
+				inTown = robbery.ReportedIn() == c.t.Name
+			}
+			if inTown {
 				c.bounties.addBounty(crime)
 			}
 		}
@@ -343,7 +369,8 @@ func (c threatsComponent) nextState(currState string, ai hasAi, world *worldmap.
 		return "normal"
 	}
 
-	if (currState == "normal" || currState == "fighting") && len(c.threats(ai, world)) > 0 {
+	// Someone waiting, such as at a table, will get up to fight
+	if (currState == "normal" || currState == "wait" || currState == "shelter" || currState == "fighting") && len(c.threats(ai, world)) > 0 {
 		return "fighting"
 	}
 
@@ -566,6 +593,88 @@ func (c *hasMountComponent) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+type needsComponent struct{}
+
+func (c needsComponent) nextState(currState string, ai hasAi, world *worldmap.Map) string {
+	n, ok := ai.(hasNeeds)
+	if !ok {
+		return ""
+	}
+
+	if currState == "normal" && worldmap.Level(n.need("fatigue")) >= worldmap.Severe {
+		return "sleeping"
+	}
+
+	if currState == "sleeping" {
+		fatigue := n.need("fatigue")
+		if fatigue == nil || fatigue.Value() == 0 || worldmap.Level(n.need("hunger")) == worldmap.Critical || worldmap.Level(n.need("thirst")) == worldmap.Critical {
+			return "normal"
+		}
+	}
+	return ""
+}
+
+func (c needsComponent) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{\"Type\": \"needs\"}")
+	return buffer.Bytes(), nil
+}
+
+func (c *needsComponent) UnmarshalJSON(data []byte) error {
+	return nil
+}
+
+type fireComponent struct{}
+
+func (c fireComponent) nextState(currState string, ai hasAi, world *worldmap.Map) string {
+	if len(visibleFires(ai, world)) > 0 {
+		return "fire"
+	}
+
+	if currState == "fire" {
+		return "normal"
+	}
+	return ""
+}
+
+func (c fireComponent) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{\"Type\": \"fire\"}")
+	return buffer.Bytes(), nil
+}
+
+func (c *fireComponent) UnmarshalJSON(data []byte) error {
+	return nil
+}
+
+// How far townsfolk will go to get out of the weather
+const shelterDistance = 40
+
+// Townsfolk head indoors when it rains or a dust storm blows in, as long as there is somewhere nearby to go
+type weatherComponent struct{}
+
+func (c weatherComponent) nextState(currState string, ai hasAi, world *worldmap.Map) string {
+	aiX, aiY := ai.GetCoordinates()
+	foul := world.Foul(aiX, aiY)
+	if currState == "normal" && foul && !world.Sheltered(aiX, aiY) {
+		if shelter, ok := world.NearestShelter(aiX, aiY); ok && worldmap.Distance(aiX, aiY, shelter.X, shelter.Y) <= shelterDistance {
+			return "shelter"
+		}
+	}
+
+	if currState == "shelter" && !foul {
+		return "normal"
+	}
+	return ""
+}
+
+func (c weatherComponent) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{\"Type\": \"weather\"}")
+	return buffer.Bytes(), nil
+}
+
+func (c *weatherComponent) UnmarshalJSON(data []byte) error {
+	return nil
+}
+
 type waitComponent struct {
 	currentWait *int
 	waitTime    int
@@ -765,6 +874,13 @@ type consumeComponent struct {
 }
 
 func (c consumeComponent) action(ai hasAi, world *worldmap.Map) Action {
+	// Only eat or drink once it is needed
+	if c.attribute != "hp" {
+		if n, ok := ai.(hasNeeds); !ok || worldmap.Level(n.need(c.attribute)) == worldmap.Satisfied {
+			return nil
+		}
+	}
+
 	if itemHolder, ok := ai.(holdsItems); ok {
 		for _, itm := range itemHolder.Inventory() {
 			if consumable, ok := itm.Component("consumable").(item.ConsumableComponent); ok && len(consumable.Effects[c.attribute]) > 0 {
@@ -776,8 +892,15 @@ func (c consumeComponent) action(ai hasAi, world *worldmap.Map) Action {
 }
 
 func (c consumeComponent) shouldHappen(state string) float64 {
-	if state == "fleeing" {
-		return 1
+	if c.attribute == "hp" {
+		if state == "fleeing" {
+			return 1
+		}
+		return 0
+	}
+
+	if state == "normal" {
+		return 0.9
 	}
 	return 0
 }
@@ -811,6 +934,218 @@ func (c *consumeComponent) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+type sleepComponent struct{}
+
+func (c sleepComponent) action(ai hasAi, world *worldmap.Map) Action {
+	if n, ok := ai.(hasNeeds); ok {
+		aiX, aiY := ai.GetCoordinates()
+		return SleepAction{n, world.Rest(aiX, aiY)}
+	}
+	return nil
+}
+
+func (c sleepComponent) shouldHappen(state string) float64 {
+	if state == "sleeping" {
+		return 1
+	}
+	return 0
+}
+
+func (c sleepComponent) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{\"Type\": \"sleep\"}")
+	return buffer.Bytes(), nil
+}
+
+func (c *sleepComponent) UnmarshalJSON(data []byte) error {
+	return nil
+}
+
+type treatComponent struct{}
+
+func (c treatComponent) action(ai hasAi, world *worldmap.Map) Action {
+	w, ok := ai.(hasWounds)
+	if !ok {
+		return nil
+	}
+
+	if itemHolder, ok := ai.(holdsItems); ok {
+		for _, kind := range worldmap.WoundKinds {
+			if !w.wounded(kind) {
+				continue
+			}
+			for _, itm := range itemHolder.Inventory() {
+				if treatment, ok := itm.Component("treatment").(item.TreatmentComponent); ok && treatment.Treats(kind) {
+					return ConsumeAction{ai, itm}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c treatComponent) shouldHappen(state string) float64 {
+	if state == "sleeping" {
+		return 0
+	}
+	return 1
+}
+
+func (c treatComponent) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{\"Type\": \"treat\"}")
+	return buffer.Bytes(), nil
+}
+
+func (c *treatComponent) UnmarshalJSON(data []byte) error {
+	return nil
+}
+
+// Townsfolk fight fires, getting out of the flames and then throwing buckets of water on them
+type douseComponent struct{}
+
+func (c douseComponent) action(ai hasAi, world *worldmap.Map) Action {
+	fires := visibleFires(ai, world)
+	if len(fires) == 0 {
+		return nil
+	}
+
+	aiX, aiY := ai.GetCoordinates()
+	if world.IsBurning(aiX, aiY) {
+		return escapeFire(ai, world, fires)
+	}
+
+	for _, f := range fires {
+		if math.Abs(float64(f.X-aiX)) <= 1 && math.Abs(float64(f.Y-aiY)) <= 1 {
+			return DouseAction{world, f.X, f.Y}
+		}
+	}
+
+	// Join the bucket line
+	fireMap := generateMap(ai, world, fires)
+	return move(ai, world, possibleLocationsFromAiMap(ai, world, fireMap, safeTile(world)))
+}
+
+func (c douseComponent) shouldHappen(state string) float64 {
+	if state == "fire" {
+		return 1
+	}
+	return 0
+}
+
+func (c douseComponent) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{\"Type\": \"douse\"}")
+	return buffer.Bytes(), nil
+}
+
+func (c *douseComponent) UnmarshalJSON(data []byte) error {
+	return nil
+}
+
+type escapeFireComponent struct{}
+
+func (c escapeFireComponent) action(ai hasAi, world *worldmap.Map) Action {
+	fires := visibleFires(ai, world)
+	if len(fires) == 0 {
+		return nil
+	}
+	return escapeFire(ai, world, fires)
+}
+
+func (c escapeFireComponent) shouldHappen(state string) float64 {
+	if state == "fire" {
+		return 1
+	}
+	return 0
+}
+
+func (c escapeFireComponent) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{\"Type\": \"escapeFire\"}")
+	return buffer.Bytes(), nil
+}
+
+func (c *escapeFireComponent) UnmarshalJSON(data []byte) error {
+	return nil
+}
+
+// Makes for the nearest building and stays indoors until the weather passes
+type shelterComponent struct{}
+
+func (c shelterComponent) action(ai hasAi, world *worldmap.Map) Action {
+	aiX, aiY := ai.GetCoordinates()
+	if world.Sheltered(aiX, aiY) {
+		return NoAction{}
+	}
+
+	shelter, ok := world.NearestShelter(aiX, aiY)
+	if !ok {
+		return nil
+	}
+	shelterMap := getWaypointMap(ai, shelter, world)
+
+	tileUnoccupied := func(x, y int) bool {
+		return !world.IsOccupied(x, y)
+	}
+	locations := possibleLocationsFromAiMap(ai, world, shelterMap, tileUnoccupied)
+
+	if action := moveIfMounted(ai, world, locations); action != nil {
+		return action
+	}
+	return move(ai, world, locations)
+}
+
+func (c shelterComponent) shouldHappen(state string) float64 {
+	if state == "shelter" {
+		return 1
+	}
+	return 0
+}
+
+func (c shelterComponent) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{\"Type\": \"shelter\"}")
+	return buffer.Bytes(), nil
+}
+
+func (c *shelterComponent) UnmarshalJSON(data []byte) error {
+	return nil
+}
+
+func visibleFires(c hasAi, world *worldmap.Map) []worldmap.Coordinates {
+	d := c.GetVisionDistance()
+	cX, cY := c.GetCoordinates()
+
+	fires := make([]worldmap.Coordinates, 0)
+	for i := -d; i < d+1; i++ {
+		for j := -d; j < d+1; j++ {
+			wX, wY := cX+j, cY+i
+			if world.IsValid(wX, wY) && world.IsBurning(wX, wY) && world.IsVisible(c, wX, wY) {
+				fires = append(fires, worldmap.Coordinates{wX, wY})
+			}
+		}
+	}
+	return fires
+}
+
+func safeTile(world *worldmap.Map) func(int, int) bool {
+	return func(x, y int) bool {
+		return !world.IsOccupied(x, y) && world.IsPassable(x, y) && !world.IsBurning(x, y)
+	}
+}
+
+// Moves away from fires, never through them
+func escapeFire(c hasAi, world *worldmap.Map, fires []worldmap.Coordinates) Action {
+	fleeMap := generateMap(c, world, fires)
+	for y := 0; y < len(fleeMap); y++ {
+		for x := 0; x < len(fleeMap[0]); x++ {
+			fleeMap[y][x] = -fleeMap[y][x]
+		}
+	}
+	locations := possibleLocationsFromAiMap(c, world, fleeMap, safeTile(world))
+
+	if action := moveIfMounted(c, world, locations); action != nil {
+		return action
+	}
+	return move(c, world, locations)
+}
+
 type waypointComponent struct {
 	waypoint worldmap.WaypointSystem
 }
@@ -873,6 +1208,110 @@ func (c *waypointComponent) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Distance from where the alarm was raised at which a posse starts searching for the robber
+const posseArrivalDistance = 5
+
+// Where the alarm was raised after a robbery
+type alarm struct {
+	Raised   bool
+	Location worldmap.Coordinates
+}
+
+// posseComponent rides out to wherever an alarm is raised in town
+type posseComponent struct {
+	t     worldmap.Town
+	alarm *alarm
+}
+
+func (c posseComponent) ProcessEvent(e event.Event) {
+	switch ev := e.(type) {
+	case event.WitnessedCrimeEvent:
+		if _, ok := ev.Crime.(event.RobberyEvent); ok {
+			location := ev.Crime.Location()
+			if location.X >= c.t.TownArea.X1() && location.X <= c.t.TownArea.X2() && location.Y >= c.t.TownArea.Y1() && location.Y <= c.t.TownArea.Y2() {
+				c.alarm.Raised = true
+				c.alarm.Location = location
+			}
+		}
+	}
+}
+
+func (c posseComponent) action(ai hasAi, world *worldmap.Map) Action {
+	if !c.alarm.Raised {
+		return nil
+	}
+
+	// Once there, the robber has to be found the usual way
+	aiX, aiY := ai.GetCoordinates()
+	if worldmap.Distance(aiX, aiY, c.alarm.Location.X, c.alarm.Location.Y) <= posseArrivalDistance {
+		c.alarm.Raised = false
+		return nil
+	}
+
+	alarmMap := getWaypointMap(ai, c.alarm.Location, world)
+
+	tileUnoccupied := func(x, y int) bool {
+		return !world.IsOccupied(x, y)
+	}
+
+	locations := possibleLocationsFromAiMap(ai, world, alarmMap, tileUnoccupied)
+	if action := moveIfMounted(ai, world, locations); action != nil {
+		return action
+	}
+
+	if action := move(ai, world, locations); action != nil {
+		return action
+	}
+	return nil
+}
+
+func (c posseComponent) shouldHappen(state string) float64 {
+	if state == "normal" {
+		return 0.6
+	}
+	return 0
+}
+
+func (c posseComponent) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+
+	buffer.WriteString("\"Type\": \"posse\",")
+
+	townValue, err := json.Marshal(c.t)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Town\":%s,", townValue))
+
+	alarmValue, err := json.Marshal(c.alarm)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Alarm\":%s", alarmValue))
+
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (c *posseComponent) UnmarshalJSON(data []byte) error {
+	type posseJSON struct {
+		Town  worldmap.Town
+		Alarm *alarm
+	}
+
+	var v posseJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	c.t = v.Town
+	c.alarm = v.Alarm
+	if c.alarm == nil {
+		c.alarm = &alarm{}
+	}
+	return nil
+}
+
 type moveRandomlyComponent struct {
 	moveRandomly worldmap.WaypointSystem
 }
@@ -1335,6 +1774,21 @@ func unmarshalSenses(cs []map[string]interface{}) []senses {
 			err := json.Unmarshal(componentJSON, &hasMount)
 			check(err)
 			component = hasMount
+		case "needs":
+			var needs needsComponent
+			err := json.Unmarshal(componentJSON, &needs)
+			check(err)
+			component = needs
+		case "fire":
+			var fire fireComponent
+			err := json.Unmarshal(componentJSON, &fire)
+			check(err)
+			component = fire
+		case "weather":
+			var weather weatherComponent
+			err := json.Unmarshal(componentJSON, &weather)
+			check(err)
+			component = weather
 		case "wait":
 			var wait waitComponent
 			err := json.Unmarshal(componentJSON, &wait)
@@ -1379,11 +1833,42 @@ func unmarshalActions(cs []map[string]interface{}) []hasAction {
 			err := json.Unmarshal(componentJSON, &consume)
 			check(err)
 			component = consume
+		case "sleep":
+			var sleep sleepComponent
+			err := json.Unmarshal(componentJSON, &sleep)
+			check(err)
+			component = sleep
+		case "treat":
+			var treat treatComponent
+			err := json.Unmarshal(componentJSON, &treat)
+			check(err)
+			component = treat
+		case "douse":
+			var douse douseComponent
+			err := json.Unmarshal(componentJSON, &douse)
+			check(err)
+			component = douse
+		case "escapeFire":
+			var escapeFire escapeFireComponent
+			err := json.Unmarshal(componentJSON, &escapeFire)
+			check(err)
+			component = escapeFire
+		case "shelter":
+			var shelter shelterComponent
+			err := json.Unmarshal(componentJSON, &shelter)
+			check(err)
+			component = shelter
 		case "waypoint":
 			var waypoint waypointComponent
 			err := json.Unmarshal(componentJSON, &waypoint)
 			check(err)
 			component = waypoint
+		case "posse":
+			var posse posseComponent
+			err := json.Unmarshal(componentJSON, &posse)
+			check(err)
+			component = posse
+			event.Subscribe(posse)
 		case "moveRandomly":
 			var moveRandomly moveRandomlyComponent
 			err := json.Unmarshal(componentJSON, &moveRandomly)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/onorton/cowboysindians/event"
@@ -22,6 +23,7 @@ func (b bounty) String() string {
 	for c := range b.crimes {
 		crimes = append(crimes, c)
 	}
+	sort.Strings(crimes)
 
 	return fmt.Sprintf("%s - %s - $%.2f", b.criminalName, strings.Join(crimes, ", "), float64(b.value)/100)
 }
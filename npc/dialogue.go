@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"strings"
 
+	"github.com/onorton/cowboysindians/event"
 	"github.com/onorton/cowboysindians/message"
 	"github.com/onorton/cowboysindians/worldmap"
 )
@@ -19,6 +20,10 @@ const (
 	Shopkeeper
 	Sheriff
 	EnemyDialogue
+	Doctor
+	Bank
+	Gambler
+	Station
 )
 
 type interaction int
@@ -28,6 +33,10 @@ const (
 	Trade
 	Bounty
 	DoesNotSpeak
+	Treatment
+	Banking
+	Gambling
+	Tickets
 )
 
 var dialogueData map[string][]string = fetchDialogueData()
@@ -50,11 +59,19 @@ func newDialogue(dialogueType *dialogueType, world *worldmap.Map, t *worldmap.To
 	case Basic:
 		return &basicDialogue{false}
 	case Shopkeeper:
-		return &shopkeeperDialogue{false, world, *b, *t}
+		return &shopkeeperDialogue{false, world, *b, *t, make(map[string]int), 0, make([]string, 0)}
 	case Sheriff:
 		return &sheriffDialogue{false, world, *b, *t}
 	case EnemyDialogue:
 		return &enemyDialogue{false}
+	case Doctor:
+		return &doctorDialogue{false, world, *b, *t}
+	case Bank:
+		return &bankDialogue{false, world, *b, *t}
+	case Gambler:
+		return &gamblerDialogue{false}
+	case Station:
+		return &stationDialogue{false, world, *b, *t}
 	}
 	return &basicDialogue{false}
 }
@@ -120,6 +137,12 @@ type shopkeeperDialogue struct {
 	world      *worldmap.Map
 	b          worldmap.Building
 	t          worldmap.Town
+	// How many more of each item the shop has than usual, from what has been bought and sold
+	supply map[string]int
+	// Deals done with the player
+	trades int
+	// Ids of those known to have stolen in town, who the shopkeeper won't deal with
+	thieves []string
 }
 
 func (d *shopkeeperDialogue) initialGreeting() {
@@ -139,10 +162,37 @@ func (d *shopkeeperDialogue) initialGreeting() {
 }
 
 func (d *shopkeeperDialogue) interact() interaction {
+	if d.knowsThief(d.world.GetPlayer().GetID()) {
+		message.PrintMessage("\"I don't deal with thieves. Get out of my store.\"")
+		return Normal
+	}
 	message.PrintMessage("\"Sure. Feel free to look around.\"")
 	return Trade
 }
 
+// Word of theft in town gets back to the shopkeeper
+func (d *shopkeeperDialogue) hearOfCrime(crime event.CrimeEvent) {
+	switch crime.Crime() {
+	case "Theft", "Pickpocketing", "Bank robbery":
+	default:
+		return
+	}
+
+	location := crime.Location()
+	if location.X >= d.t.TownArea.X1() && location.X <= d.t.TownArea.X2() && location.Y >= d.t.TownArea.Y1() && location.Y <= d.t.TownArea.Y2() && !d.knowsThief(crime.Perpetrator()) {
+		d.thieves = append(d.thieves, crime.Perpetrator())
+	}
+}
+
+func (d *shopkeeperDialogue) knowsThief(id string) bool {
+	for _, thief := range d.thieves {
+		if thief == id {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *shopkeeperDialogue) resetSeen() {
 	pX, pY := d.world.GetPlayer().GetCoordinates()
 
@@ -181,7 +231,20 @@ func (d *shopkeeperDialogue) MarshalJSON() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	buffer.WriteString(fmt.Sprintf("\"Town\":%s", townValue))
+	buffer.WriteString(fmt.Sprintf("\"Town\":%s,", townValue))
+
+	supplyValue, err := json.Marshal(d.supply)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Supply\":%s,", supplyValue))
+	buffer.WriteString(fmt.Sprintf("\"Trades\":%d,", d.trades))
+
+	thievesValue, err := json.Marshal(d.thieves)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Thieves\":%s", thievesValue))
 	buffer.WriteString("}")
 
 	return buffer.Bytes(), nil
@@ -193,6 +256,9 @@ func (sd *shopkeeperDialogue) UnmarshalJSON(data []byte) error {
 		SeenPlayer bool
 		Building   worldmap.Building
 		Town       worldmap.Town
+		Supply     map[string]int
+		Trades     int
+		Thieves    []string
 	}
 
 	var v sdJson
@@ -203,6 +269,15 @@ func (sd *shopkeeperDialogue) UnmarshalJSON(data []byte) error {
 	sd.seenPlayer = v.SeenPlayer
 	sd.b = v.Building
 	sd.t = v.Town
+	sd.supply = v.Supply
+	if sd.supply == nil {
+		sd.supply = make(map[string]int)
+	}
+	sd.trades = v.Trades
+	sd.thieves = v.Thieves
+	if sd.thieves == nil {
+		sd.thieves = make([]string, 0)
+	}
 
 	return nil
 }
@@ -296,6 +371,327 @@ func (sd *sheriffDialogue) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+type doctorDialogue struct {
+	seenPlayer bool
+	world      *worldmap.Map
+	b          worldmap.Building
+	t          worldmap.Town
+}
+
+func (d *doctorDialogue) initialGreeting() {
+	pX, pY := d.world.GetPlayer().GetCoordinates()
+	if !d.seenPlayer && d.b.Inside(pX, pY) {
+		dialogue := choose(dialogueData["Greetings"]) + " " + choose(dialogueData["Doctor"])
+		dialogue = addTownToDialogue(dialogue, d.t.Name)
+		message.Enqueue(fmt.Sprintf("\"%s\"", dialogue))
+		d.seenPlayer = true
+	}
+	if d.seenPlayer && !d.b.Inside(pX, pY) {
+		message.Enqueue("\"Try not to get yourself shot.\"")
+		d.seenPlayer = false
+	}
+}
+
+func (d *doctorDialogue) interact() interaction {
+	message.PrintMessage("\"Let's have a look at you.\"")
+	return Treatment
+}
+
+func (d *doctorDialogue) resetSeen() {
+	pX, pY := d.world.GetPlayer().GetCoordinates()
+
+	// If player has not left the surgery but is currently not visible, do not reset
+	if !d.b.Inside(pX, pY) {
+		d.seenPlayer = false
+	}
+}
+
+func (d *doctorDialogue) setMap(world *worldmap.Map) {
+	d.world = world
+}
+
+func (d *doctorDialogue) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+
+	typeValue, err := json.Marshal(Doctor)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Type\":%s,", typeValue))
+
+	seenPlayerValue, err := json.Marshal(d.seenPlayer)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"SeenPlayer\":%s,", seenPlayerValue))
+
+	buildingValue, err := json.Marshal(d.b)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Building\":%s,", buildingValue))
+
+	townValue, err := json.Marshal(d.t)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Town\":%s", townValue))
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (dd *doctorDialogue) UnmarshalJSON(data []byte) error {
+
+	type ddJson struct {
+		SeenPlayer bool
+		Building   worldmap.Building
+		Town       worldmap.Town
+	}
+
+	var v ddJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	dd.seenPlayer = v.SeenPlayer
+	dd.b = v.Building
+	dd.t = v.Town
+	return nil
+}
+
+type bankDialogue struct {
+	seenPlayer bool
+	world      *worldmap.Map
+	b          worldmap.Building
+	t          worldmap.Town
+}
+
+func (d *bankDialogue) initialGreeting() {
+	pX, pY := d.world.GetPlayer().GetCoordinates()
+	if !d.seenPlayer && d.b.Inside(pX, pY) {
+		dialogue := choose(dialogueData["Greetings"]) + " " + choose(dialogueData["Bank"])
+		dialogue = addTownToDialogue(dialogue, d.t.Name)
+		message.Enqueue(fmt.Sprintf("\"%s\"", dialogue))
+		d.seenPlayer = true
+	}
+	if d.seenPlayer && !d.b.Inside(pX, pY) {
+		message.Enqueue("\"Come back soon.\"")
+		d.seenPlayer = false
+	}
+}
+
+func (d *bankDialogue) interact() interaction {
+	message.PrintMessage("\"How can I help you today?\"")
+	return Banking
+}
+
+func (d *bankDialogue) resetSeen() {
+	pX, pY := d.world.GetPlayer().GetCoordinates()
+
+	// If player has not left the bank but is currently not visible, do not reset
+	if !d.b.Inside(pX, pY) {
+		d.seenPlayer = false
+	}
+}
+
+func (d *bankDialogue) setMap(world *worldmap.Map) {
+	d.world = world
+}
+
+func (d *bankDialogue) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+
+	typeValue, err := json.Marshal(Bank)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Type\":%s,", typeValue))
+
+	seenPlayerValue, err := json.Marshal(d.seenPlayer)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"SeenPlayer\":%s,", seenPlayerValue))
+
+	buildingValue, err := json.Marshal(d.b)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Building\":%s,", buildingValue))
+
+	townValue, err := json.Marshal(d.t)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Town\":%s", townValue))
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (bd *bankDialogue) UnmarshalJSON(data []byte) error {
+
+	type bdJson struct {
+		SeenPlayer bool
+		Building   worldmap.Building
+		Town       worldmap.Town
+	}
+
+	var v bdJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	bd.seenPlayer = v.SeenPlayer
+	bd.b = v.Building
+	bd.t = v.Town
+	return nil
+}
+
+type stationDialogue struct {
+	seenPlayer bool
+	world      *worldmap.Map
+	b          worldmap.Building
+	t          worldmap.Town
+}
+
+func (d *stationDialogue) initialGreeting() {
+	pX, pY := d.world.GetPlayer().GetCoordinates()
+	if !d.seenPlayer && d.b.Inside(pX, pY) {
+		dialogue := choose(dialogueData["Greetings"]) + " " + choose(dialogueData["Station"])
+		dialogue = addTownToDialogue(dialogue, d.t.Name)
+		message.Enqueue(fmt.Sprintf("\"%s\"", dialogue))
+		d.seenPlayer = true
+	}
+	if d.seenPlayer && !d.b.Inside(pX, pY) {
+		message.Enqueue("\"Safe travels.\"")
+		d.seenPlayer = false
+	}
+}
+
+func (d *stationDialogue) interact() interaction {
+	message.PrintMessage("\"Where are you headed?\"")
+	return Tickets
+}
+
+func (d *stationDialogue) resetSeen() {
+	pX, pY := d.world.GetPlayer().GetCoordinates()
+
+	// If player has not left the station but is currently not visible, do not reset
+	if !d.b.Inside(pX, pY) {
+		d.seenPlayer = false
+	}
+}
+
+func (d *stationDialogue) setMap(world *worldmap.Map) {
+	d.world = world
+}
+
+func (d *stationDialogue) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+
+	typeValue, err := json.Marshal(Station)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Type\":%s,", typeValue))
+
+	seenPlayerValue, err := json.Marshal(d.seenPlayer)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"SeenPlayer\":%s,", seenPlayerValue))
+
+	buildingValue, err := json.Marshal(d.b)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Building\":%s,", buildingValue))
+
+	townValue, err := json.Marshal(d.t)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Town\":%s", townValue))
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (sd *stationDialogue) UnmarshalJSON(data []byte) error {
+
+	type sdJson struct {
+		SeenPlayer bool
+		Building   worldmap.Building
+		Town       worldmap.Town
+	}
+
+	var v sdJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	sd.seenPlayer = v.SeenPlayer
+	sd.b = v.Building
+	sd.t = v.Town
+	return nil
+}
+
+type gamblerDialogue struct {
+	seenPlayer bool
+}
+
+func (d *gamblerDialogue) initialGreeting() {
+	if !d.seenPlayer {
+		message.Enqueue(fmt.Sprintf("\"%s\"", choose(dialogueData["Greetings"])))
+		d.seenPlayer = true
+	}
+}
+
+func (d *gamblerDialogue) interact() interaction {
+	message.PrintMessage(fmt.Sprintf("\"%s\"", choose(dialogueData["Gambler"])))
+	return Gambling
+}
+
+func (d *gamblerDialogue) resetSeen() {
+	d.seenPlayer = false
+}
+
+func (d *gamblerDialogue) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	typeValue, err := json.Marshal(Gambler)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Type\":%s,", typeValue))
+
+	seenPlayerValue, err := json.Marshal(d.seenPlayer)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"SeenPlayer\":%s", seenPlayerValue))
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (d *gamblerDialogue) UnmarshalJSON(data []byte) error {
+
+	type gdJson struct {
+		SeenPlayer bool
+	}
+
+	var v gdJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	d.seenPlayer = v.SeenPlayer
+
+	return nil
+}
+
 type enemyDialogue struct {
 	seenPlayer bool
 }
@@ -393,6 +789,26 @@ func unmarshalDialogue(dialogue map[string]interface{}) dialogue {
 		err = json.Unmarshal(dialogueJson, &ed)
 		check(err)
 		return &ed
+	case Doctor:
+		var dd doctorDialogue
+		err = json.Unmarshal(dialogueJson, &dd)
+		check(err)
+		return &dd
+	case Bank:
+		var bd bankDialogue
+		err = json.Unmarshal(dialogueJson, &bd)
+		check(err)
+		return &bd
+	case Gambler:
+		var gd gamblerDialogue
+		err = json.Unmarshal(dialogueJson, &gd)
+		check(err)
+		return &gd
+	case Station:
+		var sd stationDialogue
+		err = json.Unmarshal(dialogueJson, &sd)
+		check(err)
+		return &sd
 	}
 	return nil
 }
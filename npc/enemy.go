@@ -17,6 +17,9 @@ type EnemyAttributes struct {
 	Ac           int
 	Str          int
 	Dex          int
+	Con          int
+	Cha          int
+	Per          int
 	Encumbrance  int
 	Money        int
 	Unarmed      item.WeaponComponent
@@ -59,10 +62,15 @@ func NewEnemy(enemyType string, x, y int, world *worldmap.Map) *Npc {
 		"str":         worldmap.NewAttribute(enemy.Str, enemy.Str),
 		"dex":         worldmap.NewAttribute(enemy.Dex, enemy.Dex),
 		"encumbrance": worldmap.NewAttribute(enemy.Encumbrance, enemy.Encumbrance)}
+	addScores(attributes, enemy.Con, enemy.Cha, enemy.Per)
+	if enemy.Human {
+		addNeeds(attributes)
+	}
 	name := generateName(enemyType, enemy.Human)
-	e := &Npc{name, id, worldmap.Coordinates{x, y}, enemy.Icon, enemy.Initiative, attributes, worldmap.Enemy, false, enemy.Money, enemy.Unarmed, nil, nil, make([]*item.Item, 0), nil, "", generateMount(enemy.Mount, x, y), world, ai, dialogue, enemy.Human}
+	e := &Npc{name, id, worldmap.Coordinates{x, y}, worldmap.Surface, enemy.Icon, enemy.Initiative, attributes, worldmap.Enemy, false, enemy.Money, enemy.Unarmed, nil, nil, make([]*item.Item, 0), nil, "", generateMount(enemy.Mount, x, y), world, ai, dialogue, enemy.Human, worldmap.NewWounds()}
 	for _, itm := range generateInventory(enemy.Inventory) {
 		e.PickupItem(itm)
 	}
+	e.provision()
 	return e
 }
@@ -47,8 +47,9 @@ func NewMount(name string, x, y int, world *worldmap.Map) *Npc {
 		"str":         worldmap.NewAttribute(mount.Str, mount.Str),
 		"dex":         worldmap.NewAttribute(mount.Dex, mount.Dex),
 		"encumbrance": worldmap.NewAttribute(mount.Encumbrance, mount.Encumbrance)}
+	worldmap.AddAttributes(attributes)
 
-	npc := &Npc{&ui.PlainName{name}, id, worldmap.Coordinates{x, y}, mount.Icon, mount.Initiative, attributes, worldmap.Neutral, false, 0, mount.Unarmed, nil, nil, make([]*item.Item, 0), &mountableComponent{}, "", nil, world, ai, nil, false}
+	npc := &Npc{&ui.PlainName{name}, id, worldmap.Coordinates{x, y}, worldmap.Surface, mount.Icon, mount.Initiative, attributes, worldmap.Neutral, false, 0, mount.Unarmed, nil, nil, make([]*item.Item, 0), &mountableComponent{}, "", nil, world, ai, nil, false, worldmap.NewWounds()}
 
 	event.Subscribe(npc)
 	return npc
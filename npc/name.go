@@ -13,6 +13,8 @@ type nameData struct {
 	FirstNames []string
 	LastNames  []string
 	Towns      map[string][]string
+	Mines      []string
+	Caves      []string
 }
 
 var Names nameData = fetchNameData()
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"sort"
 
 	"github.com/onorton/cowboysindians/event"
 	"github.com/onorton/cowboysindians/icon"
@@ -23,23 +24,25 @@ func check(err error) {
 }
 
 type NpcAttributes struct {
-	Icon          icon.Icon
-	Initiative    int
-	Hp            int
-	Ac            int
-	Str           int
-	Dex           int
-	Encumbrance   int
-	Money         int
-	Unarmed       item.WeaponComponent
-	Inventory     [][]item.ItemChoice
-	ShopInventory map[string]int
-	DialogueType  *dialogueType
-	AiType        string
-	Mount         map[string]float64
-	Protector     map[string]float64
-	Probability   float64
-	Human         bool
+	Icon         icon.Icon
+	Initiative   int
+	Hp           int
+	Ac           int
+	Str          int
+	Dex          int
+	Con          int
+	Cha          int
+	Per          int
+	Encumbrance  int
+	Money        int
+	Unarmed      item.WeaponComponent
+	Inventory    [][]item.ItemChoice
+	DialogueType *dialogueType
+	AiType       string
+	Mount        map[string]float64
+	Protector    map[string]float64
+	Probability  float64
+	Human        bool
 }
 
 var npcData map[string]NpcAttributes = fetchNpcData()
@@ -86,6 +89,16 @@ func RandomNpcTypeFromSelection(npcTypes []string) string {
 	return chooseType(probabilities)
 }
 
+// Names are sorted so that the same choice is made for the same random number
+func sortedNames(probabilities map[string]float64) []string {
+	names := make([]string, 0, len(probabilities))
+	for name := range probabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func chooseType(probabilities map[string]float64) string {
 	max := 0.0
 
@@ -99,7 +112,8 @@ func chooseType(probabilities map[string]float64) string {
 	}
 	possibleTypes := make([]string, 0)
 
-	for name, probability := range probabilities {
+	for _, name := range sortedNames(probabilities) {
+		probability := probabilities[name]
 		count := int(probability * max)
 		for i := 0; i < count; i++ {
 			possibleTypes = append(possibleTypes, name)
@@ -129,7 +143,8 @@ func AddProtector(npcType string, x, y int, protectee string) *Npc {
 	}
 
 	protectors := make([]string, 0)
-	for name, probability := range probabilities {
+	for _, name := range sortedNames(probabilities) {
+		probability := probabilities[name]
 		count := int(probability * max)
 		for i := 0; i < count; i++ {
 			protectors = append(protectors, name)
@@ -156,29 +171,22 @@ func NewNpc(npcType string, x, y int, world *worldmap.Map, t *worldmap.Town, b *
 		"str":         worldmap.NewAttribute(n.Str, n.Str),
 		"dex":         worldmap.NewAttribute(n.Dex, n.Dex),
 		"encumbrance": worldmap.NewAttribute(n.Encumbrance, n.Encumbrance)}
+	addScores(attributes, n.Con, n.Cha, n.Per)
+	if n.Human {
+		addNeeds(attributes)
+	}
 
-	npc := &Npc{generateName(npcType, n.Human), id, worldmap.Coordinates{x, y}, n.Icon, n.Initiative, attributes, worldmap.Neutral, false, n.Money, n.Unarmed, nil, nil, make([]*item.Item, 0), nil, "", generateMount(n.Mount, x, y), world, ai, dialogue, n.Human}
-	for c, count := range n.ShopInventory {
-
-		for i := 0; i < count; i++ {
-			switch c {
-			case "Ammo":
-				npc.PickupItem(item.GenerateAmmo())
-			case "Armour":
-				npc.PickupItem(item.GenerateArmour())
-			case "Consumable":
-				npc.PickupItem(item.GenerateConsumable())
-			case "Item":
-				npc.PickupItem(item.GenerateItem())
-			case "Weapon":
-				npc.PickupItem(item.GenerateWeapon())
-			}
-		}
+	npc := &Npc{generateName(npcType, n.Human), id, worldmap.Coordinates{x, y}, worldmap.Surface, n.Icon, n.Initiative, attributes, worldmap.Neutral, false, n.Money, n.Unarmed, nil, nil, make([]*item.Item, 0), nil, "", generateMount(n.Mount, x, y), world, ai, dialogue, n.Human, worldmap.NewWounds()}
+	if d, ok := dialogue.(*shopkeeperDialogue); ok {
+		profile := shopData[d.b.T.String()]
+		npc.money = profile.Money
+		npc.stock(profile)
 	}
 
 	for _, itm := range generateInventory(n.Inventory) {
 		npc.PickupItem(itm)
 	}
+	npc.provision()
 	event.Subscribe(npc)
 	return npc
 }
@@ -217,7 +225,14 @@ func generateInventory(itemChoices [][]item.ItemChoice) []*item.Item {
 		}
 
 		choice := chooseItems(choices)
-		for itemType, count := range selection[choice].Items {
+		itemTypes := make([]string, 0, len(selection[choice].Items))
+		for itemType := range selection[choice].Items {
+			itemTypes = append(itemTypes, itemType)
+		}
+		sort.Strings(itemTypes)
+
+		for _, itemType := range itemTypes {
+			count := selection[choice].Items[itemType]
 			for i := 0; i < count; i++ {
 				inventory = append(inventory, item.NewItem(itemType))
 			}
@@ -236,7 +251,7 @@ func (npc *Npc) Render() ui.Element {
 func (npc *Npc) MarshalJSON() ([]byte, error) {
 	buffer := bytes.NewBufferString("{")
 
-	keys := []string{"Name", "Id", "Location", "Icon", "Initiative", "Attributes", "Alignment", "Crouching", "Money", "Unarmed", "Weapon", "Armour", "Inventory", "MountID", "MountableComponent", "Ai", "Dialogue", "Human"}
+	keys := []string{"Name", "Id", "Location", "Depth", "Icon", "Initiative", "Attributes", "Alignment", "Crouching", "Money", "Unarmed", "Weapon", "Armour", "Inventory", "MountID", "MountableComponent", "Ai", "Dialogue", "Human", "Wounds"}
 
 	mountID := ""
 	if npc.mount != nil {
@@ -247,6 +262,7 @@ func (npc *Npc) MarshalJSON() ([]byte, error) {
 		"Name":               npc.name,
 		"Id":                 npc.id,
 		"Location":           npc.location,
+		"Depth":              npc.depth,
 		"Icon":               npc.icon,
 		"Initiative":         npc.initiative,
 		"Attributes":         npc.attributes,
@@ -262,6 +278,7 @@ func (npc *Npc) MarshalJSON() ([]byte, error) {
 		"Ai":                 npc.ai,
 		"Dialogue":           npc.dialogue,
 		"Human":              npc.human,
+		"Wounds":             npc.wounds,
 	}
 
 	length := len(npcValues)
@@ -299,6 +316,7 @@ func (npc *Npc) UnmarshalJSON(data []byte) error {
 		Name               map[string]interface{}
 		Id                 string
 		Location           worldmap.Coordinates
+		Depth              int
 		Icon               icon.Icon
 		Initiative         int
 		Attributes         map[string]*worldmap.Attribute
@@ -314,6 +332,7 @@ func (npc *Npc) UnmarshalJSON(data []byte) error {
 		Ai                 ai
 		Dialogue           map[string]interface{}
 		Human              bool
+		Wounds             *worldmap.Wounds
 	}
 	var v npcJson
 
@@ -322,6 +341,7 @@ func (npc *Npc) UnmarshalJSON(data []byte) error {
 	npc.name = unmarshalName(v.Name)
 	npc.id = v.Id
 	npc.location = v.Location
+	npc.depth = v.Depth
 	npc.icon = v.Icon
 	npc.initiative = v.Initiative
 	npc.attributes = v.Attributes
@@ -337,6 +357,15 @@ func (npc *Npc) UnmarshalJSON(data []byte) error {
 	npc.ai = v.Ai
 	npc.dialogue = unmarshalDialogue(v.Dialogue)
 	npc.human = v.Human
+	npc.wounds = v.Wounds
+	if npc.wounds == nil {
+		npc.wounds = worldmap.NewWounds()
+	}
+	// Saves from before npcs had every attribute or needs
+	worldmap.AddAttributes(npc.attributes)
+	if npc.human {
+		worldmap.AddNeeds(npc.attributes)
+	}
 
 	event.Subscribe(npc)
 
@@ -350,11 +379,34 @@ func (npc *Npc) SetCoordinates(x int, y int) {
 	npc.location = worldmap.Coordinates{x, y}
 }
 
+func (npc *Npc) GetDepth() int {
+	return npc.depth
+}
+
+func (npc *Npc) SetDepth(depth int) {
+	npc.depth = depth
+	if npc.mount != nil {
+		npc.mount.SetDepth(depth)
+	}
+}
+
+// LightRadius is how far the brightest lit light the npc carries lets them see in the dark
+func (npc *Npc) LightRadius() int {
+	radius := 0
+	for _, itm := range npc.inventory {
+		if itm.LightRadius() > radius {
+			radius = itm.LightRadius()
+		}
+	}
+	return radius
+}
+
 func (npc *Npc) GetInitiative() int {
 	return npc.initiative
 }
 
 func (npc *Npc) MeleeAttack(c worldmap.Creature) {
+	npc.Weapon().Wear()
 	npc.attack(c, worldmap.GetBonus(npc.attributes["str"].Value()), worldmap.GetBonus(npc.attributes["str"].Value()))
 }
 
@@ -392,6 +444,12 @@ func (npc *Npc) TakeDamage(damage item.Damage, effects item.Effects, bonus int)
 	total_damage := damage.Damage() + bonus
 	npc.attributes["hp"].AddEffect(item.NewInstantEffect(-total_damage))
 	npc.applyEffects(effects)
+	npc.wounds.Inflict(npc.attributes, total_damage)
+
+	// Being hurt wakes anyone up
+	if *npc.ai.state == "sleeping" {
+		*npc.ai.state = "normal"
+	}
 
 	if npc.mc != nil && npc.mc.rider != nil && npc.IsDead() {
 		npc.mc.rider.TakeDamage(item.NewDamage(4, 1, 0), item.Effects{}, 0)
@@ -403,7 +461,12 @@ func (npc *Npc) TakeDamage(damage item.Damage, effects item.Effects, bonus int)
 }
 
 func (npc *Npc) IsDead() bool {
-	return npc.attributes["hp"].Value() == 0
+	return npc.attributes["hp"].Value() == 0 || worldmap.Starved(npc.attributes)
+}
+
+// Starving returns true if the npc is wasting away from hunger or thirst
+func (npc *Npc) Starving() bool {
+	return worldmap.Starving(npc.attributes)
 }
 
 func (npc *Npc) wieldItem() bool {
@@ -473,6 +536,9 @@ func (npc *Npc) Update() {
 	for _, attribute := range npc.attributes {
 		attribute.Update()
 	}
+	npc.world.ApplyWeather(npc.attributes, npc.location.X, npc.location.Y)
+	worldmap.ApplyNeeds(npc.attributes)
+	npc.wounds.Update(npc.attributes)
 
 	// Apply armour AC bonus
 	if npc.armour != nil {
@@ -502,6 +568,11 @@ func (npc *Npc) Update() {
 		npc.dialogue.resetSeen()
 	}
 
+	// The team and crew of a stagecoach go wherever it takes them
+	if npc.world.OnStagecoach(npc) {
+		return
+	}
+
 	action := npc.ai.update(npc, npc.world)
 	action.execute()
 
@@ -640,6 +711,45 @@ func (npc *Npc) applyEffects(effects item.Effects) {
 
 func (npc *Npc) consume(itm *item.Item) {
 	npc.applyEffects(itm.Component("consumable").(item.ConsumableComponent).Effects)
+	if treatment, ok := itm.Component("treatment").(item.TreatmentComponent); ok {
+		npc.wounds.Treat(npc.attributes, treatment.Wounds)
+	}
+}
+
+func (npc *Npc) wounded(kind string) bool {
+	return npc.wounds.Has(kind)
+}
+
+func (npc *Npc) stumbles() bool {
+	return npc.wounds.Stumbles()
+}
+
+func (npc *Npc) need(name string) *worldmap.Attribute {
+	return npc.attributes[name]
+}
+
+func (npc *Npc) rest(amount int) {
+	if fatigue, ok := npc.attributes["fatigue"]; ok {
+		fatigue.AddEffect(item.NewInstantEffect(-amount))
+	}
+}
+
+// Sets the attributes given in an npc's data, with any that aren't given being average
+func addScores(attributes map[string]*worldmap.Attribute, con, cha, per int) {
+	for attr, score := range map[string]int{"con": con, "cha": cha, "per": per} {
+		if score > 0 {
+			attributes[attr] = worldmap.NewAttribute(score, score)
+		}
+	}
+	worldmap.AddAttributes(attributes)
+}
+
+// Gives a human npc needs, which have been partly met at different times
+func addNeeds(attributes map[string]*worldmap.Attribute) {
+	worldmap.AddNeeds(attributes)
+	for _, need := range worldmap.Needs {
+		attributes[need].AddEffect(item.NewInstantEffect(rand.Intn(attributes[need].Maximum() / 2)))
+	}
 }
 
 func (npc *Npc) bloodied() bool {
@@ -711,6 +821,12 @@ func (npc *Npc) SetMap(world *worldmap.Map) {
 		d.setMap(world)
 	case *sheriffDialogue:
 		d.setMap(world)
+	case *doctorDialogue:
+		d.setMap(world)
+	case *bankDialogue:
+		d.setMap(world)
+	case *stationDialogue:
+		d.setMap(world)
 	}
 
 }
@@ -728,8 +844,28 @@ func (npc *Npc) Encumbrance() int {
 	return npc.attributes["encumbrance"].Value()
 }
 
+// Saddlebags returns the saddlebags a mount is wearing, if any
+func (npc *Npc) Saddlebags() *item.Item {
+	for _, itm := range npc.inventory {
+		if itm.HasComponent("container") {
+			return itm
+		}
+	}
+	return nil
+}
+
+// PutOnSaddlebags straps saddlebags on a mount. They still belong to whoever owned them.
+func (npc *Npc) PutOnSaddlebags(saddlebags *item.Item) {
+	npc.inventory = append(npc.inventory, saddlebags)
+}
+
 func (npc *Npc) GetVisionDistance() int {
-	return 20
+	return npc.wounds.VisionDistance(worldmap.VisionDistance(npc.attributes))
+}
+
+// Bonus returns the npc's bonus for an attribute
+func (npc *Npc) Bonus(attr string) int {
+	return worldmap.Bonus(npc.attributes, attr)
 }
 
 func (npc *Npc) GetItems(addMoney bool) map[rune]([]*item.Item) {
@@ -759,6 +895,10 @@ func (npc *Npc) LoadMount(mounts []*Npc) {
 	}
 }
 
+func (npc *Npc) GetMoney() int {
+	return npc.money
+}
+
 func (npc Npc) CanAfford(value int) bool {
 	return value <= npc.money
 }
@@ -788,16 +928,33 @@ func (npc *Npc) GetBounties() *Bounties {
 	return &Bounties{}
 }
 
+// Bank returns the name of the town whose bank the npc works at
+func (npc *Npc) Bank() string {
+	if d, ok := npc.dialogue.(*bankDialogue); ok {
+		return d.t.Name
+	}
+	return ""
+}
+
 func (npc *Npc) ProcessEvent(e event.Event) {
 	if ev, ok := e.(event.CrimeEvent); ok && npc.alignment != worldmap.Enemy && npc.Human() {
 		ev.Witness(npc.world, npc)
 	}
+	if ev, ok := e.(event.AttackEvent); ok && ev.Victim().GetID() == npc.id && npc.world != nil {
+		npc.world.StopStagecoach(npc)
+	}
+	if ev, ok := e.(event.WitnessedCrimeEvent); ok {
+		if d, ok := npc.dialogue.(*shopkeeperDialogue); ok {
+			d.hearOfCrime(ev.Crime)
+		}
+	}
 }
 
 type Npc struct {
 	name       ui.Name
 	id         string
 	location   worldmap.Coordinates
+	depth      int
 	icon       icon.Icon
 	initiative int
 	attributes map[string]*worldmap.Attribute
@@ -815,4 +972,5 @@ type Npc struct {
 	ai         ai
 	dialogue   dialogue
 	human      bool
+	wounds     *worldmap.Wounds
 }
@@ -0,0 +1,253 @@
+package npc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"sort"
+
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// Turns between shops restocking and their prices settling back down
+const RestockInterval = 1000
+
+// How much prices fall for each item a shop has more of than usual, or rise for each it has fewer of
+const supplyPriceChange = 0.1
+
+// How far a shopkeeper will move from their price for anyone, and the furthest anyone can talk them
+const baseLeeway = 0.05
+const maxLeeway = 0.5
+
+// Share of what a shop would sell an item for that it pays for one, so that it turns a profit
+const buyBackRatio = 0.5
+
+// What a shop keeps in stock, by the type of building it is in
+type shopProfile struct {
+	Money int
+	Items map[string]int
+}
+
+var shopData map[string]shopProfile = fetchShopData()
+
+func fetchShopData() map[string]shopProfile {
+	data, err := ioutil.ReadFile("data/shop.json")
+	check(err)
+	var sD map[string]shopProfile
+	err = json.Unmarshal(data, &sD)
+	check(err)
+	return sD
+}
+
+// Components that items in each category of stock have
+var categoryComponents = map[string]string{"Ammo": "ammo", "Armour": "armour", "Consumable": "consumable", "Weapon": "weapon"}
+
+func inCategory(itm *item.Item, category string) bool {
+	if component, ok := categoryComponents[category]; ok {
+		return itm.HasComponent(component)
+	}
+	// Shops can also stock particular items by name
+	if category != "Item" {
+		return itm.GetName() == category
+	}
+	for _, component := range categoryComponents {
+		if itm.HasComponent(component) {
+			return false
+		}
+	}
+	return true
+}
+
+// Tops up the npc's stock to what the profile says a shop should have
+func (npc *Npc) stock(profile shopProfile) {
+	categories := make([]string, 0, len(profile.Items))
+	for c := range profile.Items {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+
+	for _, c := range categories {
+		count := profile.Items[c]
+		for _, itm := range npc.inventory {
+			if inCategory(itm, c) {
+				count--
+			}
+		}
+		for i := 0; i < count; i++ {
+			switch c {
+			case "Ammo":
+				npc.PickupItem(item.GenerateAmmo())
+			case "Armour":
+				npc.PickupItem(item.GenerateArmour())
+			case "Consumable":
+				npc.PickupItem(item.GenerateConsumable())
+			case "Item":
+				npc.PickupItem(item.GenerateItem())
+			case "Weapon":
+				npc.PickupItem(item.GenerateWeapon())
+			default:
+				npc.PickupItem(item.NewItem(c))
+			}
+		}
+	}
+}
+
+// What people buy to eat and drink, the cheapest food and drink there is
+var provisions = map[string]string{"hunger": "potato", "thirst": "water"}
+
+// Buys enough food and drink to last until the npc next restocks, as far as its money goes
+func (npc *Npc) provision() {
+	for _, need := range []string{"hunger", "thirst"} {
+		if _, ok := npc.attributes[need]; !ok {
+			continue
+		}
+		for npc.carried(need) < RestockInterval {
+			itm := item.NewItem(provisions[need])
+			if !npc.CanAfford(itm.GetValue()) {
+				break
+			}
+			npc.RemoveMoney(itm.GetValue())
+			npc.PickupItem(itm)
+		}
+	}
+}
+
+// How much of a need everything the npc has to eat or drink would meet
+func (npc *Npc) carried(need string) int {
+	total := 0
+	for _, itm := range npc.inventory {
+		if consumable, ok := itm.Component("consumable").(item.ConsumableComponent); ok {
+			for _, effect := range consumable.Effects[need] {
+				total -= effect.Value()
+			}
+		}
+	}
+	return total
+}
+
+// Restock tops a shop back up to its profile and lets the prices of what was bought and sold settle.
+// Shopkeepers and everyone else then buy their food and drink.
+func (npc *Npc) Restock() {
+	if npc.IsDead() {
+		return
+	}
+	if d, ok := npc.dialogue.(*shopkeeperDialogue); ok {
+		npc.restockShop(d)
+	}
+	npc.provision()
+}
+
+func (npc *Npc) restockShop(d *shopkeeperDialogue) {
+	for name, supply := range d.supply {
+		if supply/2 == 0 {
+			delete(d.supply, name)
+		} else {
+			d.supply[name] = supply / 2
+		}
+	}
+
+	profile := shopData[d.b.T.String()]
+	if npc.money < profile.Money {
+		npc.money = profile.Money
+	}
+	npc.stock(profile)
+}
+
+// Price the npc's shop sells an item for or, if the player is selling, pays for it.
+// Prices fall the more a shop has of something and are higher in towns far from others.
+func (npc *Npc) Price(itm *item.Item, selling bool) int {
+	d, ok := npc.dialogue.(*shopkeeperDialogue)
+	if !ok {
+		return itm.GetValue()
+	}
+
+	if selling {
+		// A shop pays a share of what it could sell the item on for once it has it
+		return int(math.Round(buyBackRatio * d.price(itm, d.supply[itm.GetName()]+1)))
+	}
+	return int(math.Round(d.price(itm, d.supply[itm.GetName()])))
+}
+
+// What the shop sells an item for when it has a given supply of them
+func (d *shopkeeperDialogue) price(itm *item.Item, supply int) float64 {
+	return float64(itm.GetValue()) * (1 + d.t.Markup) * math.Pow(1-supplyPriceChange, float64(supply))
+}
+
+// Limit is the least the npc will take for an item or, if the player is selling, the most they will pay,
+// given how far the player can talk them round on top of their own leeway
+func (npc *Npc) Limit(itm *item.Item, selling bool, leeway float64) int {
+	if !selling {
+		return haggled(npc.Price(itm, false), leeway+npc.Leeway(itm, false), false)
+	}
+
+	limit := haggled(npc.Price(itm, true), leeway+npc.Leeway(itm, true), true)
+	// However well the player haggles, the npc never pays more for an item than they would let it go for once they have it
+	resale := haggled(itm.GetValue(), leeway+baseLeeway, false)
+	if d, ok := npc.dialogue.(*shopkeeperDialogue); ok {
+		supply := d.supply[itm.GetName()] + 1
+		resale = haggled(int(math.Round(d.price(itm, supply))), leeway+d.leeway(supply), false)
+	}
+	if resale < limit {
+		return resale
+	}
+	return limit
+}
+
+// Moves a price down, or up if raising it, by a share of itself no bigger than anyone can haggle
+func haggled(price int, leeway float64, up bool) int {
+	leeway = math.Max(0, math.Min(leeway, maxLeeway))
+	if !up {
+		leeway = -leeway
+	}
+	return int(math.Round(float64(price) * (1 + leeway)))
+}
+
+// How far the npc will move from their price when haggling, more for regular customers
+// and for items they have plenty of or, if the player is selling, are short of.
+func (npc *Npc) Leeway(itm *item.Item, selling bool) float64 {
+	d, ok := npc.dialogue.(*shopkeeperDialogue)
+	if !ok {
+		return baseLeeway
+	}
+
+	supply := d.supply[itm.GetName()]
+	if selling {
+		supply = -supply
+	}
+	return d.leeway(supply)
+}
+
+// How far the shop will move from its price on an item it has a given supply of
+func (d *shopkeeperDialogue) leeway(supply int) float64 {
+	return baseLeeway + 0.01*math.Min(float64(d.trades), 10) + 0.02*float64(supply)
+}
+
+// Gunsmiths repair weapons as well as selling them
+func (npc *Npc) RepairsWeapons() bool {
+	d, ok := npc.dialogue.(*shopkeeperDialogue)
+	return ok && d.b.T == worldmap.GunShop
+}
+
+// What the npc charges to restore a weapon, half the value it has lost to wear
+func (npc *Npc) RepairCost(itm *item.Item) int {
+	d, ok := npc.dialogue.(*shopkeeperDialogue)
+	if !ok {
+		return itm.WearValue()
+	}
+	return int(math.Round(float64(itm.WearValue()) / 2 * (1 + d.t.Markup)))
+}
+
+// RecordTrade remembers a deal done with the player
+func (npc *Npc) RecordTrade() {
+	if d, ok := npc.dialogue.(*shopkeeperDialogue); ok {
+		d.trades++
+	}
+}
+
+// ChangeSupply records items being sold to, or if negative, bought from the npc's shop
+func (npc *Npc) ChangeSupply(name string, amount int) {
+	if d, ok := npc.dialogue.(*shopkeeperDialogue); ok {
+		d.supply[name] += amount
+	}
+}
@@ -0,0 +1,73 @@
+package player
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/npc"
+	"github.com/onorton/cowboysindians/ui"
+)
+
+// Lets the player deposit money with a bank teller and withdraw it again.
+// Deposits are kept by the town's bank, not the teller, so they are still there if the teller dies.
+func visitBank(p *Player, teller *npc.Npc) {
+	dialogueComplete := false
+	for !dialogueComplete {
+		printBankScreen(p, teller)
+		message.PrintMessages()
+		action := ui.GetBankInput()
+		if action == ui.Deposit {
+			amount, ok := requestAmount("How much do you want to deposit?")
+			if !ok {
+				continue
+			}
+			if amount > p.money {
+				message.Enqueue("\"You don't have that much on you.\"")
+				continue
+			}
+			p.money -= amount
+			p.deposits[teller.Bank()] += amount
+			message.Enqueue(fmt.Sprintf("You deposit $%.2f.", float64(amount)/100))
+		} else if action == ui.Withdraw {
+			amount, ok := requestAmount("How much do you want to withdraw?")
+			if !ok {
+				continue
+			}
+			if amount > p.deposits[teller.Bank()] {
+				message.Enqueue("\"You don't have that much with us.\"")
+				continue
+			}
+			p.deposits[teller.Bank()] -= amount
+			p.money += amount
+			message.Enqueue(fmt.Sprintf("You withdraw $%.2f.", float64(amount)/100))
+		} else if action == ui.Exit {
+			dialogueComplete = true
+			message.PrintMessage("\"Pleasure doing business with you.\"")
+		}
+	}
+}
+
+// Asks the player for an amount of money in dollars, returning it in cents
+func requestAmount(prompt string) (int, bool) {
+	input := strings.TrimSpace(message.RequestInput(prompt))
+	if input == "" {
+		return 0, false
+	}
+
+	amount, ok := item.ParseMoney(input)
+	if !ok {
+		message.Enqueue("\"I'm not sure what you mean.\"")
+	}
+	return amount, ok
+}
+
+func printBankScreen(p *Player, teller *npc.Npc) {
+	ui.ClearScreen()
+	padding := 2
+
+	ui.WriteText(0, 0, "Bank")
+	ui.WriteText(0, padding, fmt.Sprintf("On hand:   $%.2f", float64(p.money)/100))
+	ui.WriteText(0, padding+1, fmt.Sprintf("Deposited: $%.2f", float64(p.deposits[teller.Bank()])/100))
+}
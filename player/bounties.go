@@ -6,6 +6,7 @@ import (
 	"github.com/onorton/cowboysindians/message"
 	"github.com/onorton/cowboysindians/npc"
 	"github.com/onorton/cowboysindians/ui"
+	"github.com/onorton/cowboysindians/worldmap"
 )
 
 func claimBounties(p *Player, npc *npc.Npc) {
@@ -31,7 +32,13 @@ func claimBounties(p *Player, npc *npc.Npc) {
 			if totalReward == 0 {
 				message.PrintMessage(fmt.Sprintf("You have no bounties to claim here."))
 			} else {
+				// A persuasive bounty hunter can talk the reward up
+				if bonus := totalReward * 5 * worldmap.Bonus(p.attributes, "cha") / 100; bonus > 0 {
+					totalReward += bonus
+					message.Enqueue(fmt.Sprintf("You talk %s into an extra $%.2f.", npc.GetName().WithDefinite(), float64(bonus)/100))
+				}
 				p.money += totalReward
+				p.gainXp(totalReward / bountyXpRatio)
 				collectedBounty = true
 			}
 
@@ -0,0 +1,108 @@
+package player
+
+import (
+	"fmt"
+
+	"github.com/onorton/cowboysindians/event"
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/npc"
+	"github.com/onorton/cowboysindians/ui"
+)
+
+// Lets the player take items out of a container and put items in
+func openContainer(p *Player, container *item.Item) {
+	cc := container.Component("container").(*item.ContainerComponent)
+	if cc.Locked() {
+		message.Enqueue(fmt.Sprintf("The %s is locked.", container.GetName()))
+		return
+	}
+
+	// Value of what has been taken that belonged to someone else
+	stolen := 0
+	containerComplete := false
+	for !containerComplete {
+		printExchangeScreen(p, container.GetName(), cc.GetItems())
+		message.PrintMessages()
+		action := ui.GetInput()
+		if action == ui.Pickpocket {
+			validSelection := false
+			for !validSelection {
+				message.PrintMessage("Take: ")
+				command, selection := ui.GetItemSelection()
+
+				if command == ui.Cancel {
+					break
+				}
+
+				itm := cc.Take(selection)
+				if itm != nil {
+					validSelection = true
+					if !itm.Owned(p.GetID()) {
+						stolen += itm.GetValue()
+					}
+					if itm.GetName() == "money" {
+						// If money had previous owner, send theft event
+						if !itm.Owned(p.GetID()) {
+							event.Emit(event.NewTheft(p, itm, p.location))
+						}
+						p.money += itm.GetValue()
+						message.Enqueue(fmt.Sprintf("You took $%.2f.", float64(itm.GetValue())/100))
+					} else {
+						p.AddItem(itm)
+						message.Enqueue(fmt.Sprintf("You took a %s.", itm.GetName()))
+					}
+				}
+			}
+		} else if action == ui.Place {
+			validSelection := false
+			for !validSelection {
+				message.PrintMessage("Place: ")
+				command, selection := ui.GetItemSelection()
+
+				if command == ui.Cancel {
+					break
+				}
+
+				playerItems := p.pocketInventory()[selection]
+				if playerItems == nil {
+					continue
+				}
+				validSelection = true
+
+				if !cc.Fits(playerItems[0]) {
+					message.Enqueue(fmt.Sprintf("The %s won't fit in the %s.", playerItems[0].GetName(), container.GetName()))
+					break
+				}
+
+				if playerItems[0].GetName() == "money" {
+					money := playerItems[0]
+					p.money -= money.GetValue()
+					cc.Put(money)
+					message.Enqueue(fmt.Sprintf("You put $%.2f in the %s.", float64(money.GetValue())/100, container.GetName()))
+				} else {
+					itm := p.GetItem(selection)
+					cc.Put(itm)
+					message.Enqueue(fmt.Sprintf("You put a %s in the %s.", itm.GetName(), container.GetName()))
+				}
+			}
+		} else if action == ui.Exit || action == ui.CancelAction {
+			containerComplete = true
+		}
+	}
+
+	if stolen > 0 && container.HasComponent("alarm") {
+		message.Enqueue("An alarm bell rings out across town!")
+		event.Alarm(event.NewRobbery(p, stolen, p.location))
+	}
+}
+
+// Finds a container at a location, including the saddlebags of a mount standing there
+func (p *Player) containerAt(x, y int) *item.Item {
+	if n, ok := p.world.GetCreature(x, y).(*npc.Npc); ok && n.IsMount() {
+		if saddlebags := n.Saddlebags(); saddlebags != nil {
+			return saddlebags
+		}
+	}
+	return p.world.Container(x, y)
+}
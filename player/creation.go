@@ -91,7 +91,7 @@ func CreatePlayer(location worldmap.Coordinates) *Player {
 	currentSelection := &selection{attribute, -1}
 	skillsAvailable := 3
 	selectedSkills := structs.Initialise()
-	pointsAvailable := 8
+	pointsAvailable := 20
 	for _, attr := range worldmap.Attributes {
 		attributes[attr] = 8
 	}
@@ -0,0 +1,287 @@
+package player
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/ui"
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// Experience gained for different deeds
+const (
+	killEnemyXp   = 25
+	killOtherXp   = 10
+	pickpocketXp  = 5
+	lockpickXp    = 10
+	tradeXp       = 2
+	bountyXpRatio = 20
+)
+
+// Highest an attribute can be raised to when levelling up
+const maxAttribute = 20
+
+// Ranks the skills with bonuses that scale can be raised to. Other skills only have one rank.
+var maxSkillRanks = map[worldmap.Skill]int{
+	worldmap.Haggling:      3,
+	worldmap.Lockpicking:   3,
+	worldmap.Pickpocketing: 3,
+}
+
+func maxSkillRank(skill worldmap.Skill) int {
+	if rank, ok := maxSkillRanks[skill]; ok {
+		return rank
+	}
+	return 1
+}
+
+// Player's experience and the points they have yet to spend on levelling up
+type progress struct {
+	xp              int
+	level           int
+	attributePoints int
+	skillPoints     int
+	// Kinds of item the player has sold, which only earn experience the first time
+	sold map[string]bool
+}
+
+func newProgress() progress {
+	return progress{0, 1, 0, 0, map[string]bool{}}
+}
+
+// Experience needed in total to reach the next level
+func (pr progress) nextLevel() int {
+	return 100 * pr.level * pr.level
+}
+
+func (pr progress) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+
+	keys := []string{"Xp", "Level", "AttributePoints", "SkillPoints"}
+	values := map[string]int{
+		"Xp":              pr.xp,
+		"Level":           pr.level,
+		"AttributePoints": pr.attributePoints,
+		"SkillPoints":     pr.skillPoints,
+	}
+
+	for _, key := range keys {
+		jsonValue, err := json.Marshal(values[key])
+		if err != nil {
+			return nil, err
+		}
+		buffer.WriteString(fmt.Sprintf("\"%s\":%s,", key, jsonValue))
+	}
+
+	sold := make([]string, 0, len(pr.sold))
+	for name := range pr.sold {
+		sold = append(sold, name)
+	}
+	sort.Strings(sold)
+	soldValue, err := json.Marshal(sold)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Sold\":%s", soldValue))
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (pr *progress) UnmarshalJSON(data []byte) error {
+
+	type progressJson struct {
+		Xp              int
+		Level           int
+		AttributePoints int
+		SkillPoints     int
+		Sold            []string
+	}
+
+	var v progressJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	pr.xp = v.Xp
+	pr.level = v.Level
+	pr.attributePoints = v.AttributePoints
+	pr.skillPoints = v.SkillPoints
+	pr.sold = make(map[string]bool)
+	for _, name := range v.Sold {
+		pr.sold[name] = true
+	}
+
+	return nil
+}
+
+// Rewards the player with experience, levelling them up if they have enough
+func (p *Player) gainXp(xp int) {
+	p.progress.xp += xp
+	for p.progress.xp >= p.progress.nextLevel() {
+		p.progress.level++
+		p.progress.attributePoints++
+		p.progress.skillPoints++
+		keys := strings.Join(ui.KeysFor(ui.GameContext, ui.Advance), " or ")
+		message.Enqueue(fmt.Sprintf("Welcome to level %d! Press %s to improve yourself.", p.progress.level, keys))
+	}
+}
+
+// Rewards the player for selling a kind of item for the first time, so that trading the same thing back and forth earns nothing
+func (p *Player) gainTradeXp(itm *item.Item) {
+	if p.progress.sold[itm.GetName()] {
+		return
+	}
+	p.progress.sold[itm.GetName()] = true
+	p.gainXp(tradeXp)
+}
+
+// Number of times a skill has been learnt
+func (p *Player) skillRank(skill worldmap.Skill) int {
+	rank := 0
+	for _, s := range p.skills {
+		if s == skill {
+			rank++
+		}
+	}
+	return rank
+}
+
+// Advance lets the player spend the points they have gained from levelling up
+func (p *Player) Advance() {
+	if p.progress.attributePoints == 0 && p.progress.skillPoints == 0 {
+		message.PrintMessage(fmt.Sprintf("You need %d more experience to reach level %d.", p.progress.nextLevel()-p.progress.xp, p.progress.level+1))
+		return
+	}
+
+	// Raises chosen this time round, which can still be taken back
+	raisedAttributes := make(map[string]int)
+	raisedSkills := make(map[worldmap.Skill]bool)
+	currentSelection := &selection{attribute, -1}
+
+	for {
+		printAdvancementScreen(p, raisedAttributes, raisedSkills, *currentSelection)
+		action := ui.CreationInput()
+		currentSelection.next(action)
+
+		if currentSelection.selection == attribute && currentSelection.index >= 0 {
+			attr := worldmap.Attributes[currentSelection.index]
+			switch action {
+			case ui.Left:
+				if raisedAttributes[attr] > 0 {
+					raisedAttributes[attr]--
+					p.progress.attributePoints++
+				}
+			case ui.Right:
+				if p.progress.attributePoints > 0 && p.attributes[attr].Maximum()+raisedAttributes[attr] < maxAttribute {
+					raisedAttributes[attr]++
+					p.progress.attributePoints--
+				}
+			}
+		} else if currentSelection.selection == skill && currentSelection.index >= 0 {
+			sk := skillsInfo[currentSelection.index].skill
+			if action == ui.Select {
+				if raisedSkills[sk] {
+					delete(raisedSkills, sk)
+					p.progress.skillPoints++
+				} else if p.progress.skillPoints > 0 && p.skillRank(sk) < maxSkillRank(sk) {
+					raisedSkills[sk] = true
+					p.progress.skillPoints--
+				}
+			}
+		} else if currentSelection.selection == completion && action == ui.Select {
+			break
+		}
+	}
+	ui.ClearScreen()
+
+	originalHp := maxHp(p.attributes["con"].Maximum())
+	for attr, raise := range raisedAttributes {
+		p.attributes[attr].AddEffect(item.NewPermanentMaxEffect(raise))
+		p.attributes[attr].AddEffect(item.NewInstantEffect(raise))
+	}
+	// A hardier constitution means more hit points
+	if hpGained := maxHp(p.attributes["con"].Maximum()) - originalHp; hpGained > 0 {
+		p.attributes["hp"].AddEffect(item.NewPermanentMaxEffect(hpGained))
+		p.attributes["hp"].AddEffect(item.NewInstantEffect(hpGained))
+	}
+	for _, info := range skillsInfo {
+		if raisedSkills[info.skill] {
+			p.skills = append(p.skills, info.skill)
+		}
+	}
+}
+
+func printAdvancementScreen(p *Player, raisedAttributes map[string]int, raisedSkills map[worldmap.Skill]bool, s selection) {
+	ui.ClearScreen()
+
+	if s.selection == skill && s.index >= 0 {
+		message.PrintMessage(skillsInfo[s.index].description)
+	} else {
+		message.PrintMessage(fmt.Sprintf("Level %d. %d experience needed for the next level.", p.progress.level, p.progress.nextLevel()-p.progress.xp))
+	}
+
+	skillsOffset := 50
+	padding := 2
+
+	if s.selection == attribute && s.index == -1 {
+		ui.WriteHighlightedText(0, padding, "Attributes:")
+	} else {
+		ui.WriteText(0, padding, "Attributes:")
+	}
+	padding += 2
+	ui.WriteText(0, padding, fmt.Sprintf("Points Available: %d", p.progress.attributePoints))
+	padding += 2
+
+	for index, attr := range worldmap.Attributes {
+		text := fmt.Sprintf("%s: %d", attr, p.attributes[attr].Maximum()+raisedAttributes[attr])
+		if s.selection == attribute && index == s.index {
+			ui.WriteHighlightedText(0, padding+index, text)
+		} else {
+			ui.WriteText(0, padding+index, text)
+		}
+	}
+
+	padding = 2
+	if s.selection == skill && s.index == -1 {
+		ui.WriteHighlightedText(skillsOffset, padding, "Skills:")
+	} else {
+		ui.WriteText(skillsOffset, padding, "Skills:")
+	}
+	padding += 2
+	ui.WriteText(skillsOffset, padding, fmt.Sprintf("Skills Available: %d", p.progress.skillPoints))
+	padding += 2
+
+	for index, sk := range skillsInfo {
+		rank := p.skillRank(sk.skill)
+		if raisedSkills[sk.skill] {
+			rank++
+		}
+
+		text := sk.skillName
+		if maxSkillRank(sk.skill) > 1 && rank > 0 {
+			text = fmt.Sprintf("%s %d/%d", text, rank, maxSkillRank(sk.skill))
+		}
+		if rank > 0 {
+			ui.WriteText(skillsOffset-2, padding+index, "*")
+		}
+
+		if s.selection == skill && index == s.index {
+			ui.WriteHighlightedText(skillsOffset, padding+index, text)
+		} else {
+			ui.WriteText(skillsOffset, padding+index, text)
+		}
+	}
+
+	if s.selection == completion {
+		ui.WriteHighlightedText(0, padding+len(skillsInfo)+2, "Complete")
+	} else {
+		ui.WriteText(0, padding+len(skillsInfo)+2, "Complete")
+	}
+}
@@ -0,0 +1,415 @@
+package player
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/onorton/cowboysindians/event"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/npc"
+	"github.com/onorton/cowboysindians/ui"
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// Chance of being caught cheating before dexterity and perception are taken into account
+const baseChanceCaughtCheating = 0.3
+
+// Fraction of their money a gambler will stake on a single hand
+const tableLimitFraction = 4
+
+type card struct {
+	rank int
+	suit int
+}
+
+var rankNames = []string{"2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "A"}
+var suitNames = []string{"♠", "♥", "♦", "♣"}
+
+// Ranks run from 2 to 14, with aces high
+func (c card) String() string {
+	return rankNames[c.rank-2] + suitNames[c.suit]
+}
+
+func cardsString(cards []card) string {
+	names := make([]string, len(cards))
+	for i, c := range cards {
+		names[i] = c.String()
+	}
+	return strings.Join(names, " ")
+}
+
+type deck []card
+
+func newDeck() *deck {
+	d := make(deck, 0, 52)
+	for _, i := range rand.Perm(52) {
+		d = append(d, card{i%13 + 2, i / 13})
+	}
+	return &d
+}
+
+func (d *deck) draw() card {
+	c := (*d)[len(*d)-1]
+	*d = (*d)[:len(*d)-1]
+	return c
+}
+
+func (d *deck) deal(n int) []card {
+	cards := make([]card, n)
+	for i := range cards {
+		cards[i] = d.draw()
+	}
+	return cards
+}
+
+func (d *deck) peek() card {
+	return (*d)[len(*d)-1]
+}
+
+// Lets the player play cards with a gambler sitting at a table
+func gamble(p *Player, n *npc.Npc) {
+	tableX, tableY, seated := seatedAtTable(p.world, n)
+	if !seated {
+		message.Enqueue("\"Find me at a table if you want a game.\"")
+		return
+	}
+
+	cheating := false
+	lastHand := []string{}
+	gamblingComplete := false
+	for !gamblingComplete {
+		printGamblingScreen(p, n, cheating, lastHand)
+		message.PrintMessages()
+		action := ui.GetGamblingInput()
+		if action == ui.Cheat {
+			cheating = !cheating
+		} else if action == ui.Poker || action == ui.Faro || action == ui.Blackjack {
+			rank := 0
+			if action == ui.Faro {
+				var ok bool
+				if rank, ok = requestRank(); !ok {
+					continue
+				}
+			}
+
+			bet, ok := placeBet(p, n)
+			if !ok {
+				continue
+			}
+
+			if cheating && caughtCheating(p, n) {
+				p.money -= bet
+				n.AddMoney(bet)
+				brawl(p, n, tableX, tableY)
+				return
+			}
+
+			var winnings int
+			d := newDeck()
+			switch action {
+			case ui.Poker:
+				winnings, lastHand = playPoker(d, bet, cheating)
+			case ui.Faro:
+				winnings, lastHand = playFaro(d, bet, rank, cheating)
+			case ui.Blackjack:
+				winnings, lastHand = playBlackjack(p, n, d, bet, cheating)
+			}
+			settleBet(p, n, winnings)
+		} else if action == ui.Exit {
+			gamblingComplete = true
+		}
+	}
+}
+
+// Gamblers play from a chair next to a table
+func seatedAtTable(world *worldmap.Map, n *npc.Npc) (int, int, bool) {
+	x, y := n.GetCoordinates()
+	if !world.HasItem(x, y, "chair") {
+		return 0, 0, false
+	}
+	for _, offset := range []worldmap.Coordinates{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+		tX, tY := x+offset.X, y+offset.Y
+		if world.IsValid(tX, tY) && world.HasItem(tX, tY, "table") {
+			return tX, tY, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Asks the player how much to bet, which cannot be more than the table limit
+func placeBet(p *Player, n *npc.Npc) (int, bool) {
+	limit := n.GetMoney() / tableLimitFraction
+	if limit < 100 {
+		message.Enqueue("\"I'm cleaned out.\"")
+		return 0, false
+	}
+
+	bet, ok := requestAmount(fmt.Sprintf("How much do you bet? (up to $%.2f)", float64(limit)/100))
+	if !ok {
+		return 0, false
+	}
+	if bet > p.money {
+		message.Enqueue("You don't have that much on you.")
+		return 0, false
+	}
+	if bet > limit {
+		message.Enqueue(fmt.Sprintf("\"Table limit's $%.2f.\"", float64(limit)/100))
+		return 0, false
+	}
+	return bet, true
+}
+
+// Asks the player which rank of card to bet on in faro
+func requestRank() (int, bool) {
+	input := strings.ToUpper(strings.TrimSpace(message.RequestInput("Which card do you bet on?")))
+	if input == "" {
+		return 0, false
+	}
+	for i, name := range rankNames {
+		if name == input {
+			return i + 2, true
+		}
+	}
+	message.Enqueue("\"That ain't a card.\"")
+	return 0, false
+}
+
+func caughtCheating(p *Player, n *npc.Npc) bool {
+	chanceCaught := baseChanceCaughtCheating - 0.05*float64(worldmap.Bonus(p.attributes, "dex")) + 0.05*float64(n.Bonus("per"))
+	return rand.Float64() < math.Max(chanceCaught, 0.05)
+}
+
+// Everyone sitting at the table turns on a player caught cheating
+func brawl(p *Player, n *npc.Npc, tableX, tableY int) {
+	message.Enqueue(fmt.Sprintf("%s catches you cheating and takes your stake!", n.GetName().WithDefinite()))
+	event.Emit(event.NewAttack(p, n))
+	for _, offset := range []worldmap.Coordinates{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+		x, y := tableX+offset.X, tableY+offset.Y
+		if !p.world.IsValid(x, y) {
+			continue
+		}
+		if other, ok := p.world.GetCreature(x, y).(*npc.Npc); ok && other != n && other.Human() {
+			event.Emit(event.NewAttack(p, other))
+		}
+	}
+	message.Enqueue("A brawl breaks out!")
+}
+
+func settleBet(p *Player, n *npc.Npc, winnings int) {
+	p.money += winnings
+	n.RemoveMoney(winnings)
+	if winnings > 0 {
+		message.Enqueue(fmt.Sprintf("You win $%.2f.", float64(winnings)/100))
+	} else if winnings < 0 {
+		message.Enqueue(fmt.Sprintf("You lose $%.2f.", float64(-winnings)/100))
+	} else {
+		message.Enqueue("It's a draw. You keep your stake.")
+	}
+}
+
+var pokerHandNames = []string{"High card", "Pair", "Two pair", "Three of a kind", "Straight", "Flush", "Full house", "Four of a kind", "Straight flush"}
+
+// Ranks a five card poker hand, returning its category followed by the ranks that break ties
+func pokerHand(cards []card) []int {
+	counts := make(map[int]int)
+	flush := true
+	for _, c := range cards {
+		counts[c.rank]++
+		if c.suit != cards[0].suit {
+			flush = false
+		}
+	}
+
+	// Ranks ordered by how many of them there are, then by how high they are
+	ranks := make([]int, 0, len(counts))
+	for rank := range counts {
+		ranks = append(ranks, rank)
+	}
+	sort.Slice(ranks, func(i, j int) bool {
+		if counts[ranks[i]] != counts[ranks[j]] {
+			return counts[ranks[i]] > counts[ranks[j]]
+		}
+		return ranks[i] > ranks[j]
+	})
+
+	straight := len(ranks) == 5 && ranks[0]-ranks[4] == 4
+	// Ace can be low in a straight
+	if len(ranks) == 5 && ranks[0] == 14 && ranks[1] == 5 {
+		straight = true
+		ranks = append(ranks[1:], 1)
+	}
+
+	var category int
+	switch {
+	case straight && flush:
+		category = 8
+	case counts[ranks[0]] == 4:
+		category = 7
+	case counts[ranks[0]] == 3 && counts[ranks[1]] == 2:
+		category = 6
+	case flush:
+		category = 5
+	case straight:
+		category = 4
+	case counts[ranks[0]] == 3:
+		category = 3
+	case counts[ranks[0]] == 2 && counts[ranks[1]] == 2:
+		category = 2
+	case counts[ranks[0]] == 2:
+		category = 1
+	}
+	return append([]int{category}, ranks...)
+}
+
+// Returns 1 if the first hand wins, -1 if the second wins and 0 if they tie
+func comparePokerHands(a, b []card) int {
+	aRank, bRank := pokerHand(a), pokerHand(b)
+	for i := 0; i < len(aRank) && i < len(bRank); i++ {
+		if aRank[i] > bRank[i] {
+			return 1
+		} else if aRank[i] < bRank[i] {
+			return -1
+		}
+	}
+	return 0
+}
+
+// A showdown of five cards each
+func playPoker(d *deck, bet int, cheating bool) (int, []string) {
+	playerCards := d.deal(5)
+	otherCards := d.deal(5)
+	lines := []string{"Poker"}
+
+	if cheating {
+		// Deal a second hand from the bottom of the deck and keep the better one
+		secondHand := d.deal(5)
+		if comparePokerHands(secondHand, playerCards) > 0 {
+			playerCards = secondHand
+		}
+		lines = append(lines, "You deal yourself a second hand from the bottom of the deck.")
+	}
+
+	lines = append(lines, fmt.Sprintf("You:     %s (%s)", cardsString(playerCards), pokerHandNames[pokerHand(playerCards)[0]]))
+	lines = append(lines, fmt.Sprintf("Gambler: %s (%s)", cardsString(otherCards), pokerHandNames[pokerHand(otherCards)[0]]))
+	return comparePokerHands(playerCards, otherCards) * bet, lines
+}
+
+// Cards are drawn in pairs, the first losing for the player and the second winning, until one matches the rank bet on
+func playFaro(d *deck, bet, rank int, cheating bool) (int, []string) {
+	lines := []string{"Faro", fmt.Sprintf("Betting on %s", rankNames[rank-2])}
+	// The first card is discarded
+	d.draw()
+	for turn := 1; len(*d) >= 2; turn++ {
+		losing, winning := d.draw(), d.draw()
+		if cheating && losing.rank == rank && winning.rank != rank {
+			lines = append(lines, fmt.Sprintf("You palm the banker's %s before anyone sees it.", losing))
+			continue
+		}
+		if losing.rank != rank && winning.rank != rank {
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("Turn %d: banker's card %s, player's card %s", turn, losing, winning))
+		switch {
+		case losing.rank == rank && winning.rank == rank:
+			// Split, the bank takes half
+			return -bet / 2, lines
+		case losing.rank == rank:
+			return -bet, lines
+		default:
+			return bet, lines
+		}
+	}
+	return 0, lines
+}
+
+func blackjackValue(cards []card) int {
+	value, aces := 0, 0
+	for _, c := range cards {
+		switch {
+		case c.rank == 14:
+			value += 11
+			aces++
+		case c.rank > 10:
+			value += 10
+		default:
+			value += c.rank
+		}
+	}
+	for value > 21 && aces > 0 {
+		value -= 10
+		aces--
+	}
+	return value
+}
+
+// The player plays against the gambler, who deals and draws to 17
+func playBlackjack(p *Player, n *npc.Npc, d *deck, bet int, cheating bool) (int, []string) {
+	playerCards := d.deal(2)
+	dealerCards := d.deal(2)
+
+	hands := func(showDealer bool) []string {
+		lines := []string{"Blackjack", fmt.Sprintf("You:     %s (%d)", cardsString(playerCards), blackjackValue(playerCards))}
+		if showDealer {
+			lines = append(lines, fmt.Sprintf("Gambler: %s (%d)", cardsString(dealerCards), blackjackValue(dealerCards)))
+		} else {
+			lines = append(lines, fmt.Sprintf("Gambler: %s ??", dealerCards[0]))
+		}
+		return lines
+	}
+
+	for blackjackValue(playerCards) < 21 {
+		lines := hands(false)
+		if cheating {
+			lines = append(lines, fmt.Sprintf("You peek at the next card: %s", d.peek()))
+		}
+		printGamblingScreen(p, n, cheating, lines)
+		message.PrintMessage("Hit or stand?")
+		action := ui.GetGamblingInput()
+		if action == ui.Hit {
+			playerCards = append(playerCards, d.draw())
+		} else if action == ui.Stand || action == ui.Exit {
+			break
+		}
+	}
+
+	playerValue := blackjackValue(playerCards)
+	if playerValue > 21 {
+		return -bet, append(hands(true), "You bust.")
+	}
+
+	for blackjackValue(dealerCards) < 17 {
+		dealerCards = append(dealerCards, d.draw())
+	}
+	dealerValue := blackjackValue(dealerCards)
+
+	lines := hands(true)
+	switch {
+	case dealerValue > 21:
+		return bet, append(lines, "The gambler busts.")
+	case playerValue > dealerValue:
+		return bet, lines
+	case playerValue < dealerValue:
+		return -bet, lines
+	}
+	return 0, lines
+}
+
+func printGamblingScreen(p *Player, n *npc.Npc, cheating bool, hand []string) {
+	ui.ClearScreen()
+	padding := 2
+
+	ui.WriteText(0, 0, fmt.Sprintf("Gambling with %s", n.GetName().WithDefinite()))
+	ui.WriteText(0, padding, fmt.Sprintf("On hand:     $%.2f", float64(p.money)/100))
+	ui.WriteText(0, padding+1, fmt.Sprintf("Table limit: $%.2f", float64(n.GetMoney()/tableLimitFraction)/100))
+	if cheating {
+		ui.WriteText(0, padding+2, "You are cheating.")
+	}
+
+	for i, line := range hand {
+		ui.WriteText(0, 2*padding+3+i, line)
+	}
+}
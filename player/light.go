@@ -0,0 +1,40 @@
+package player
+
+import (
+	"fmt"
+
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// Lights a lantern or puts it out
+func (p *Player) toggleLight(itm *item.Item) bool {
+	light := itm.Component("light").(*item.LightComponent)
+	light.Toggle()
+	if light.Lit {
+		message.Enqueue(fmt.Sprintf("You light your %s.", itm.GetName()))
+	} else {
+		message.Enqueue(fmt.Sprintf("You put out your %s.", itm.GetName()))
+	}
+	return true
+}
+
+// Builds a campfire where the player is standing out of firewood, returning whether it caught
+func (p *Player) makeCampfire(firewood *item.Item) bool {
+	x, y := p.location.X, p.location.Y
+	if p.depth == worldmap.Surface && p.world.Sheltered(x, y) {
+		message.PrintMessage("You can't build a fire indoors.")
+		p.AddItem(firewood)
+		return false
+	}
+	if p.depth == worldmap.Surface && p.world.WeatherAt(x, y) == worldmap.Rain {
+		message.PrintMessage("The firewood is too wet to light in this rain.")
+		p.AddItem(firewood)
+		return false
+	}
+
+	p.world.PlaceItem(x, y, item.NewNormalItem("campfire"))
+	message.Enqueue("You build a campfire.")
+	return true
+}
@@ -0,0 +1,106 @@
+package player
+
+import (
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// Messages for when a need gets worse, indexed by level
+var needMessages = map[string][]string{
+	"hunger":  []string{"", "You are getting hungry.", "You are famished.", "You are starving to death!"},
+	"thirst":  []string{"", "You are getting thirsty.", "Your throat is parched.", "You are dying of thirst!"},
+	"fatigue": []string{"", "You are getting tired.", "You are exhausted.", "You can barely keep your eyes open!"},
+}
+
+var needSatisfiedMessages = map[string]string{
+	"hunger":  "You are no longer hungry.",
+	"thirst":  "You are no longer thirsty.",
+	"fatigue": "You feel wide awake.",
+}
+
+func (p *Player) needLevels() map[string]worldmap.NeedLevel {
+	levels := make(map[string]worldmap.NeedLevel)
+	for _, need := range worldmap.Needs {
+		levels[need] = worldmap.Level(p.attributes[need])
+	}
+	return levels
+}
+
+// Tells the player about changes in their needs, and wakes them up or knocks them out
+func (p *Player) updateNeeds(originalLevels map[string]worldmap.NeedLevel) {
+	levels := p.needLevels()
+	for _, need := range worldmap.Needs {
+		if levels[need] > originalLevels[need] {
+			message.Enqueue(needMessages[need][levels[need]])
+		}
+	}
+
+	fatigue := p.attributes["fatigue"]
+	if p.Asleep() {
+		if fatigue.Value() == 0 {
+			p.wake("You wake up feeling rested.")
+		} else if levels["hunger"] == worldmap.Critical {
+			p.wake("Your hunger wakes you up.")
+		} else if levels["thirst"] == worldmap.Critical {
+			p.wake("Your thirst wakes you up.")
+		}
+	} else if fatigue.Value() == fatigue.Maximum() && p.mount == nil {
+		// Riders are kept upright by their saddle
+		message.Enqueue("You collapse from exhaustion.")
+		p.lieDown(p.world.Rest(p.location.X, p.location.Y))
+	}
+}
+
+// Sleep lies down on a bed, a bedroll or the ground until rested
+func (p *Player) Sleep() bool {
+	if p.mount != nil {
+		message.PrintMessage("You can't sleep in the saddle.")
+		return false
+	}
+	if worldmap.Level(p.attributes["fatigue"]) == worldmap.Satisfied {
+		message.PrintMessage("You are not tired enough to sleep.")
+		return false
+	}
+
+	rest := p.world.Rest(p.location.X, p.location.Y)
+	bedroll := p.bedroll()
+	if bedroll != nil && bedroll.Component("bed").(item.BedComponent).Rest > rest {
+		rest = bedroll.Component("bed").(item.BedComponent).Rest
+		message.Enqueue("You roll out your bedroll and go to sleep.")
+	} else if rest > worldmap.GroundRest {
+		message.Enqueue("You lie down on the bed and go to sleep.")
+	} else {
+		message.Enqueue("You lie down on the hard ground and go to sleep.")
+	}
+	p.lieDown(rest)
+	return true
+}
+
+func (p *Player) lieDown(rest int) {
+	p.Standup()
+	p.rest = rest
+}
+
+// The best bed the player is carrying
+func (p *Player) bedroll() *item.Item {
+	var best *item.Item
+	for _, items := range p.inventory {
+		if bed, ok := items[0].Component("bed").(item.BedComponent); ok {
+			if best == nil || bed.Rest > best.Component("bed").(item.BedComponent).Rest {
+				best = items[0]
+			}
+		}
+	}
+	return best
+}
+
+func (p *Player) wake(reason string) {
+	message.Enqueue(reason)
+	p.rest = 0
+}
+
+// Asleep returns true if the player is sleeping and cannot act
+func (p *Player) Asleep() bool {
+	return p.rest > 0
+}
@@ -12,11 +12,22 @@ import (
 	"github.com/onorton/cowboysindians/worldmap"
 )
 
+// Roll needed with a charisma check to talk your way out of being caught pickpocketing
+const pickpocketExcuseDifficulty = 18
+
+// How much less likely victims are to notice in the dark
+const shadowPickpocketBonus = 0.1
+
 func pickpocket(p *Player, npc *npc.Npc) {
 	pickpocketComplete := false
 	chanceCaught := 0.25
-	if p.hasSkill(worldmap.Pickpocketing) {
-		chanceCaught -= 0.2
+	if rank := p.skillRank(worldmap.Pickpocketing); rank > 0 {
+		chanceCaught -= 0.2 + 0.02*float64(rank-1)
+	}
+	// Observant victims are more likely to notice
+	chanceCaught += 0.02 * float64(npc.Bonus("per"))
+	if p.world.InShadow(p.location.X, p.location.Y) {
+		chanceCaught -= shadowPickpocketBonus
 	}
 
 	for !pickpocketComplete {
@@ -46,10 +57,17 @@ func pickpocket(p *Player, npc *npc.Npc) {
 					}
 					message.Enqueue(fmt.Sprintf("You took a %s.", item[0].GetName()))
 					if rand.Float64() < chanceCaught {
+						if p.charismaCheck(pickpocketExcuseDifficulty) {
+							// Hand it back and talk your way out of trouble
+							p.giveBack(npc, item[0])
+							message.Enqueue(fmt.Sprintf("%s catches you, but you talk your way out of it and hand it back.", npc.GetName().WithDefinite()))
+							return
+						}
 						event.Emit(event.NewPickpocket(p, item[0], p.location))
 						message.Enqueue("You've been caught!")
 						return
 					}
+					p.gainXp(pickpocketXp)
 				}
 
 			}
@@ -64,7 +82,12 @@ func pickpocket(p *Player, npc *npc.Npc) {
 					break
 				}
 
-				item := p.GetItem(selection)
+				var item *item.Item
+				if playerItems := p.pocketInventory()[selection]; playerItems != nil && playerItems[0].GetName() == "money" {
+					item = playerItems[0]
+				} else {
+					item = p.GetItem(selection)
+				}
 				if item != nil {
 					validSelection = true
 					if item.GetName() == "money" {
@@ -88,26 +111,55 @@ func pickpocket(p *Player, npc *npc.Npc) {
 }
 
 func printPickpocketScreen(p *Player, npc *npc.Npc) {
+	printExchangeScreen(p, npc.GetName().String(), npc.GetItems(true))
+}
+
+// Shows what the player has on their person beside the items they can take
+func printExchangeScreen(p *Player, otherName string, otherItems map[rune][]*item.Item) {
 	ui.ClearScreen()
 	padding := 2
-	npcX := 50
+	otherX := 50
 
 	ui.WriteText(0, 0, "You:")
-	ui.WriteText(npcX, 0, fmt.Sprintf("%s:", npc.GetName()))
+	ui.WriteText(otherX, 0, fmt.Sprintf("%s:", otherName))
 
-	playerInventory := p.inventory
-	money := item.Money(p.money)
-	playerInventory[money.GetKey()] = []*item.Item{money}
+	playerInventory := p.pocketInventory()
 	i := 0
-	for c, items := range p.inventory {
+	for _, c := range sortedKeys(playerInventory) {
+		items := playerInventory[c]
 		ui.WriteText(0, padding+i, fmt.Sprintf("%s %dx %s $%.2f", string(c), len(items), items[0].GetName(), float64(items[0].GetValue())/100))
 		i++
 	}
 
 	i = 0
-	for c, items := range npc.GetItems(true) {
-		ui.WriteText(npcX, padding+i, fmt.Sprintf("%s %dx %s $%.2f", string(c), len(items), items[0].GetName(), float64(items[0].GetValue())/100))
+	for _, c := range sortedKeys(otherItems) {
+		items := otherItems[c]
+		ui.WriteText(otherX, padding+i, fmt.Sprintf("%s %dx %s $%.2f", string(c), len(items), items[0].GetName(), float64(items[0].GetValue())/100))
 		i++
 	}
 
 }
+
+// Items the player has on their person, including their money
+func (p *Player) pocketInventory() map[rune][]*item.Item {
+	inventory := make(map[rune][]*item.Item)
+	for c, items := range p.inventory {
+		inventory[c] = items
+	}
+	if p.money > 0 {
+		money := item.Money(p.money)
+		inventory[money.GetKey()] = []*item.Item{money}
+	}
+	return inventory
+}
+
+// Returns something the player has taken from an npc
+func (p *Player) giveBack(n *npc.Npc, itm *item.Item) {
+	if itm.GetName() == "money" {
+		p.money -= itm.GetValue()
+		n.AddMoney(itm.GetValue())
+		return
+	}
+	p.GetItem(itm.GetKey())
+	n.PickupItem(itm)
+}
@@ -5,8 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
 
-	termbox "github.com/nsf/termbox-go"
 	"github.com/onorton/cowboysindians/event"
 	"github.com/onorton/cowboysindians/icon"
 	"github.com/onorton/cowboysindians/item"
@@ -24,20 +25,20 @@ func check(err error) {
 
 func newPlayer(location worldmap.Coordinates, name string, attrs map[string]int, skills []worldmap.Skill) *Player {
 	attributes := map[string]*worldmap.Attribute{
-		"hp":          worldmap.NewAttribute(10, 10),
-		"hunger":      worldmap.NewAttribute(0, 1000),
-		"thirst":      worldmap.NewAttribute(0, 1000),
 		"ac":          worldmap.NewAttribute(15, 15),
 		"encumbrance": worldmap.NewAttribute(100, 100)}
 
 	for attr, v := range attrs {
 		attributes[attr] = worldmap.NewAttribute(v, v)
 	}
+	worldmap.AddAttributes(attributes)
 
-	attributes["hunger"].AddEffect(item.NewOngoingEffect(1))
-	attributes["thirst"].AddEffect(item.NewOngoingEffect(1))
+	hp := maxHp(attributes["con"].Value())
+	attributes["hp"] = worldmap.NewAttribute(hp, hp)
 
-	player := &Player{name, location, icon.CreatePlayerIcon(), 1, attributes, skills, false, 1000, item.WeaponComponent{0, item.NoAmmo, nil, item.NewDamage(2, 1, 0), item.Effects{}}, nil, nil, nil, make(map[rune]([]*item.Item)), "", nil, nil}
+	worldmap.AddNeeds(attributes)
+
+	player := &Player{name, location, worldmap.Surface, icon.CreatePlayerIcon(), 1, attributes, skills, false, 1000, item.WeaponComponent{0, item.NoAmmo, nil, item.NewDamage(2, 1, 0), item.Effects{}, nil}, nil, nil, nil, make(map[rune]([]*item.Item)), "", nil, nil, 0, worldmap.NewWounds(), newProgress(), map[string]int{}, nil}
 	player.AddItem(item.NewWeapon("shotgun"))
 	player.AddItem(item.NewArmour("leather jacket"))
 	player.AddItem(item.NewAmmo("shotgun shell"))
@@ -48,6 +49,11 @@ func newPlayer(location worldmap.Coordinates, name string, attrs map[string]int,
 	return player
 }
 
+// Hit points a player with a constitution score has at most
+func maxHp(con int) int {
+	return 10 + 2*worldmap.GetBonus(con)
+}
+
 func (p *Player) Render() ui.Element {
 	if p.mount != nil {
 		return icon.MergeIcons(p.icon, p.mount.GetIcon())
@@ -58,7 +64,7 @@ func (p *Player) Render() ui.Element {
 func (p *Player) MarshalJSON() ([]byte, error) {
 	buffer := bytes.NewBufferString("{")
 
-	keys := []string{"Name", "Location", "Icon", "Initiative", "Attributes", "Skills", "Crouching", "Money", "Unarmed", "Primary", "Secondary", "Armour", "Inventory", "MountID"}
+	keys := []string{"Name", "Location", "Depth", "Icon", "Initiative", "Attributes", "Skills", "Crouching", "Money", "Unarmed", "Primary", "Secondary", "Armour", "Inventory", "MountID", "Rest", "Wounds", "Progress", "Deposits", "Fuses"}
 
 	mountID := ""
 	if p.mount != nil {
@@ -68,6 +74,7 @@ func (p *Player) MarshalJSON() ([]byte, error) {
 	playerValues := map[string]interface{}{
 		"Name":       p.name,
 		"Location":   p.location,
+		"Depth":      p.depth,
 		"Icon":       p.icon,
 		"Initiative": p.initiative,
 		"Attributes": p.attributes,
@@ -79,6 +86,11 @@ func (p *Player) MarshalJSON() ([]byte, error) {
 		"Armour":     p.armour,
 		"Crouching":  p.crouching,
 		"MountID":    mountID,
+		"Rest":       p.rest,
+		"Wounds":     p.wounds,
+		"Progress":   p.progress,
+		"Deposits":   p.deposits,
+		"Fuses":      p.fuses,
 	}
 
 	var inventory []*item.Item
@@ -113,6 +125,7 @@ func (p *Player) UnmarshalJSON(data []byte) error {
 	type playerJson struct {
 		Name       string
 		Location   worldmap.Coordinates
+		Depth      int
 		Icon       icon.Icon
 		Initiative int
 		Attributes map[string]*worldmap.Attribute
@@ -125,6 +138,11 @@ func (p *Player) UnmarshalJSON(data []byte) error {
 		Armour     *item.Item
 		Inventory  []*item.Item
 		MountID    string
+		Rest       int
+		Wounds     *worldmap.Wounds
+		Progress   progress
+		Deposits   map[string]int
+		Fuses      []*fuse
 	}
 	v := playerJson{}
 
@@ -134,6 +152,7 @@ func (p *Player) UnmarshalJSON(data []byte) error {
 
 	p.name = v.Name
 	p.location = v.Location
+	p.depth = v.Depth
 	p.icon = v.Icon
 	p.initiative = v.Initiative
 	p.attributes = v.Attributes
@@ -145,6 +164,24 @@ func (p *Player) UnmarshalJSON(data []byte) error {
 	p.secondary = v.Secondary
 	p.armour = v.Armour
 	p.mountID = v.MountID
+	p.rest = v.Rest
+	p.wounds = v.Wounds
+	if p.wounds == nil {
+		p.wounds = worldmap.NewWounds()
+	}
+	p.progress = v.Progress
+	// Saves from before levelling existed
+	if p.progress.level == 0 {
+		p.progress = newProgress()
+	}
+	p.deposits = v.Deposits
+	if p.deposits == nil {
+		p.deposits = make(map[string]int)
+	}
+	p.fuses = v.Fuses
+	// Saves from before fatigue and the newer attributes existed
+	worldmap.AddNeeds(p.attributes)
+	worldmap.AddAttributes(p.attributes)
 	p.inventory = make(map[rune][]*item.Item)
 
 	for _, itm := range v.Inventory {
@@ -166,6 +203,35 @@ func (p *Player) SetCoordinates(x int, y int) {
 	}
 }
 
+func (p *Player) GetDepth() int {
+	return p.depth
+}
+
+func (p *Player) SetDepth(depth int) {
+	p.depth = depth
+	if p.mount != nil {
+		p.mount.SetDepth(depth)
+	}
+}
+
+// LightRadius is how far the brightest lit light the player carries lets them see in the dark
+func (p *Player) LightRadius() int {
+	radius := 0
+	for _, items := range p.inventory {
+		for _, itm := range items {
+			if itm.LightRadius() > radius {
+				radius = itm.LightRadius()
+			}
+		}
+	}
+	return radius
+}
+
+// Riders can swim their mounts across deep water
+func (p *Player) CanSwim() bool {
+	return p.mount != nil
+}
+
 func (p *Player) GetInitiative() int {
 	return p.initiative
 }
@@ -180,12 +246,22 @@ func (p *Player) attack(c worldmap.Creature, weapon item.WeaponComponent, hitBon
 		message.Enqueue(fmt.Sprintf("You miss %s.", c.GetName().WithDefinite()))
 	}
 	if c.IsDead() {
-		message.Enqueue(fmt.Sprintf("%s died.", c.GetName().WithDefinite()))
+		p.killed(c)
+	}
+}
 
-		// If non-enemy dead, send murder event
-		if c.GetAlignment() == worldmap.Neutral {
-			event.Emit(event.NewMurder(p, c, p.location))
-		}
+func (p *Player) killed(c worldmap.Creature) {
+	message.Enqueue(fmt.Sprintf("%s died.", c.GetName().WithDefinite()))
+	// Murdering townsfolk earns nothing but a bounty
+	if c.GetAlignment() == worldmap.Enemy {
+		p.gainXp(killEnemyXp)
+	} else if n, ok := c.(*npc.Npc); !ok || !n.Human() {
+		p.gainXp(killOtherXp)
+	}
+
+	// If non-enemy dead, send murder event
+	if c.GetAlignment() == worldmap.Neutral {
+		event.Emit(event.NewMurder(p, c, p.location))
 	}
 }
 
@@ -239,6 +315,7 @@ func (p *Player) MeleeAttack(c worldmap.Creature) {
 	hitBonus := worldmap.GetBonus(p.attributes["str"].Value()) + proficiencyBonus
 	damageBonus := worldmap.GetBonus(p.attributes["str"].Value()) + proficiencyBonus
 
+	weapon.Wear()
 	p.attack(c, weapon, hitBonus, damageBonus)
 }
 
@@ -316,6 +393,17 @@ func (p *Player) RangedAttack() bool {
 func (p *Player) useRangedWeapon(weapon item.WeaponComponent) {
 	target := p.findTarget()
 
+	if weapon.Jams() {
+		weapon.Fire()
+		message.Enqueue("Your weapon jams and the round is wasted.")
+		return
+	}
+	if weapon.UsesPowder() && p.world.PowderWet(p.location.X, p.location.Y) {
+		weapon.Fire()
+		message.Enqueue("Your powder is wet and the weapon misfires.")
+		return
+	}
+
 	weapon.Fire()
 	if target == nil {
 		message.Enqueue("You fire your weapon at the ground.")
@@ -325,9 +413,9 @@ func (p *Player) useRangedWeapon(weapon item.WeaponComponent) {
 	tX, tY := target.GetCoordinates()
 	distance := worldmap.Distance(p.location.X, p.location.Y, tX, tY)
 	if distance < float64(weapon.Range) {
-		coverPenalty := 0
+		coverPenalty := p.world.StormPenalty(p.location.X, p.location.Y, tX, tY)
 		if p.world.TargetBehindCover(p, target) {
-			coverPenalty = 5
+			coverPenalty += 5
 		}
 
 		proficiencyBonus := 0
@@ -349,67 +437,104 @@ func (p *Player) TakeDamage(damage item.Damage, effects item.Effects, bonus int)
 	total_damage := damage.Damage() + bonus
 	p.attributes["hp"].AddEffect(item.NewInstantEffect(-total_damage))
 	p.applyEffects(effects)
+	if wound := p.wounds.Inflict(p.attributes, total_damage); wound != "" {
+		message.Enqueue(woundMessages[wound])
+	}
+	if p.Asleep() {
+		p.wake("You are woken up by a sharp pain!")
+	}
 }
 
 func (p *Player) GetStats() []string {
-	stats := make([]string, 5)
-	stats[0] = fmt.Sprintf("HP:%s", p.attributes["hp"].Status())
-	stats[1] = fmt.Sprintf("STR:%d(%+d)", p.attributes["str"].Value(), worldmap.GetBonus(p.attributes["str"].Value()))
-	stats[2] = fmt.Sprintf("DEX:%d(%+d)", p.attributes["dex"].Value(), worldmap.GetBonus(p.attributes["dex"].Value()))
-	stats[3] = fmt.Sprintf("AC:%d", p.attributes["ac"].Value())
-	stats[4] = fmt.Sprintf("$%.2f", float64(p.money)/100)
+	stats := []string{fmt.Sprintf("HP:%s", p.attributes["hp"].Status())}
+	for _, attr := range worldmap.Attributes {
+		stats = append(stats, fmt.Sprintf("%s:%d(%+d)", strings.ToUpper(attr), p.attributes[attr].Value(), worldmap.GetBonus(p.attributes[attr].Value())))
+	}
+	stats = append(stats, fmt.Sprintf("AC:%d", p.attributes["ac"].Value()))
+	stats = append(stats, fmt.Sprintf("$%.2f", float64(p.money)/100))
+	stats = append(stats, fmt.Sprintf("Lvl:%d", p.progress.level))
 	if p.crouching {
 		stats = append(stats, "Crouching")
 	}
-	if p.attributes["hunger"].Value() > p.attributes["hunger"].Maximum()/2 {
-		stats = append(stats, "Hungry")
+	if p.Asleep() {
+		stats = append(stats, "Asleep")
 	}
-	if p.attributes["thirst"].Value() > p.attributes["thirst"].Maximum()/2 {
-		stats = append(stats, "Thirsty")
+	for _, need := range worldmap.Needs {
+		if status := worldmap.NeedStatus(need, p.attributes[need]); status != "" {
+			stats = append(stats, status)
+		}
 	}
+	stats = append(stats, p.wounds.Status()...)
 
 	return stats
 }
 
 func (p *Player) PrintInventory() {
-	ui.WriteText(0, 0, "Wearing: ")
+	p.PrintInventoryAt(0, 0)
+}
 
-	position := 2
+// PrintInventoryAt prints equipped items and the inventory starting from x, y
+func (p *Player) PrintInventoryAt(x, y int) {
+	ui.WriteText(x, y, "Wearing: ")
+
+	position := y + 2
 	if p.primary != nil {
-		equippedWeaponText := fmt.Sprintf("%s - %s", string(p.primary.GetKey()), p.primary.GetName())
-		ui.WriteText(0, position, equippedWeaponText)
+		equippedWeaponText := fmt.Sprintf("%s - %s", string(p.primary.GetKey()), inventoryName(p.primary))
+		ui.WriteText(x, position, equippedWeaponText)
 		position++
 	}
 
 	if p.secondary != nil {
-		equippedWeaponText := fmt.Sprintf("%s - %s", string(p.secondary.GetKey()), p.secondary.GetName())
-		ui.WriteText(0, position, equippedWeaponText)
+		equippedWeaponText := fmt.Sprintf("%s - %s", string(p.secondary.GetKey()), inventoryName(p.secondary))
+		ui.WriteText(x, position, equippedWeaponText)
 		position++
 	}
 
 	if p.armour != nil {
 		equippedArmourText := fmt.Sprintf("%s - %s", string(p.armour.GetKey()), p.armour.GetName())
-		ui.WriteText(0, 2, equippedArmourText)
+		ui.WriteText(x, position, equippedArmourText)
 		position++
 	}
 	position++
-	ui.WriteText(0, position, "Inventory: ")
+	ui.WriteText(x, position, "Inventory: ")
 	position += 2
 
-	for k, items := range p.inventory {
-		itemString := fmt.Sprintf("%s - %s", string(k), items[0].GetName())
+	for _, k := range sortedKeys(p.inventory) {
+		items := p.inventory[k]
+		itemString := fmt.Sprintf("%s - %s", string(k), inventoryName(items[0]))
 		if len(items) > 1 {
 			itemString += fmt.Sprintf(" x%d", len(items))
 		}
-		ui.WriteText(0, position, itemString)
+		ui.WriteText(x, position, itemString)
 		position++
 	}
 }
 
+// Name of an item as listed in the inventory, with how worn it is for weapons
+func inventoryName(itm *item.Item) string {
+	if itm.HasComponent("weapon") {
+		if weapon := itm.Component("weapon").(item.WeaponComponent); weapon.Condition != nil {
+			return fmt.Sprintf("%s (%s)", itm.GetName(), weapon.ConditionDescription())
+		}
+	}
+	return itm.GetName()
+}
+
+// Inventory keys in the order they are listed on screen
+func sortedKeys(inventory map[rune][]*item.Item) []rune {
+	keys := make([]rune, 0, len(inventory))
+	for k := range inventory {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
 func (p *Player) PrintItemsByType(itemType string) {
 	position := 0
-	for k, items := range p.inventory {
-		if !p.inventory[k][0].HasComponent(itemType) {
+	for _, k := range sortedKeys(p.inventory) {
+		items := p.inventory[k]
+		if !items[0].HasComponent(itemType) {
 			continue
 		}
 		itemString := fmt.Sprintf("%s - %s", string(k), items[0].GetName())
@@ -422,7 +547,7 @@ func (p *Player) PrintItemsByType(itemType string) {
 }
 
 func (p *Player) IsDead() bool {
-	return p.attributes["hp"].Value() == 0 || p.attributes["hunger"].Value() == p.attributes["hunger"].Maximum() || p.attributes["thirst"].Value() == p.attributes["thirst"].Maximum()
+	return p.attributes["hp"].Value() == 0 || worldmap.Starved(p.attributes)
 }
 
 func (p *Player) AttackHits(roll int) bool {
@@ -753,7 +878,11 @@ func (p *Player) ConsumeItem() bool {
 				ui.GetInput()
 			} else {
 				if itm.HasComponent("consumable") {
-					message.Enqueue(fmt.Sprintf("You ate a %s.", itm.GetName()))
+					if len(itm.Component("consumable").(item.ConsumableComponent).Effects) == 0 {
+						message.Enqueue(fmt.Sprintf("You used a %s.", itm.GetName()))
+					} else {
+						message.Enqueue(fmt.Sprintf("You ate a %s.", itm.GetName()))
+					}
 					p.consume(itm)
 					return true
 				} else {
@@ -790,23 +919,22 @@ func (p *Player) applyEffects(effects item.Effects) {
 
 func (p *Player) consume(itm *item.Item) {
 	originalHp := p.attributes["hp"].Value()
-	originalHunger := p.attributes["hunger"].Value()
-	originalThirst := p.attributes["thirst"].Value()
+	originalLevels := p.needLevels()
 
 	p.applyEffects(itm.Component("consumable").(item.ConsumableComponent).Effects)
+	if treatment, ok := itm.Component("treatment").(item.TreatmentComponent); ok {
+		p.treat(treatment)
+	}
 
 	if p.attributes["hp"].Value() > originalHp {
 		message.Enqueue(fmt.Sprintf("You healed for %d hit points.", p.attributes["hp"].Value()-originalHp))
 	}
 
-	hungerThresh := p.attributes["hunger"].Maximum() / 2
-	if originalHunger > hungerThresh && p.attributes["hunger"].Value() <= hungerThresh {
-		message.Enqueue("You are no longer hungry.")
-	}
-
-	thirstThresh := p.attributes["thirst"].Maximum() / 2
-	if originalThirst > hungerThresh && p.attributes["thirst"].Value() <= thirstThresh {
-		message.Enqueue("You are no longer thirsty.")
+	levels := p.needLevels()
+	for _, need := range worldmap.Needs {
+		if originalLevels[need] > worldmap.Satisfied && levels[need] == worldmap.Satisfied {
+			message.Enqueue(needSatisfiedMessages[need])
+		}
 	}
 }
 
@@ -865,6 +993,39 @@ func (p *Player) Move(action ui.PlayerAction) (bool, ui.PlayerAction) {
 		return true, ui.NoAction
 	}
 
+	if destination, fare, ok := p.world.BoardingStagecoach(newX, newY); ok {
+		return rideStagecoach(p, newX, newY, destination, fare), ui.NoAction
+	}
+
+	if p.mount == nil && p.wounds.Stumbles() {
+		message.Enqueue("You stumble on your broken leg.")
+		return true, ui.NoAction
+	}
+
+	if p.world.IsDeepWater(newX, newY) && !p.CanSwim() {
+		message.PrintMessage("The water is too deep to cross on foot.")
+		return false, ui.NoAction
+	}
+
+	if p.CanSwim() && p.world.DragUnder(newX, newY, p, p.mount) {
+		message.Enqueue("The current drags you and your mount under!")
+		return true, ui.NoAction
+	}
+
+	if p.world.StuckInMud(newX, newY) {
+		if p.mount != nil {
+			message.Enqueue("Your mount struggles through the mud.")
+		} else {
+			message.Enqueue("Your boots sink into the mud.")
+		}
+		return true, ui.NoAction
+	}
+
+	if p.world.SlowGoing(newX, newY) {
+		message.Enqueue("You struggle across the rough ground.")
+		return true, ui.NoAction
+	}
+
 	if p.mount != nil {
 
 		// If mount has not moved already, player can still do an action
@@ -910,14 +1071,42 @@ func (p *Player) OverEncumbered() bool {
 }
 
 func (p *Player) findTarget() worldmap.Creature {
+	x, y, ok := p.selectLocation("Select target")
+	if !ok || !p.world.IsOccupied(x, y) {
+		message.PrintMessage("Never mind...")
+		return nil
+	}
+
+	// If a creature is there, return it.
+	c := p.world.GetCreature(x, y)
+
+	var m *npc.Npc
+	if r, ok := c.(npc.Rider); ok {
+		m = r.Mount()
+	}
+
+	if m != nil {
+		message.PrintMessage(fmt.Sprintf("%s is riding %s. Would you like to target %s instead? [yn]", c.GetName().WithDefinite(), m.GetName().WithIndefinite(), m.GetName().WithDefinite()))
+
+		input := ui.GetInput()
+		if input == ui.Confirm {
+			return m
+		}
+	}
+
+	return c
+}
+
+// Moves a cursor around the screen for the player to pick a location, returning false if they back out
+func (p *Player) selectLocation(prompt string) (int, int, bool) {
 	x, y := p.GetCoordinates()
 	// In terms of viewer space rather than world space
 	rX, rY := x-p.world.GetViewerX(), y-p.world.GetViewerY()
 	width, height := p.world.GetWidth(), p.world.GetHeight()
 	vWidth, vHeight := p.world.GetViewerWidth(), p.world.GetViewerHeight()
 	for {
-		message.PrintMessage("Select target")
-		ui.DrawElement(rX, rY, ui.NewElement('X', termbox.ColorYellow))
+		message.PrintMessage(prompt)
+		ui.DrawElement(rX, rY, ui.NewElement('X', ui.ColourYellow))
 		x, y = p.world.GetViewerX()+rX, p.world.GetViewerY()+rY
 		oX, oY := rX, rY
 
@@ -962,29 +1151,9 @@ func (p *Player) findTarget() worldmap.Creature {
 				}
 			}
 		} else if action == ui.CancelAction { // Counter intuitive at the moment
-			if p.world.IsOccupied(x, y) {
-				// If a creature is there, return it.
-				c := p.world.GetCreature(x, y)
-
-				var m *npc.Npc
-				if r, ok := c.(npc.Rider); ok {
-					m = r.Mount()
-				}
-
-				if m != nil {
-					message.PrintMessage(fmt.Sprintf("%s is riding %s. Would you like to target %s instead? [yn]", c.GetName().WithDefinite(), m.GetName().WithIndefinite(), m.GetName().WithDefinite()))
-
-					input := ui.GetInput()
-					if input == ui.Confirm {
-						return m
-					}
-				}
-
-				return c
-			} else {
-				message.PrintMessage("Never mind...")
-				return nil
-			}
+			return x, y, true
+		} else if action == ui.Exit {
+			return x, y, false
 		}
 
 		// overwrite
@@ -1086,6 +1255,14 @@ func (p *Player) ToggleDoor(open bool) bool {
 		return false
 	}
 
+	// Containers can be opened as well as doors
+	if open && !p.world.IsDoor(x, y) {
+		if container := p.containerAt(x, y); container != nil {
+			openContainer(p, container)
+			return true
+		}
+	}
+
 	// If there is a door, toggle its position if it's not already there
 	if p.world.IsDoor(x, y) {
 		if p.world.Door(x, y).Open() != open {
@@ -1107,6 +1284,8 @@ func (p *Player) ToggleDoor(open bool) bool {
 				message.PrintMessage("The door is already closed.")
 			}
 		}
+	} else if open {
+		message.PrintMessage("You see nothing there to open.")
 	} else {
 		message.PrintMessage("You see no door there.")
 	}
@@ -1261,24 +1440,40 @@ func (p *Player) Talk() {
 		for j := -1; j <= 1; j++ {
 			x := p.location.X + i
 			y := p.location.Y + j
-			if p.world.IsValid(x, y) {
-				creature := p.world.GetCreature(x, y).(*npc.Npc)
-				interaction := creature.Talk()
-				switch interaction {
-				case npc.Trade:
-					ui.GetInput()
-					trade(p, creature)
-				case npc.Bounty:
-					ui.GetInput()
-					claimBounties(p, creature)
-				case npc.DoesNotSpeak:
-					message.PrintMessage(fmt.Sprintf("You try to talk to %s. It doesn't seem to respond.", creature.GetName().WithDefinite()))
-				}
-				return
+			if !p.world.IsValid(x, y) {
+				continue
+			}
+			creature, ok := p.world.GetCreature(x, y).(*npc.Npc)
+			if !ok {
+				continue
+			}
+			interaction := creature.Talk()
+			switch interaction {
+			case npc.Trade:
+				ui.GetInput()
+				trade(p, creature)
+			case npc.Bounty:
+				ui.GetInput()
+				claimBounties(p, creature)
+			case npc.Treatment:
+				ui.GetInput()
+				visitDoctor(p, creature)
+			case npc.Banking:
+				ui.GetInput()
+				visitBank(p, creature)
+			case npc.Gambling:
+				ui.GetInput()
+				gamble(p, creature)
+			case npc.Tickets:
+				ui.GetInput()
+				buyTicket(p, creature)
+			case npc.DoesNotSpeak:
+				message.PrintMessage(fmt.Sprintf("You try to talk to %s. It doesn't seem to respond.", creature.GetName().WithDefinite()))
 			}
+			return
 		}
-		message.PrintMessage("You talk to yourself.")
 	}
+	message.PrintMessage("You talk to yourself.")
 }
 
 func (p *Player) Pickpocket() bool {
@@ -1389,8 +1584,8 @@ func (p *Player) Use() bool {
 				if itm.HasComponent("usable") {
 					if itm.HasComponent("key") {
 						lockpickingBonus := 0.0
-						if p.hasSkill(worldmap.Lockpicking) {
-							lockpickingBonus = 0.2
+						if rank := p.skillRank(worldmap.Lockpicking); rank > 0 {
+							lockpickingBonus = 0.1 * float64(rank+1)
 						}
 
 						// Keys are multiple use
@@ -1400,33 +1595,65 @@ func (p *Player) Use() bool {
 							return false
 						}
 
-						if !p.world.IsDoor(x, y) {
+						// Keys work on locked containers as well as doors
+						var lock lockable
+						var fits func(item.KeyComponent) bool
+						lockName := "door"
+						if p.world.IsDoor(x, y) {
+							door := p.world.Door(x, y)
+							lock = door
+							fits = func(key item.KeyComponent) bool { return door.KeyFits(key) }
+						} else if container := p.containerAt(x, y); container != nil && container.Component("container").(*item.ContainerComponent).Lockable() {
+							cc := container.Component("container").(*item.ContainerComponent)
+							lock, fits, lockName = cc, cc.KeyFits, container.GetName()
+						}
+
+						if lock == nil {
 							message.Enqueue("You see no door here.")
 						} else {
-							door := p.world.Door(x, y)
 							// Can have multiple keys that unlock different doors
 							allKeys := p.inventory[c]
 							anyFit := false
 							for _, key := range allKeys {
-								anyFit = anyFit || door.KeyFits(key.Component("key").(item.KeyComponent))
+								anyFit = anyFit || fits(key.Component("key").(item.KeyComponent))
 							}
 
 							if anyFit {
 								if itm.Component("key").(item.KeyComponent).Works(lockpickingBonus) {
-									door.ToggleLocked()
-									if door.Locked() {
-										message.Enqueue("You lock the door.")
+									lock.ToggleLocked()
+									if lock.Locked() {
+										message.Enqueue(fmt.Sprintf("You lock the %s.", lockName))
 									} else {
-										message.Enqueue("You unlock the door.")
+										message.Enqueue(fmt.Sprintf("You unlock the %s.", lockName))
+										// Picking a lock is more of an achievement than using the right key
+										if itm.Component("key").(item.KeyComponent).Chance < 1 {
+											p.gainXp(lockpickXp)
+										}
 									}
 								} else {
 									message.Enqueue(fmt.Sprintf("The %s didn't work.", itm.GetName()))
 								}
 							} else {
-								message.Enqueue("This does not work for this door.")
+								message.Enqueue(fmt.Sprintf("This does not work for this %s.", lockName))
 							}
 						}
 						itm = p.GetItem(c)
+					} else if itm.HasComponent("container") {
+						return p.putOnSaddlebags(itm)
+					} else if itm.HasComponent("canteen") {
+						p.AddItem(itm)
+						return p.useCanteen(itm)
+					} else if itm.HasComponent("repair") && !p.cleanWeapon(itm) {
+						p.AddItem(itm)
+						return false
+					} else if itm.HasComponent("mining") && !p.mine() {
+						p.AddItem(itm)
+						return false
+					} else if itm.HasComponent("light") {
+						p.AddItem(itm)
+						return p.toggleLight(itm)
+					} else if itm.HasComponent("campfire") {
+						return p.makeCampfire(itm)
 					}
 					name := itm.GetName()
 					if itm.TryBreaking() {
@@ -1448,6 +1675,109 @@ func (p *Player) Use() bool {
 	}
 }
 
+// Cleans a weapon the player is wielding or carrying with a kit, returning whether it was used
+func (p *Player) cleanWeapon(kit *item.Item) bool {
+	keys := p.KeysByType("weapon")
+	for _, w := range []*item.Item{p.secondary, p.primary} {
+		if w != nil {
+			keys = string(w.GetKey()) + keys
+		}
+	}
+	message.PrintMessage(fmt.Sprintf("Which weapon do you want to clean? [%s]", keys))
+	command, c := ui.GetItemSelection()
+	if command != ui.SpecificItem {
+		message.PrintMessage("Never mind.")
+		return false
+	}
+
+	weapon := p.weaponByKey(c)
+	if weapon == nil {
+		message.PrintMessage("You can only clean weapons.")
+		ui.GetInput()
+		return false
+	}
+
+	repair := kit.Component("repair").(item.RepairComponent)
+	component := weapon.Component("weapon").(item.WeaponComponent)
+	if component.GetCondition() >= repair.Limit {
+		message.PrintMessage(fmt.Sprintf("A cleaning won't do any more for your %s.", weapon.GetName()))
+		ui.GetInput()
+		return false
+	}
+
+	component.Repair(repair.Amount, repair.Limit)
+	message.Enqueue(fmt.Sprintf("You clean your %s.", weapon.GetName()))
+	return true
+}
+
+// Fills a canteen when there is water nearby, otherwise takes a drink from it
+func (p *Player) useCanteen(itm *item.Item) bool {
+	canteen := itm.Component("canteen").(*item.CanteenComponent)
+	if !canteen.Full() && p.world.NearWater(p.location.X, p.location.Y) {
+		canteen.Refill()
+		message.Enqueue(fmt.Sprintf("You fill your %s.", itm.GetName()))
+		return true
+	}
+
+	if !canteen.Drink() {
+		message.PrintMessage(fmt.Sprintf("Your %s is empty.", itm.GetName()))
+		ui.GetInput()
+		return false
+	}
+
+	originalLevels := p.needLevels()
+	p.attributes["thirst"].AddEffect(item.NewInstantEffect(-canteen.Quench))
+	message.Enqueue(fmt.Sprintf("You drink from your %s.", itm.GetName()))
+	if originalLevels["thirst"] > worldmap.Satisfied && p.needLevels()["thirst"] == worldmap.Satisfied {
+		message.Enqueue(needSatisfiedMessages["thirst"])
+	}
+	return true
+}
+
+// The weapon the player is wielding or carrying with the key given
+func (p *Player) weaponByKey(key rune) *item.Item {
+	for _, w := range []*item.Item{p.primary, p.secondary} {
+		if w != nil && w.GetKey() == key {
+			return w
+		}
+	}
+	if items := p.inventory[key]; items != nil && items[0].HasComponent("weapon") {
+		return items[0]
+	}
+	return nil
+}
+
+// Straps saddlebags on a mount so it can carry things for the player
+func (p *Player) putOnSaddlebags(saddlebags *item.Item) bool {
+	x, y, _ := p.SelectDirection()
+	if p.location == (worldmap.Coordinates{x, y}) {
+		p.AddItem(saddlebags)
+		return false
+	}
+
+	n, ok := p.world.GetCreature(x, y).(*npc.Npc)
+	if !ok || !n.IsMount() {
+		message.Enqueue(fmt.Sprintf("There is nothing there to put the %s on.", saddlebags.GetName()))
+		p.AddItem(saddlebags)
+		return true
+	}
+	if n.Saddlebags() != nil {
+		message.Enqueue(fmt.Sprintf("%s is already carrying saddlebags.", n.GetName().WithDefinite()))
+		p.AddItem(saddlebags)
+		return true
+	}
+
+	n.PutOnSaddlebags(saddlebags)
+	message.Enqueue(fmt.Sprintf("You put the %s on %s.", saddlebags.GetName(), n.GetName().WithDefinite()))
+	return true
+}
+
+// Anything with a lock that keys can be used on
+type lockable interface {
+	Locked() bool
+	ToggleLocked()
+}
+
 func (p *Player) hasSkill(skill worldmap.Skill) bool {
 	for _, s := range p.skills {
 		if s == skill {
@@ -1457,6 +1787,11 @@ func (p *Player) hasSkill(skill worldmap.Skill) bool {
 	return false
 }
 
+// Rolls a d20 with the player's charisma bonus, succeeding if the roll meets the difficulty
+func (p *Player) charismaCheck(difficulty int) bool {
+	return rand.Intn(20)+1+worldmap.Bonus(p.attributes, "cha") >= difficulty
+}
+
 func (p *Player) hasWeaponProficiency(weapon item.WeaponComponent) bool {
 
 	var skill worldmap.Skill
@@ -1495,15 +1830,27 @@ func (p *Player) AddMount(m *npc.Npc) {
 }
 
 func (p *Player) GetVisionDistance() int {
-	return 20
+	return p.wounds.VisionDistance(worldmap.VisionDistance(p.attributes))
 }
 
 func (p *Player) Update() {
+	originalLevels := p.needLevels()
 
 	for _, attribute := range p.attributes {
 		attribute.Update()
 	}
 
+	if p.Asleep() {
+		p.attributes["fatigue"].AddEffect(item.NewInstantEffect(-p.rest))
+	}
+	p.world.ApplyWeather(p.attributes, p.location.X, p.location.Y)
+	worldmap.ApplyNeeds(p.attributes)
+	p.updateNeeds(originalLevels)
+	for _, wound := range p.wounds.Update(p.attributes) {
+		message.Enqueue(woundHealedMessages[wound])
+	}
+	p.burnFuses()
+
 	// Apply armour AC bonus
 	if p.armour != nil {
 		p.attributes["ac"].AddEffect(item.NewEffect(p.armour.Component("armour").(item.ArmourComponent).Bonus, 1, true))
@@ -1531,6 +1878,7 @@ func (p *Player) LoadMount(mounts []*npc.Npc) {
 type Player struct {
 	name       string
 	location   worldmap.Coordinates
+	depth      int
 	icon       icon.Icon
 	initiative int
 	attributes map[string]*worldmap.Attribute
@@ -1545,4 +1893,12 @@ type Player struct {
 	mountID    string
 	mount      *npc.Npc
 	world      *worldmap.Map
+	// Fatigue recovered each turn while asleep, zero if awake
+	rest     int
+	wounds   *worldmap.Wounds
+	progress progress
+	// Money the player has in each town's bank, which stays there whatever becomes of the teller
+	deposits map[string]int
+	// Explosives the player has thrown that have yet to go off
+	fuses []*fuse
 }
@@ -0,0 +1,54 @@
+package player
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/npc"
+	"github.com/onorton/cowboysindians/ui"
+)
+
+// Sells the player a ticket from a station agent and puts them on the train if it is in
+func buyTicket(p *Player, agent *npc.Npc) {
+	stations := p.world.Stations()
+	from := p.world.NearestStation(p.location.X, p.location.Y)
+	if from == -1 || len(stations) < 2 {
+		message.PrintMessage("\"No trains run from here.\"")
+		return
+	}
+
+	if !p.world.InStation(from) {
+		message.PrintMessage(fmt.Sprintf("\"Next train gets in %d turns from now. Come back then.\"", p.world.NextTrain(from)))
+		return
+	}
+
+	destinations := make([]int, 0, len(stations)-1)
+	options := make([]string, 0, len(stations)-1)
+	for i, s := range stations {
+		if i != from {
+			destinations = append(destinations, i)
+			options = append(options, fmt.Sprintf("%d) %s $%.2f", len(destinations), s.Name, float64(p.world.Fare(from, i))/100))
+		}
+	}
+
+	message.PrintMessage(fmt.Sprintf("\"Train's waiting.\" Buy a ticket to: %s", strings.Join(options, ", ")))
+	command, c := ui.GetItemSelection()
+	choice := int(c - '1')
+	if command != ui.SpecificItem || choice < 0 || choice >= len(destinations) {
+		message.PrintMessage("Never mind.")
+		return
+	}
+
+	to := destinations[choice]
+	fare := p.world.Fare(from, to)
+	if fare > p.money {
+		message.PrintMessage("\"Come back when you can pay the fare.\"")
+		return
+	}
+
+	p.money -= fare
+	agent.AddMoney(fare)
+	p.world.RideTrain(from, to)
+	message.Enqueue(fmt.Sprintf("You ride the train to %s.", stations[to].Name))
+}
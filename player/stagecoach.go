@@ -0,0 +1,26 @@
+package player
+
+import (
+	"fmt"
+
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/ui"
+)
+
+// Offers the player a seat on a stagecoach waiting in town, returning true if they took it
+func rideStagecoach(p *Player, x, y int, destination string, fare int) bool {
+	message.PrintMessage(fmt.Sprintf("Ride the stagecoach to %s for $%.2f? [yn]", destination, float64(fare)/100))
+	if ui.GetInput() != ui.Confirm {
+		return false
+	}
+
+	if fare > p.money {
+		message.PrintMessage("You can't afford the fare.")
+		return false
+	}
+
+	p.money -= fare
+	p.world.RideStagecoach(x, y)
+	message.Enqueue(fmt.Sprintf("You ride the stagecoach to %s.", destination))
+	return true
+}
@@ -0,0 +1,222 @@
+package player
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/onorton/cowboysindians/event"
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/ui"
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// Damage done by throwing something that isn't made for it
+var improvisedDamage = item.NewDamage(2, 1, 0)
+
+// A lit explosive lying where it was thrown
+type fuse struct {
+	location  worldmap.Coordinates
+	explosive *item.Item
+	turns     int
+	// Loaded from a save, so the explosive on the map is no longer the same item and has to be found by name
+	reloaded bool
+}
+
+func (f *fuse) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+
+	keys := []string{"Location", "Explosive", "Turns"}
+	values := map[string]interface{}{
+		"Location":  f.location,
+		"Explosive": f.explosive,
+		"Turns":     f.turns,
+	}
+
+	for i, key := range keys {
+		jsonValue, err := json.Marshal(values[key])
+		if err != nil {
+			return nil, err
+		}
+		buffer.WriteString(fmt.Sprintf("\"%s\":%s", key, jsonValue))
+		if i < len(keys)-1 {
+			buffer.WriteString(",")
+		}
+	}
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (f *fuse) UnmarshalJSON(data []byte) error {
+
+	type fuseJson struct {
+		Location  worldmap.Coordinates
+		Explosive *item.Item
+		Turns     int
+	}
+
+	var v fuseJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	f.location = v.Location
+	f.explosive = v.Explosive
+	f.turns = v.Turns
+	f.reloaded = true
+
+	return nil
+}
+
+// How far the player can throw something, further the stronger they are
+func (p *Player) throwingRange() int {
+	return 6 + worldmap.Bonus(p.attributes, "str")
+}
+
+func (p *Player) Throw() bool {
+	for {
+		message.PrintMessage(fmt.Sprintf("What do you want to throw? [%s or *]", p.GetInventoryKeys()))
+		s, c := ui.GetItemSelection()
+
+		switch s {
+		case ui.All:
+			p.PrintInventory()
+			continue
+		case ui.Cancel:
+			message.PrintMessage("Never mind.")
+			return false
+		case ui.SpecificItem:
+			if c == item.Money(0).GetKey() {
+				message.PrintMessage("You can't throw that.")
+				ui.GetInput()
+				continue
+			}
+			itm := p.GetItem(c)
+			if itm == nil {
+				message.PrintMessage("You don't have that item.")
+				ui.GetInput()
+			} else {
+				return p.throw(itm)
+			}
+		case ui.AllRelevant:
+			return false
+		}
+	}
+}
+
+func (p *Player) throw(itm *item.Item) bool {
+	x, y, ok := p.selectLocation(fmt.Sprintf("Where do you want to throw the %s?", itm.GetName()))
+	if !ok {
+		message.PrintMessage("Never mind.")
+		p.AddItem(itm)
+		return false
+	}
+	if worldmap.Distance(p.location.X, p.location.Y, x, y) > float64(p.throwingRange()) {
+		message.PrintMessage("You can't throw that far.")
+		p.AddItem(itm)
+		return false
+	}
+
+	message.Enqueue(fmt.Sprintf("You throw the %s.", itm.GetName()))
+	x, y = p.world.LandingSpot(p.location.X, p.location.Y, x, y)
+	if c := p.world.GetCreature(x, y); c != nil && c.GetAlignment() != worldmap.Player {
+		p.hitWithThrown(c, itm)
+	}
+
+	if itm.HasComponent("explosive") {
+		explosive := itm.Component("explosive").(item.ExplosiveComponent)
+		// Without a fuse, it goes off as soon as it lands
+		if explosive.Fuse == 0 {
+			p.explode(x, y, itm)
+			return true
+		}
+		p.fuses = append(p.fuses, &fuse{worldmap.Coordinates{x, y}, itm, explosive.Fuse, false})
+		message.Enqueue(fmt.Sprintf("The fuse on the %s fizzes.", itm.GetName()))
+	}
+	p.world.PlaceItem(x, y, itm)
+	return true
+}
+
+// Knives and tomahawks are made for throwing; anything else does little more than bruise
+func (p *Player) hitWithThrown(c worldmap.Creature, itm *item.Item) {
+	weapon := item.WeaponComponent{0, item.NoAmmo, nil, improvisedDamage, item.Effects{}, nil}
+	damageBonus := 0
+	if itm.HasComponent("throwable") {
+		weapon = itm.Component("weapon").(item.WeaponComponent)
+		damageBonus = worldmap.Bonus(p.attributes, "str")
+	}
+	p.attack(c, weapon, worldmap.Bonus(p.attributes, "dex"), damageBonus)
+}
+
+// Counts down the fuses on explosives the player has thrown, setting off any that burn down
+func (p *Player) burnFuses() {
+	burning := make([]*fuse, 0, len(p.fuses))
+	for _, f := range p.fuses {
+		f.turns--
+		if f.turns > 0 {
+			burning = append(burning, f)
+			continue
+		}
+		// The fuse goes out if the explosive has been picked up or its chunk is no longer loaded
+		x, y := f.location.X, f.location.Y
+		if !p.world.IsValid(x, y) {
+			continue
+		}
+		if p.world.PowderWet(x, y) {
+			if p.world.IsVisible(p, x, y) {
+				message.Enqueue(fmt.Sprintf("The rain puts out the fuse on the %s.", f.explosive.GetName()))
+			}
+			continue
+		}
+		explosive := f.explosive
+		if f.reloaded {
+			explosive = p.world.Item(x, y, explosive.GetName())
+		}
+		if explosive != nil && p.world.RemoveItem(x, y, explosive) {
+			p.explode(x, y, explosive)
+		}
+	}
+	p.fuses = burning
+}
+
+// Sets off an explosive, hurting everyone within its radius and blowing through walls or starting fires if it can
+func (p *Player) explode(x, y int, itm *item.Item) {
+	explosive := itm.Component("explosive").(item.ExplosiveComponent)
+	if p.world.IsVisible(p, x, y) {
+		message.Enqueue(fmt.Sprintf("The %s explodes!", itm.GetName()))
+	} else {
+		message.Enqueue("You hear an explosion.")
+	}
+
+	for bY := y - explosive.Radius; bY <= y+explosive.Radius; bY++ {
+		for bX := x - explosive.Radius; bX <= x+explosive.Radius; bX++ {
+			if !p.world.IsValid(bX, bY) || worldmap.Distance(x, y, bX, bY) > float64(explosive.Radius) {
+				continue
+			}
+			if explosive.Breach {
+				p.world.Blast(bX, bY)
+			}
+			if explosive.Ignites {
+				p.world.Ignite(bX, bY)
+			}
+
+			c := p.world.GetCreature(bX, bY)
+			if c == nil || c.IsDead() {
+				continue
+			}
+			if c.GetAlignment() == worldmap.Player {
+				message.Enqueue("You are caught in the blast!")
+				p.TakeDamage(explosive.Damage, explosive.Effects, 0)
+				continue
+			}
+			event.Emit(event.NewAttack(p, c))
+			c.TakeDamage(explosive.Damage, explosive.Effects, 0)
+			if c.IsDead() {
+				p.killed(c)
+			}
+		}
+	}
+}
@@ -2,13 +2,18 @@ package player
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/onorton/cowboysindians/item"
 	"github.com/onorton/cowboysindians/message"
 	"github.com/onorton/cowboysindians/npc"
 	"github.com/onorton/cowboysindians/ui"
 	"github.com/onorton/cowboysindians/worldmap"
 )
 
+// Offers a shopkeeper will hear before giving up on a deal
+const shopkeeperPatience = 3
+
 func trade(p *Player, npc *npc.Npc) {
 	tradeComplete := false
 	for !tradeComplete {
@@ -29,9 +34,9 @@ func trade(p *Player, npc *npc.Npc) {
 				item := npcItems[selection]
 				if item != nil {
 					validSelection = true
-					value := item[0].GetValue()
-					if p.hasSkill(worldmap.Haggling) {
-						value -= value / 5
+					value, deal := p.negotiate(npc, item[0], false)
+					if !deal {
+						break
 					}
 
 					if value > p.money {
@@ -39,9 +44,12 @@ func trade(p *Player, npc *npc.Npc) {
 					} else {
 						p.money -= value
 						npc.AddMoney(value)
+						item[0].ChangeOwner(p.GetID())
 						p.AddItem(item[0])
-						message.Enqueue(fmt.Sprintf("You bought a %s.", item[0].GetName()))
+						message.Enqueue(fmt.Sprintf("You bought a %s for $%.2f.", item[0].GetName(), float64(value)/100))
 						npc.RemoveItem(item[0])
+						npc.ChangeSupply(item[0].GetName(), -1)
+						npc.RecordTrade()
 					}
 				}
 			}
@@ -57,24 +65,32 @@ func trade(p *Player, npc *npc.Npc) {
 				}
 
 				if item != nil {
-					value := item.GetValue()
-					if p.hasSkill(worldmap.Haggling) {
-						value += value / 5
+					validSelection = true
+					value, deal := p.negotiate(npc, item, true)
+					if !deal {
+						p.AddItem(item)
+						break
 					}
 
-					validSelection = true
 					if !npc.CanAfford(value) {
-						p.AddItem(item)
-						message.Enqueue(fmt.Sprintf("%s cannot afford that!", npc.GetName()))
+						if !barter(p, npc, item, value) {
+							p.AddItem(item)
+						}
 					} else {
 						p.money += value
 						npc.RemoveMoney(value)
-						message.Enqueue(fmt.Sprintf("You sold a %s.", item.GetName()))
+						message.Enqueue(fmt.Sprintf("You sold a %s for $%.2f.", item.GetName(), float64(value)/100))
+						item.ChangeOwner(npc.GetID())
 						npc.PickupItem(item)
+						npc.ChangeSupply(item.GetName(), 1)
+						npc.RecordTrade()
+						p.gainTradeXp(item)
 					}
 				}
 
 			}
+		} else if action == ui.Repair {
+			repair(p, npc)
 		} else if action == ui.Exit || action == ui.CancelAction {
 			tradeComplete = true
 			message.PrintMessage("\"Pleasure doing business with you.\"")
@@ -82,6 +98,126 @@ func trade(p *Player, npc *npc.Npc) {
 	}
 }
 
+// Haggles over the price of an item, returning the price agreed or false if no deal was struck.
+// The shopkeeper names a price and comes down, or up if the player is selling, towards the most they will accept.
+func (p *Player) negotiate(n *npc.Npc, itm *item.Item, selling bool) (int, bool) {
+	asking := n.Price(itm, selling)
+	limit := n.Limit(itm, selling, p.leeway())
+	// Nobody pays more than they would sell the item for
+	if selling && asking > limit {
+		asking = limit
+	}
+
+	reply := fmt.Sprintf("\"$%.2f for the %s.\"", float64(asking)/100, itm.GetName())
+	for patience := shopkeeperPatience; patience > 0; {
+		input := strings.TrimSpace(message.RequestInput(reply + " Your offer? (Enter to accept)"))
+		if input == "" {
+			return asking, true
+		}
+
+		offer, ok := item.ParseMoney(input)
+		if !ok {
+			reply = "\"I'm not sure what you mean.\""
+			continue
+		}
+
+		// Offering more than the shopkeeper wants when buying, or less when selling, settles it at their price
+		if (!selling && offer >= asking) || (selling && offer <= asking) {
+			return asking, true
+		}
+		if (!selling && offer >= limit) || (selling && offer <= limit) {
+			message.Enqueue("\"Deal.\"")
+			return offer, true
+		}
+
+		// Insulting offers try the shopkeeper's patience more
+		if (!selling && offer < limit*3/4) || (selling && offer > limit*5/4) {
+			patience -= 2
+			reply = "\"Are you trying to insult me?\""
+		} else {
+			patience--
+			reply = "\"I can't do that.\""
+		}
+		asking -= (asking - limit) / 2
+		reply += fmt.Sprintf(" \"$%.2f, and that's generous.\"", float64(asking)/100)
+	}
+
+	message.Enqueue("\"I've heard enough. No deal.\"")
+	return 0, false
+}
+
+// Gunsmiths restore a weapon the player is wielding or carrying to full condition for a fee
+func repair(p *Player, n *npc.Npc) {
+	if !n.RepairsWeapons() {
+		message.Enqueue("\"I don't do repairs.\"")
+		return
+	}
+
+	message.PrintMessage("Repair: ")
+	command, selection := ui.GetItemSelection()
+	if command == ui.Cancel {
+		return
+	}
+
+	itm := p.weaponByKey(selection)
+	if itm == nil {
+		message.Enqueue("\"That's not something I can fix.\"")
+		return
+	}
+	weapon := itm.Component("weapon").(item.WeaponComponent)
+	if !weapon.NeedsRepair() {
+		message.Enqueue(fmt.Sprintf("\"There's nothing wrong with your %s.\"", itm.GetName()))
+		return
+	}
+
+	cost := n.RepairCost(itm)
+	message.PrintMessage(fmt.Sprintf("\"$%.2f to fix up your %s.\" [yn]", float64(cost)/100, itm.GetName()))
+	if ui.GetInput() != ui.Confirm {
+		return
+	}
+	if cost > p.money {
+		message.Enqueue("You don't have enough money for that!")
+		return
+	}
+
+	p.money -= cost
+	n.AddMoney(cost)
+	weapon.Repair(item.MaxCondition, item.MaxCondition)
+	message.Enqueue(fmt.Sprintf("Your %s is repaired for $%.2f.", itm.GetName(), float64(cost)/100))
+}
+
+// When a shopkeeper can't afford what the player is selling, they can offer one of their own items instead
+func barter(p *Player, n *npc.Npc, itm *item.Item, value int) bool {
+	message.PrintMessage(fmt.Sprintf("\"I don't have the money, but I'll trade you something worth up to $%.2f.\" Trade for: ", float64(value)/100))
+	command, selection := ui.GetItemSelection()
+	if command == ui.Cancel {
+		return false
+	}
+
+	npcItems := n.GetItems(false)[selection]
+	if npcItems == nil {
+		return false
+	}
+	other := npcItems[0]
+	if n.Price(other, false) > value {
+		message.Enqueue(fmt.Sprintf("\"That's worth more than your %s.\"", itm.GetName()))
+		return false
+	}
+
+	n.RemoveItem(other)
+	n.ChangeSupply(other.GetName(), -1)
+	other.ChangeOwner(p.GetID())
+	p.AddItem(other)
+
+	itm.ChangeOwner(n.GetID())
+	n.PickupItem(itm)
+	n.ChangeSupply(itm.GetName(), 1)
+	n.RecordTrade()
+	p.gainTradeXp(itm)
+	message.Enqueue(fmt.Sprintf("You traded your %s for a %s.", itm.GetName(), other.GetName()))
+	return true
+}
+
 func printTradeScreen(p *Player, npc *npc.Npc) {
 	ui.ClearScreen()
 	padding := 2
@@ -91,23 +227,29 @@ func printTradeScreen(p *Player, npc *npc.Npc) {
 	ui.WriteText(npcX, 0, fmt.Sprintf("%s:", npc.GetName()))
 
 	i := 0
-	for c, items := range p.inventory {
-		value := items[0].GetValue()
-		if p.hasSkill(worldmap.Haggling) {
-			value += value / 5
-		}
-		ui.WriteText(0, padding+i, fmt.Sprintf("%s %dx %s $%.2f", string(c), len(items), items[0].GetName(), float64(value)/100))
+	for _, c := range sortedKeys(p.inventory) {
+		items := p.inventory[c]
+		ui.WriteText(0, padding+i, fmt.Sprintf("%s %dx %s $%.2f", string(c), len(items), inventoryName(items[0]), float64(npc.Price(items[0], true))/100))
 		i++
 	}
 
 	i = 0
-	for c, items := range npc.GetItems(false) {
-		value := items[0].GetValue()
-		if p.hasSkill(worldmap.Haggling) {
-			value -= value / 5
-		}
-		ui.WriteText(npcX, padding+i, fmt.Sprintf("%s %dx %s $%.2f", string(c), len(items), items[0].GetName(), float64(value)/100))
+	npcItems := npc.GetItems(false)
+	for _, c := range sortedKeys(npcItems) {
+		items := npcItems[c]
+		ui.WriteText(npcX, padding+i, fmt.Sprintf("%s %dx %s $%.2f", string(c), len(items), items[0].GetName(), float64(npc.Price(items[0], false))/100))
 		i++
 	}
 
 }
+
+// How far the player can talk a shopkeeper down from their price, or up when selling
+func (p *Player) leeway() float64 {
+	// Charming players get better prices and rude ones get worse
+	leeway := 0.02 * float64(worldmap.Bonus(p.attributes, "cha"))
+	// 20% at the first rank of haggling and 10% more for each rank after
+	if rank := p.skillRank(worldmap.Haggling); rank > 0 {
+		leeway += 0.1 * float64(rank+1)
+	}
+	return leeway
+}
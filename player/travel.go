@@ -0,0 +1,72 @@
+package player
+
+import (
+	"fmt"
+
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// Pace is the number of tiles the player covers each turn when travelling
+func (p *Player) Pace() int {
+	if p.mount != nil {
+		return 2
+	}
+	return 1
+}
+
+// Journey passes a turn on the road, eating and drinking from the player's supplies when needed.
+// Returns false if the player cannot carry on.
+func (p *Player) Journey() bool {
+	p.Update()
+
+	if worldmap.Level(p.attributes["hunger"]) > worldmap.Satisfied {
+		p.eatOnRoad()
+	}
+	if worldmap.Level(p.attributes["thirst"]) > worldmap.Satisfied {
+		p.drinkOnRoad()
+	}
+
+	levels := p.needLevels()
+	return !p.IsDead() && !p.Asleep() && levels["hunger"] != worldmap.Critical && levels["thirst"] != worldmap.Critical
+}
+
+func (p *Player) eatOnRoad() {
+	for k, items := range p.inventory {
+		if !items[0].HasComponent("consumable") {
+			continue
+		}
+		if len(items[0].Component("consumable").(item.ConsumableComponent).Effects["hunger"]) == 0 {
+			continue
+		}
+		itm := p.GetItem(k)
+		message.Enqueue(fmt.Sprintf("You eat a %s on the road.", itm.GetName()))
+		p.consume(itm)
+		return
+	}
+}
+
+func (p *Player) drinkOnRoad() {
+	for k, items := range p.inventory {
+		if items[0].HasComponent("canteen") {
+			canteen := items[0].Component("canteen").(*item.CanteenComponent)
+			if canteen.Drink() {
+				p.attributes["thirst"].AddEffect(item.NewInstantEffect(-canteen.Quench))
+				message.Enqueue(fmt.Sprintf("You drink from your %s.", items[0].GetName()))
+				return
+			}
+			continue
+		}
+		if !items[0].HasComponent("consumable") {
+			continue
+		}
+		if len(items[0].Component("consumable").(item.ConsumableComponent).Effects["thirst"]) == 0 {
+			continue
+		}
+		itm := p.GetItem(k)
+		message.Enqueue(fmt.Sprintf("You drink a %s on the road.", itm.GetName()))
+		p.consume(itm)
+		return
+	}
+}
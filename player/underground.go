@@ -0,0 +1,56 @@
+package player
+
+import (
+	"fmt"
+
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// TakeStairs goes down (1) or up (-1) the stairs the player is standing on
+func (p *Player) TakeStairs(direction int) bool {
+	stairs := p.world.Stairs(p.location.X, p.location.Y)
+	if stairs != direction {
+		if direction > 0 {
+			message.PrintMessage("There are no stairs down here.")
+		} else {
+			message.PrintMessage("There are no stairs up here.")
+		}
+		return false
+	}
+	if p.mount != nil {
+		message.PrintMessage("You can't take a horse down there.")
+		return false
+	}
+
+	p.world.ChangeDepth(direction)
+	if name, ok := p.world.UndergroundName(); ok && direction > 0 {
+		message.Enqueue(fmt.Sprintf("You climb down into the darkness of %s.", name))
+	} else if ok {
+		message.Enqueue("You climb back up.")
+	} else {
+		message.Enqueue("You climb back out into the open air.")
+	}
+	if p.depth > worldmap.Surface && p.LightRadius() == 0 {
+		message.Enqueue("It is pitch dark. You can barely see your hand in front of you.")
+	}
+	return true
+}
+
+// Digs out an ore vein next to the player, returning whether there was one
+func (p *Player) mine() bool {
+	x, y, _ := p.SelectDirection()
+	if p.location == (worldmap.Coordinates{x, y}) {
+		return false
+	}
+
+	ore, ok := p.world.MineVein(x, y)
+	if !ok {
+		message.PrintMessage("There is nothing worth digging there.")
+		return false
+	}
+	p.AddItem(item.NewItem(ore))
+	message.Enqueue(fmt.Sprintf("You dig out a %s.", ore))
+	return true
+}
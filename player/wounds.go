@@ -0,0 +1,70 @@
+package player
+
+import (
+	"fmt"
+
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/npc"
+	"github.com/onorton/cowboysindians/ui"
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+var woundMessages = map[string]string{
+	"bleeding":   "You are bleeding!",
+	"broken arm": "You hear a crack. Your arm is broken!",
+	"broken leg": "You hear a crack. Your leg is broken!",
+	"head wound": "You took a blow to the head! Your vision blurs.",
+}
+
+var woundHealedMessages = map[string]string{
+	"bleeding":   "Your bleeding has stopped, leaving a scar.",
+	"broken arm": "Your arm has healed, but not straight.",
+	"broken leg": "Your leg has healed, but you walk with a limp.",
+	"head wound": "Your vision clears.",
+}
+
+var woundTreatedMessages = map[string]string{
+	"bleeding":   "You stop the bleeding.",
+	"broken arm": "You set your arm.",
+	"broken leg": "You set your leg.",
+	"head wound": "You dress the wound on your head.",
+}
+
+// Cost of seeing a doctor for each wound and each hit point missing
+const doctorWoundFee = 500
+const doctorHpFee = 100
+
+func (p *Player) treat(treatment item.TreatmentComponent) {
+	treated := p.wounds.Treat(p.attributes, treatment.Wounds)
+	for _, wound := range treated {
+		message.Enqueue(woundTreatedMessages[wound])
+	}
+}
+
+// Pays a doctor to heal the player's wounds and hit points
+func visitDoctor(p *Player, doctor *npc.Npc) {
+	hp := p.attributes["hp"]
+	fee := (hp.Maximum()-hp.Value())*doctorHpFee + p.wounds.Count()*doctorWoundFee
+
+	if fee == 0 {
+		message.PrintMessage("\"You look fit as a fiddle to me.\"")
+		return
+	}
+
+	message.PrintMessage(fmt.Sprintf("\"That'll be $%.2f to patch you up.\" Pay the doctor? [yn]", float64(fee)/100))
+	if ui.GetInput() != ui.Confirm {
+		message.PrintMessage("\"Suit yourself.\"")
+		return
+	}
+	if fee > p.money {
+		message.PrintMessage("\"Come back when you can pay.\"")
+		return
+	}
+
+	p.money -= fee
+	doctor.AddMoney(fee)
+	p.wounds.Treat(p.attributes, worldmap.WoundKinds)
+	hp.AddEffect(item.NewInstantEffect(hp.Maximum() - hp.Value()))
+	message.PrintMessage(fmt.Sprintf("%s patches you up.", doctor.GetName().WithDefinite()))
+}
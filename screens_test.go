@@ -0,0 +1,340 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/onorton/cowboysindians/event"
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/npc"
+	"github.com/onorton/cowboysindians/player"
+	"github.com/onorton/cowboysindians/ui"
+	"github.com/onorton/cowboysindians/world"
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+var update = flag.Bool("update", false, "update golden files with the screens that are rendered")
+
+const screenSeed = 1555873582740657572
+const screenWidth, screenHeight = 100, 27
+
+// Turns from the start of the first day until ten at night
+const nightTime = 14 * 60
+
+// Separates frames in golden files
+const frameSeparator = "\n----------------------------------------------------------------------------------------------------\n"
+
+// A world generated from a fixed seed, made afresh for each screen test so that none depends on what another left behind
+type screenWorld struct {
+	p        *player.Player
+	worldMap *worldmap.Map
+	// Frames shown while creating the player
+	creation []string
+}
+
+func creationScript() []ui.Event {
+	events := ui.TextEvents("Jesse")
+	events = append(events, ui.KeyEvent(ui.KeyEnter))
+	// Move to str, raise it twice, then raise dex once
+	events = append(events, ui.KeyEvent(ui.KeyArrowDown), ui.KeyEvent(ui.KeyArrowRight), ui.KeyEvent(ui.KeyArrowRight))
+	events = append(events, ui.KeyEvent(ui.KeyArrowDown), ui.KeyEvent(ui.KeyArrowRight))
+	// Back up to the attributes heading and across to skills
+	events = append(events, ui.KeyEvent(ui.KeyArrowUp), ui.KeyEvent(ui.KeyArrowUp), ui.KeyEvent(ui.KeyArrowRight))
+	// Select Haggling
+	for i := 0; i < 9; i++ {
+		events = append(events, ui.KeyEvent(ui.KeyArrowDown))
+	}
+	events = append(events, ui.KeyEvent(ui.KeyEnter))
+	// Select Pickpocketing, the last skill
+	events = append(events, ui.KeyEvent(ui.KeyArrowDown), ui.KeyEvent(ui.KeyArrowDown), ui.KeyEvent(ui.KeyEnter))
+	// Past the last skill to complete creation
+	events = append(events, ui.KeyEvent(ui.KeyArrowDown), ui.KeyEvent(ui.KeyEnter))
+	return events
+}
+
+func generateScreenWorld(t *testing.T) *screenWorld {
+	item.LoadAllData()
+	if err := ui.LoadKeymap(); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), worldSaveFilename)
+	// A world a quarter of the size across is plenty for the screens and far quicker to generate
+	world.UseConfig(filepath.Join("testdata", "world.json"))
+
+	// Creatures from the worlds of earlier tests would otherwise still hear of crimes
+	event.Reset()
+	backend := useMemory(creationScript()...)
+	rand.Seed(screenSeed)
+	p, npcs := world.GenerateWorld(filename)
+
+	// Only the creatures placed by each test are on the map, so that nothing else gets in the way
+	worldMap := worldmap.NewMap(filename, worldmap.NewViewer(0, 0, layout.ViewerWidth, layout.ViewerHeight), nil, nil, nil, nil, p, []worldmap.Creature{p})
+	worldMap.LoadActiveChunks()
+	// Generated NPCs still witness crimes, so need to know about the map
+	for _, n := range npcs {
+		n.SetMap(worldMap)
+	}
+
+	return &screenWorld{p, worldMap, backend.Frames()}
+}
+
+// Plays the game on an in-memory screen with the given input
+func useMemory(events ...ui.Event) *ui.Memory {
+	backend := ui.NewMemory(screenWidth, screenHeight, events...)
+	ui.Init(backend, backend)
+	resize(screenWidth, screenHeight, nil)
+	message.Clear()
+	return backend
+}
+
+// Puts a new NPC on the map next to the player
+func placeNextToPlayer(t *testing.T, w *screenWorld, n *npc.Npc) {
+	pX, pY := w.p.GetCoordinates()
+	for i := -1; i <= 1; i++ {
+		for j := -1; j <= 1; j++ {
+			x, y := pX+i, pY+j
+			if w.worldMap.IsValid(x, y) && w.worldMap.IsPassable(x, y) && !w.worldMap.IsOccupied(x, y) {
+				n.SetCoordinates(x, y)
+				n.SetMap(w.worldMap)
+				w.worldMap.Move(n, x, y)
+				return
+			}
+		}
+	}
+	t.Fatal("No room next to the player")
+}
+
+// Finds a building of a type in one of the towns, since what a shop stocks depends on it
+func findBuilding(t *testing.T, w *screenWorld, buildingType worldmap.BuildingType) (worldmap.Town, worldmap.Building) {
+	for _, town := range w.worldMap.Towns() {
+		for _, b := range town.Buildings {
+			if b.T == buildingType {
+				return town, b
+			}
+		}
+	}
+	t.Fatalf("No %s in any town", buildingType)
+	return worldmap.Town{}, worldmap.Building{}
+}
+
+// Runs a screen until its input runs out and returns the frames shown
+func play(backend *ui.Memory, screen func()) []string {
+	func() {
+		defer func() {
+			if r := recover(); r != nil && r != ui.ErrEndOfInput {
+				panic(r)
+			}
+		}()
+		screen()
+	}()
+	// Capture the screen the player is left with
+	return append(backend.Frames(), backend.Snapshot())
+}
+
+func compareGolden(t *testing.T, name string, frames []string) {
+	golden := filepath.Join("testdata", "golden", name+".txt")
+	actual := strings.Join(frames, frameSeparator) + "\n"
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(golden, []byte(actual), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v. Run go test -run Screens -update to create it.", err)
+	}
+	if string(expected) == actual {
+		return
+	}
+
+	expectedFrames := strings.Split(strings.TrimSuffix(string(expected), "\n"), frameSeparator)
+	for i, frame := range frames {
+		if i >= len(expectedFrames) {
+			t.Fatalf("%s: frame %d was not expected:\n%s", golden, i, frame)
+		}
+		if frame != expectedFrames[i] {
+			t.Fatalf("%s: frame %d differs.\nExpected:\n%s\nActual:\n%s", golden, i, expectedFrames[i], frame)
+		}
+	}
+	t.Fatalf("%s: expected %d frames, got %d", golden, len(expectedFrames), len(frames))
+}
+
+func TestScreensCreation(t *testing.T) {
+	w := generateScreenWorld(t)
+	compareGolden(t, "creation", w.creation)
+}
+
+func TestScreensTrade(t *testing.T) {
+	w := generateScreenWorld(t)
+	rand.Seed(screenSeed)
+
+	town, gunShop := findBuilding(t, w, worldmap.GunShop)
+	shopkeeper := npc.NewNpc("shopkeeper", 0, 0, w.worldMap, &town, &gunShop, nil)
+	placeNextToPlayer(t, w, shopkeeper)
+
+	// Buy the shopkeeper's first item after a lowball offer, sell the player's first item at the asking price, then back out of selling
+	events := []ui.Event{ui.KeyEvent(ui.KeyEnter), ui.CharEvent('b'), ui.CharEvent(firstKey(shopkeeper.GetItems(false)))}
+	events = append(events, ui.TextEvents("1")...)
+	events = append(events, ui.KeyEvent(ui.KeyEnter), ui.KeyEvent(ui.KeyEnter))
+	events = append(events, ui.CharEvent('s'), ui.CharEvent(rune(w.p.GetInventoryKeys()[0])), ui.KeyEvent(ui.KeyEnter))
+	events = append(events, ui.CharEvent('s'), ui.KeyEvent(ui.KeyEnter), ui.KeyEvent(ui.KeyEsc))
+	backend := useMemory(events...)
+	compareGolden(t, "trade", play(backend, w.p.Talk))
+}
+
+func TestScreensPickpocket(t *testing.T) {
+	w := generateScreenWorld(t)
+	rand.Seed(screenSeed)
+	// Nobody notices a skilled pickpocket in the dark, so the theft goes unnoticed however the dice fall
+	w.worldMap.UpdateLighting(nightTime)
+	if pX, pY := w.p.GetCoordinates(); !w.worldMap.InShadow(pX, pY) {
+		t.Fatal("Expected the player to be in shadow")
+	}
+
+	town := w.worldMap.Towns()[0]
+	townsman := npc.NewNpc("townsman", 0, 0, w.worldMap, &town, &town.Buildings[0], nil)
+	placeNextToPlayer(t, w, townsman)
+
+	pX, pY := w.p.GetCoordinates()
+	nX, nY := townsman.GetCoordinates()
+	// Select the townsman, take their money and slip them the player's first item
+	money := item.Money(0).GetKey()
+	events := []ui.Event{ui.CharEvent(directionKey(nX-pX, nY-pY)), ui.CharEvent('p'), ui.CharEvent(money), ui.CharEvent('P'), ui.CharEvent(rune(w.p.GetInventoryKeys()[0])), ui.KeyEvent(ui.KeyEsc)}
+	backend := useMemory(events...)
+	compareGolden(t, "pickpocket", play(backend, func() { w.p.Pickpocket() }))
+}
+
+func TestScreensBounty(t *testing.T) {
+	w := generateScreenWorld(t)
+	rand.Seed(screenSeed)
+
+	town := w.worldMap.Towns()[0]
+	sheriff := npc.NewNpc("sheriff", 0, 0, w.worldMap, &town, &town.Buildings[0], nil)
+	placeNextToPlayer(t, w, sheriff)
+
+	// A bandit murders a townsman in the middle of town and is later killed by the player
+	x, y := (town.TownArea.X1()+town.TownArea.X2())/2, (town.TownArea.Y1()+town.TownArea.Y2())/2
+	bandit := npc.NewEnemy("bandit", x, y, nil)
+	victim := npc.NewNpc("townsman", x, y, nil, &town, &town.Buildings[0], nil)
+	event.Emit(event.WitnessedCrimeEvent{event.NewMurder(bandit, victim, worldmap.Coordinates{x, y})})
+	w.p.AddItem(item.NewCorpse("head", bandit.GetID(), bandit.GetName().String(), bandit.GetIcon()))
+
+	events := []ui.Event{ui.KeyEvent(ui.KeyEnter), ui.CharEvent('c'), ui.CharEvent('c'), ui.KeyEvent(ui.KeyEsc)}
+	backend := useMemory(events...)
+	compareGolden(t, "bounty", play(backend, w.p.Talk))
+}
+
+func TestScreensContainer(t *testing.T) {
+	w := generateScreenWorld(t)
+	rand.Seed(screenSeed)
+
+	town := w.worldMap.Towns()[0]
+	chest := item.NewNormalItem("chest")
+	chest.TransferOwner(town.Name)
+	cc := chest.Component("container").(*item.ContainerComponent)
+	cc.Put(item.NewNormalItem("gem"))
+	cc.Put(item.Money(2500))
+
+	pX, pY := w.p.GetCoordinates()
+	cX, cY := placeItemNextToPlayer(t, w, chest)
+
+	// Open the chest, take the money inside and put the player's first item in it
+	money := item.Money(0).GetKey()
+	events := []ui.Event{ui.CharEvent(directionKey(cX-pX, cY-pY)), ui.CharEvent('p'), ui.CharEvent(money), ui.CharEvent('P'), ui.CharEvent(rune(w.p.GetInventoryKeys()[0])), ui.KeyEvent(ui.KeyEsc)}
+	backend := useMemory(events...)
+	compareGolden(t, "container", play(backend, func() { w.p.ToggleDoor(true) }))
+}
+
+func TestScreensBank(t *testing.T) {
+	w := generateScreenWorld(t)
+	rand.Seed(screenSeed)
+
+	town := w.worldMap.Towns()[0]
+	teller := npc.NewNpc("teller", 0, 0, w.worldMap, &town, &town.Buildings[0], nil)
+	placeNextToPlayer(t, w, teller)
+
+	// Deposit $20, withdraw $5 then try to withdraw more than is left
+	events := []ui.Event{ui.KeyEvent(ui.KeyEnter), ui.CharEvent('d')}
+	events = append(events, ui.TextEvents("20")...)
+	events = append(events, ui.KeyEvent(ui.KeyEnter), ui.CharEvent('w'))
+	events = append(events, ui.TextEvents("5")...)
+	events = append(events, ui.KeyEvent(ui.KeyEnter), ui.CharEvent('w'))
+	events = append(events, ui.TextEvents("100")...)
+	events = append(events, ui.KeyEvent(ui.KeyEnter), ui.KeyEvent(ui.KeyEsc))
+	backend := useMemory(events...)
+	compareGolden(t, "bank", play(backend, w.p.Talk))
+}
+
+func TestScreensGambling(t *testing.T) {
+	w := generateScreenWorld(t)
+	rand.Seed(screenSeed)
+
+	town := w.worldMap.Towns()[0]
+	gambler := npc.NewNpc("bar patron", 0, 0, w.worldMap, &town, &town.Buildings[0], nil)
+	placeNextToPlayer(t, w, gambler)
+
+	// Sit the gambler at a table
+	gX, gY := gambler.GetCoordinates()
+	w.worldMap.PlaceItem(gX, gY, item.NewNormalItem("chair"))
+	tX, tY := gX+1, gY
+	if !w.worldMap.IsPassable(tX, tY) {
+		tX = gX - 1
+	}
+	w.worldMap.PlaceItem(tX, tY, item.NewNormalItem("table"))
+
+	// Play a hand of poker, a hand of blackjack standing straight away and a game of faro betting on kings
+	events := []ui.Event{ui.KeyEvent(ui.KeyEnter), ui.CharEvent('p')}
+	events = append(events, ui.TextEvents("5")...)
+	events = append(events, ui.KeyEvent(ui.KeyEnter), ui.CharEvent('b'))
+	events = append(events, ui.TextEvents("5")...)
+	events = append(events, ui.KeyEvent(ui.KeyEnter), ui.CharEvent('s'), ui.CharEvent('f'))
+	events = append(events, ui.TextEvents("K")...)
+	events = append(events, ui.KeyEvent(ui.KeyEnter))
+	events = append(events, ui.TextEvents("5")...)
+	events = append(events, ui.KeyEvent(ui.KeyEnter), ui.CharEvent('c'), ui.KeyEvent(ui.KeyEsc))
+	backend := useMemory(events...)
+	compareGolden(t, "gambling", play(backend, w.p.Talk))
+}
+
+// Puts an item on an empty tile next to the player
+func placeItemNextToPlayer(t *testing.T, w *screenWorld, itm *item.Item) (int, int) {
+	pX, pY := w.p.GetCoordinates()
+	for i := -1; i <= 1; i++ {
+		for j := -1; j <= 1; j++ {
+			x, y := pX+i, pY+j
+			if w.worldMap.IsValid(x, y) && w.worldMap.IsPassable(x, y) && !w.worldMap.IsOccupied(x, y) && !w.worldMap.IsDoor(x, y) && !w.worldMap.HasItems(x, y) {
+				w.worldMap.PlaceItem(x, y, itm)
+				return x, y
+			}
+		}
+	}
+	t.Fatal("No room next to the player")
+	return 0, 0
+}
+
+func firstKey(items map[rune][]*item.Item) rune {
+	first := rune(0)
+	for k := range items {
+		if first == 0 || k < first {
+			first = k
+		}
+	}
+	return first
+}
+
+// Numpad key for moving in a direction
+func directionKey(dx, dy int) rune {
+	return []rune("789456123")[(dy+1)*3+dx+1]
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/npc"
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// The best a player could ever haggle
+const bestLeeway = 1.0
+
+func TestBuyingAndSellingBackLosesMoney(t *testing.T) {
+	item.LoadAllData()
+	town := worldmap.NewTown("Testville", 0, 0, 40, 40, 0, 18, 40, 22, true, false)
+	town.Markup = 0.5
+	building := worldmap.NewBuilding(0, 0, 10, 10, worldmap.GunShop)
+	shopkeeper := npc.NewNpc("shopkeeper", 5, 5, nil, town, &building, nil)
+	if len(shopkeeper.GetItems(false)) == 0 {
+		t.Fatal("Expected the gun shop to have stock")
+	}
+
+	for _, items := range shopkeeper.GetItems(false) {
+		itm := items[0]
+		for _, leeway := range []float64{0, bestLeeway} {
+			paid := shopkeeper.Limit(itm, false, leeway)
+			shopkeeper.ChangeSupply(itm.GetName(), -1)
+			shopkeeper.RecordTrade()
+
+			if shopkeeper.Price(itm, true) >= shopkeeper.Price(itm, false) {
+				t.Errorf("Expected a shop to pay less for a %s than it sells one for", itm.GetName())
+			}
+			if got := shopkeeper.Limit(itm, true, leeway); got > paid {
+				t.Errorf("Expected to get back no more than the $%.2f paid for a %s but could get $%.2f", float64(paid)/100, itm.GetName(), float64(got)/100)
+			}
+			shopkeeper.ChangeSupply(itm.GetName(), 1)
+		}
+	}
+}
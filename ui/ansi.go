@@ -0,0 +1,291 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrDisconnected is panicked with when the player disconnects from an ANSI backend.
+var ErrDisconnected = errors.New("player disconnected")
+
+// Size assumed until the client reports its window size
+const defaultANSIWidth, defaultANSIHeight = 100, 27
+
+// Telnet commands and options
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWill = 251
+	telnetWont = 252
+	telnetDo   = 253
+	telnetDont = 254
+	telnetIAC  = 255
+
+	telnetEcho            = 1
+	telnetSuppressGoAhead = 3
+	telnetNegotiateWindow = 31
+)
+
+const (
+	escape                 = 0x1B
+	ansiClearScreen        = "\x1b[2J"
+	ansiHideCursor         = "\x1b[?25l"
+	ansiShowCursorAndReset = "\x1b[0m\x1b[?25h"
+)
+
+// ANSI is a backend that plays the game over a network connection, such as a telnet session,
+// by drawing with ANSI escape codes.
+type ANSI struct {
+	conn          net.Conn
+	width, height int
+	// Cells on the client's screen and cells that will be drawn on the next flush
+	front, back [][]memoryCell
+	events      chan Event
+}
+
+// ListenANSI waits for a single player to connect to addr
+func ListenANSI(addr string) (*ANSI, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &ANSI{conn: conn, events: make(chan Event, 16)}
+	a.allocate(defaultANSIWidth, defaultANSIHeight)
+
+	// Ask the client to send characters as they are typed without echoing them, and to report its window size
+	conn.Write([]byte{telnetIAC, telnetWill, telnetEcho, telnetIAC, telnetWill, telnetSuppressGoAhead, telnetIAC, telnetDo, telnetNegotiateWindow})
+	conn.Write([]byte(ansiHideCursor + ansiClearScreen))
+
+	go a.read()
+	return a, nil
+}
+
+func (a *ANSI) allocate(width, height int) {
+	a.width, a.height = width, height
+	a.front = make([][]memoryCell, height)
+	a.back = make([][]memoryCell, height)
+	for y := 0; y < height; y++ {
+		a.front[y] = make([]memoryCell, width)
+		a.back[y] = make([]memoryCell, width)
+		for x := 0; x < width; x++ {
+			// Nothing has been drawn on the client yet, so every cell differs from the front buffer
+			a.back[y][x] = memoryCell{' ', ColourDefault, ColourDefault}
+		}
+	}
+}
+
+func (a *ANSI) SetCell(x, y int, ch rune, fg, bg Colour) {
+	if x >= 0 && x < a.width && y >= 0 && y < a.height {
+		a.back[y][x] = memoryCell{ch, fg, bg}
+	}
+}
+
+func (a *ANSI) Clear() {
+	for y := range a.back {
+		for x := range a.back[y] {
+			a.back[y][x] = memoryCell{' ', ColourDefault, ColourDefault}
+		}
+	}
+}
+
+func sgr(fg, bg Colour) string {
+	fgCode, bgCode := "39", "49"
+	if fg != ColourDefault {
+		fgCode = fmt.Sprintf("38;5;%d", fg-1)
+	}
+	if bg != ColourDefault {
+		bgCode = fmt.Sprintf("48;5;%d", bg-1)
+	}
+	return fmt.Sprintf("\x1b[%s;%sm", fgCode, bgCode)
+}
+
+// Flush sends only the cells that have changed since the last flush
+func (a *ANSI) Flush() {
+	var buffer bytes.Buffer
+	var current *memoryCell
+	cursorX, cursorY := -1, -1
+
+	for y := range a.back {
+		for x, cell := range a.back[y] {
+			if a.front[y][x] == cell {
+				continue
+			}
+			if cursorX != x || cursorY != y {
+				buffer.WriteString(fmt.Sprintf("\x1b[%d;%dH", y+1, x+1))
+			}
+			if current == nil || current.fg != cell.fg || current.bg != cell.bg {
+				buffer.WriteString(sgr(cell.fg, cell.bg))
+			}
+			buffer.WriteRune(cell.ch)
+			a.front[y][x] = cell
+			current = &a.front[y][x]
+			cursorX, cursorY = x+1, y
+		}
+	}
+
+	if buffer.Len() > 0 {
+		a.conn.Write(buffer.Bytes())
+	}
+}
+
+func (a *ANSI) Size() (int, int) {
+	return a.width, a.height
+}
+
+func (a *ANSI) Close() {
+	a.conn.Write([]byte(ansiShowCursorAndReset + ansiClearScreen + "\x1b[H"))
+	a.conn.Close()
+}
+
+// PollEvent returns the next key press or resize from the client
+func (a *ANSI) PollEvent() Event {
+	e, ok := <-a.events
+	if !ok {
+		panic(ErrDisconnected)
+	}
+	if e.Type == EventResize {
+		a.allocate(e.Width, e.Height)
+		a.conn.Write([]byte(ansiClearScreen))
+	}
+	return e
+}
+
+// Reads input from the client until it disconnects, translating it into events
+func (a *ANSI) read() {
+	defer close(a.events)
+	reader := bufio.NewReader(a.conn)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+
+		switch {
+		case b == telnetIAC:
+			if e, ok := readTelnetCommand(reader); ok {
+				a.events <- e
+			}
+		case b == escape:
+			// A lone escape is the escape key, otherwise it starts an escape sequence
+			if reader.Buffered() == 0 {
+				a.events <- KeyEvent(KeyEsc)
+			} else if k, ok := readEscapeSequence(reader); ok {
+				a.events <- KeyEvent(k)
+			}
+		case b == '\r':
+			// Telnet sends carriage returns followed by a line feed or null
+			if next, err := reader.Peek(1); err == nil && (next[0] == '\n' || next[0] == 0) {
+				reader.ReadByte()
+			}
+			a.events <- KeyEvent(KeyEnter)
+		case b == '\n':
+			a.events <- KeyEvent(KeyEnter)
+		case b == ' ':
+			a.events <- KeyEvent(KeySpace)
+		case b == byte(KeyBackspace) || b == byte(KeyBackspace2):
+			a.events <- KeyEvent(KeyBackspace2)
+		case b < ' ':
+			a.events <- KeyEvent(Key(b))
+		default:
+			reader.UnreadByte()
+			r, _, err := reader.ReadRune()
+			if err != nil {
+				return
+			}
+			a.events <- CharEvent(r)
+		}
+	}
+}
+
+// Reads the rest of a telnet command. The only one of interest is the client reporting its window size.
+func readTelnetCommand(reader *bufio.Reader) (Event, bool) {
+	command, err := reader.ReadByte()
+	if err != nil {
+		return Event{}, false
+	}
+	switch command {
+	case telnetWill, telnetWont, telnetDo, telnetDont:
+		reader.ReadByte()
+	case telnetSB:
+		option, _ := reader.ReadByte()
+		data := make([]byte, 0)
+		for {
+			b, err := reader.ReadByte()
+			if err != nil {
+				return Event{}, false
+			}
+			if b == telnetIAC {
+				// Anything other than the end of the subnegotiation is an escaped IAC
+				if b, _ = reader.ReadByte(); b == telnetSE {
+					break
+				}
+			}
+			data = append(data, b)
+		}
+		if option == telnetNegotiateWindow && len(data) == 4 {
+			width := int(data[0])<<8 | int(data[1])
+			height := int(data[2])<<8 | int(data[3])
+			return ResizeEvent(width, height), true
+		}
+	}
+	return Event{}, false
+}
+
+// Reads a CSI or SS3 escape sequence for one of the special keys
+func readEscapeSequence(reader *bufio.Reader) (Key, bool) {
+	introducer, err := reader.ReadByte()
+	if err != nil || (introducer != '[' && introducer != 'O') {
+		return 0, false
+	}
+
+	parameter := 0
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case b >= '0' && b <= '9':
+			parameter = parameter*10 + int(b-'0')
+			continue
+		case b == 'A':
+			return KeyArrowUp, true
+		case b == 'B':
+			return KeyArrowDown, true
+		case b == 'C':
+			return KeyArrowRight, true
+		case b == 'D':
+			return KeyArrowLeft, true
+		case b == 'H':
+			return KeyHome, true
+		case b == 'F':
+			return KeyEnd, true
+		case b == '~':
+			switch parameter {
+			case 1, 7:
+				return KeyHome, true
+			case 2:
+				return KeyInsert, true
+			case 3:
+				return KeyDelete, true
+			case 4, 8:
+				return KeyEnd, true
+			case 5:
+				return KeyPgup, true
+			case 6:
+				return KeyPgdn, true
+			}
+		}
+		return 0, false
+	}
+}
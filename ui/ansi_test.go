@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+)
+
+func newTestANSI() (*ANSI, net.Conn) {
+	server, client := net.Pipe()
+	a := &ANSI{conn: server, events: make(chan Event, 16)}
+	a.allocate(defaultANSIWidth, defaultANSIHeight)
+	go a.read()
+	return a, client
+}
+
+func TestANSIReadsKeys(t *testing.T) {
+	a, client := newTestANSI()
+	defer client.Close()
+
+	testCases := []struct {
+		input string
+		event Event
+	}{
+		{"k", CharEvent('k')},
+		{"\x1b[A", KeyEvent(KeyArrowUp)},
+		{"\x1bOD", KeyEvent(KeyArrowLeft)},
+		{"\x1b[5~", KeyEvent(KeyPgup)},
+		{"\r\n", KeyEvent(KeyEnter)},
+		{"\r\x00", KeyEvent(KeyEnter)},
+		{" ", KeyEvent(KeySpace)},
+		{"\x7f", KeyEvent(KeyBackspace2)},
+		{"\x03", KeyEvent(KeyCtrlC)},
+		{"\x1b", KeyEvent(KeyEsc)},
+		{"é", CharEvent('é')},
+		{"\xff\xfb\x01\xff\xfa\x1f\x00\x84\x00\x28\xff\xf0", ResizeEvent(132, 40)},
+	}
+
+	for _, testCase := range testCases {
+		client.Write([]byte(testCase.input))
+		if e := <-a.events; e != testCase.event {
+			t.Errorf("Expected %q to be read as %+v but was %+v", testCase.input, testCase.event, e)
+		}
+	}
+}
+
+func TestANSIFlushSendsOnlyChanges(t *testing.T) {
+	server, client := net.Pipe()
+	a := &ANSI{conn: server, events: make(chan Event)}
+	a.allocate(3, 1)
+
+	output := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(client)
+		output <- string(data)
+	}()
+
+	a.Flush()
+	a.SetCell(1, 0, 'x', ColourRed, ColourDefault)
+	a.Flush()
+	a.Flush()
+	server.Close()
+
+	expected := "\x1b[1;1H\x1b[39;49m   " + "\x1b[1;2H\x1b[38;5;1;49mx"
+	if o := <-output; o != expected {
+		t.Errorf("Expected output %q but was %q", expected, strings.TrimSpace(o))
+	}
+}
@@ -0,0 +1,119 @@
+package ui
+
+// Colour of a character or its background. Colours are indices into the 256 colour palette
+// offset by one, with 0 meaning the terminal's default colour.
+type Colour uint16
+
+const (
+	ColourDefault Colour = iota
+	ColourBlack
+	ColourRed
+	ColourGreen
+	ColourYellow
+	ColourBlue
+	ColourMagenta
+	ColourCyan
+	ColourWhite
+)
+
+// Key is a special key such as an arrow key. Control keys have the value of their ASCII control code.
+type Key uint16
+
+const (
+	KeyCtrlA      Key = 0x01
+	KeyCtrlC      Key = 0x03
+	KeyBackspace  Key = 0x08
+	KeyTab        Key = 0x09
+	KeyEnter      Key = 0x0D
+	KeyCtrlZ      Key = 0x1A
+	KeyEsc        Key = 0x1B
+	KeySpace      Key = 0x20
+	KeyBackspace2 Key = 0x7F
+)
+
+const (
+	KeyF1 Key = 0xFFFF - iota
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeyInsert
+	KeyDelete
+	KeyHome
+	KeyEnd
+	KeyPgup
+	KeyPgdn
+	KeyArrowUp
+	KeyArrowDown
+	KeyArrowLeft
+	KeyArrowRight
+)
+
+type EventType int
+
+const (
+	EventKey EventType = iota
+	EventResize
+)
+
+// Event is either a key press or the screen changing size.
+// For key presses, Ch is set for characters and Key for special keys.
+type Event struct {
+	Type   EventType
+	Key    Key
+	Ch     rune
+	Width  int
+	Height int
+}
+
+func KeyEvent(k Key) Event {
+	return Event{EventKey, k, 0, 0, 0}
+}
+
+func CharEvent(ch rune) Event {
+	return Event{EventKey, 0, ch, 0, 0}
+}
+
+func ResizeEvent(width, height int) Event {
+	return Event{EventResize, 0, 0, width, height}
+}
+
+// TextEvents returns a key press for each character in s
+func TextEvents(s string) []Event {
+	events := make([]Event, 0, len(s))
+	for _, ch := range s {
+		if ch == ' ' {
+			events = append(events, KeyEvent(KeySpace))
+		} else {
+			events = append(events, CharEvent(ch))
+		}
+	}
+	return events
+}
+
+// Renderer draws characters to a screen. Cells set are not guaranteed to be visible until Flush is called.
+type Renderer interface {
+	SetCell(x, y int, ch rune, fg, bg Colour)
+	Clear()
+	Flush()
+	Size() (int, int)
+	Close()
+}
+
+// InputSource blocks until the player presses a key or the screen is resized.
+type InputSource interface {
+	PollEvent() Event
+}
+
+// Backend is somewhere the game can be displayed and played from.
+type Backend interface {
+	Renderer
+	InputSource
+}
@@ -0,0 +1,393 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Input contexts that have their own key bindings
+const (
+	GameContext     = "game"
+	BountyContext   = "bounty"
+	BankContext     = "bank"
+	GamblingContext = "gambling"
+	EquippedContext = "equipped"
+	CreationContext = "creation"
+)
+
+var keymapPath = "data/keymap.json"
+
+var actionNames = []string{
+	"MoveNorth",
+	"MoveSouth",
+	"MoveWest",
+	"MoveEast",
+	"MoveNorthWest",
+	"MoveNorthEast",
+	"MoveSouthWest",
+	"MoveSouthEast",
+	"PrintMessages",
+	"Exit",
+	"Wait",
+	"CloseDoor",
+	"OpenDoor",
+	"ToggleCrouch",
+	"RangedAttack",
+	"Throw",
+	"PickUpItem",
+	"DropItem",
+	"ToggleInventory",
+	"WieldItem",
+	"WieldArmour",
+	"LoadWeapon",
+	"Consume",
+	"Mount",
+	"Talk",
+	"Buy",
+	"Sell",
+	"Repair",
+	"Claim",
+	"Deposit",
+	"Withdraw",
+	"Poker",
+	"Faro",
+	"Blackjack",
+	"Hit",
+	"Stand",
+	"Cheat",
+	"Read",
+	"Use",
+	"Pickpocket",
+	"Place",
+	"Help",
+	"WorldMap",
+	"Sleep",
+	"Advance",
+	"Travel",
+	"Descend",
+	"Ascend",
+	"Primary",
+	"Secondary",
+	"Confirm",
+	"CancelAction",
+	"NoAction",
+}
+
+var actionDescriptions = map[PlayerAction]string{
+	MoveNorth:       "Move north",
+	MoveSouth:       "Move south",
+	MoveWest:        "Move west",
+	MoveEast:        "Move east",
+	MoveNorthWest:   "Move north west",
+	MoveNorthEast:   "Move north east",
+	MoveSouthWest:   "Move south west",
+	MoveSouthEast:   "Move south east",
+	PrintMessages:   "Print next message",
+	Exit:            "Exit",
+	Wait:            "Wait",
+	CloseDoor:       "Close door",
+	OpenDoor:        "Open door or container",
+	ToggleCrouch:    "Crouch/stand up",
+	RangedAttack:    "Ranged attack",
+	Throw:           "Throw item",
+	PickUpItem:      "Pick up items",
+	DropItem:        "Drop item",
+	ToggleInventory: "Toggle inventory",
+	WieldItem:       "Wield item",
+	WieldArmour:     "Wear armour",
+	LoadWeapon:      "Load weapon",
+	Consume:         "Eat or drink",
+	Mount:           "Mount/dismount",
+	Talk:            "Talk",
+	Buy:             "Buy",
+	Sell:            "Sell",
+	Repair:          "Repair weapon",
+	Claim:           "Claim bounty",
+	Deposit:         "Deposit money",
+	Withdraw:        "Withdraw money",
+	Poker:           "Play poker",
+	Faro:            "Play faro",
+	Blackjack:       "Play blackjack",
+	Hit:             "Hit",
+	Stand:           "Stand",
+	Cheat:           "Cheat/play fair",
+	Read:            "Read",
+	Use:             "Apply/use item",
+	Pickpocket:      "Pickpocket/take",
+	Place:           "Place item",
+	Help:            "Show this help",
+	WorldMap:        "World map",
+	Sleep:           "Sleep",
+	Advance:         "Level up",
+	Travel:          "Fast travel to town (world map)",
+	Descend:         "Go down stairs",
+	Ascend:          "Go up stairs",
+	Primary:         "Primary hand",
+	Secondary:       "Secondary hand",
+	Confirm:         "Confirm/select",
+	CancelAction:    "Cancel",
+}
+
+var keyNames = map[string]Key{
+	"Up":        KeyArrowUp,
+	"Down":      KeyArrowDown,
+	"Left":      KeyArrowLeft,
+	"Right":     KeyArrowRight,
+	"Enter":     KeyEnter,
+	"Esc":       KeyEsc,
+	"Space":     KeySpace,
+	"Tab":       KeyTab,
+	"Backspace": KeyBackspace2,
+	"Insert":    KeyInsert,
+	"Delete":    KeyDelete,
+	"Home":      KeyHome,
+	"End":       KeyEnd,
+	"PgUp":      KeyPgup,
+	"PgDn":      KeyPgdn,
+}
+
+func (a PlayerAction) String() string {
+	if int(a) < 0 || int(a) >= len(actionNames) {
+		return fmt.Sprintf("PlayerAction(%d)", int(a))
+	}
+	return actionNames[a]
+}
+
+func parseAction(name string) (PlayerAction, error) {
+	for i, n := range actionNames {
+		if n == name {
+			return PlayerAction(i), nil
+		}
+	}
+	return NoAction, fmt.Errorf("unknown action %q", name)
+}
+
+// A key is either a special key, such as an arrow key, or a character.
+type key struct {
+	special Key
+	ch      rune
+}
+
+func parseKey(name string) (key, error) {
+	if k, ok := keyNames[name]; ok {
+		return key{k, 0}, nil
+	}
+	if strings.HasPrefix(name, "Ctrl+") && len(name) == len("Ctrl+")+1 {
+		c := strings.ToUpper(name[len("Ctrl+"):])[0]
+		if c >= 'A' && c <= 'Z' {
+			return key{Key(c - 'A' + 1), 0}, nil
+		}
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		return key{0, r}, nil
+	}
+	return key{}, fmt.Errorf("unknown key %q", name)
+}
+
+func (k key) String() string {
+	if k.ch != 0 {
+		return string(k.ch)
+	}
+	for name, special := range keyNames {
+		if special == k.special {
+			return name
+		}
+	}
+	if k.special >= KeyCtrlA && k.special <= KeyCtrlZ {
+		return fmt.Sprintf("Ctrl+%c", 'A'+rune(k.special)-1)
+	}
+	return fmt.Sprintf("Key(%d)", k.special)
+}
+
+func eventKey(e Event) key {
+	if e.Ch != 0 {
+		return key{0, e.Ch}
+	}
+	// Terminals differ in which backspace code they send
+	if e.Key == KeyBackspace {
+		return key{KeyBackspace2, 0}
+	}
+	return key{e.Key, 0}
+}
+
+// bindings maps action names to the names of the keys bound to them, for each context.
+type bindings map[string]map[string][]string
+
+type keymapJson struct {
+	Preset   string
+	Presets  map[string]bindings
+	Bindings bindings
+}
+
+// Conflict describes a key that is bound to more than one action in the same context.
+type Conflict struct {
+	Context  string
+	Key      string
+	Existing PlayerAction
+	Action   PlayerAction
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("'%s' is bound to both %s and %s in %s", c.Key, c.Existing, c.Action, c.Context)
+}
+
+// ConflictError is returned when loading a keymap with conflicting bindings.
+type ConflictError []Conflict
+
+func (e ConflictError) Error() string {
+	conflicts := make([]string, len(e))
+	for i, c := range e {
+		conflicts[i] = c.String()
+	}
+	return "Keymap conflicts: " + strings.Join(conflicts, "; ")
+}
+
+type keymap struct {
+	keys    map[string]map[key]PlayerAction
+	actions map[string]map[PlayerAction][]key
+}
+
+func newKeymap() *keymap {
+	return &keymap{make(map[string]map[key]PlayerAction), make(map[string]map[PlayerAction][]key)}
+}
+
+// bind binds all keys in b, replacing any keys previously bound to the same actions.
+// Actions are bound in a fixed order so conflicts are resolved consistently.
+func (km *keymap) bind(b bindings) ([]Conflict, error) {
+	conflicts := make([]Conflict, 0)
+
+	contexts := make([]string, 0, len(b))
+	for context := range b {
+		contexts = append(contexts, context)
+	}
+	sort.Strings(contexts)
+
+	for _, context := range contexts {
+		if km.keys[context] == nil {
+			km.keys[context] = make(map[key]PlayerAction)
+			km.actions[context] = make(map[PlayerAction][]key)
+		}
+		keys := km.keys[context]
+		actions := km.actions[context]
+
+		parsed := make(map[PlayerAction][]string)
+		for name, keyNames := range b[context] {
+			action, err := parseAction(name)
+			if err != nil {
+				return nil, err
+			}
+			parsed[action] = keyNames
+			for _, k := range actions[action] {
+				delete(keys, k)
+			}
+			delete(actions, action)
+		}
+
+		order := make([]PlayerAction, 0, len(parsed))
+		for action := range parsed {
+			order = append(order, action)
+		}
+		sort.Slice(order, func(i, j int) bool { return order[i] < order[j] })
+
+		for _, action := range order {
+			for _, name := range parsed[action] {
+				k, err := parseKey(name)
+				if err != nil {
+					return nil, err
+				}
+				if existing, ok := keys[k]; ok && existing != action {
+					conflicts = append(conflicts, Conflict{context, k.String(), existing, action})
+					continue
+				}
+				keys[k] = action
+				actions[action] = append(actions[action], k)
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+func (km *keymap) action(context string, k key) PlayerAction {
+	if action, ok := km.keys[context][k]; ok {
+		return action
+	}
+	return NoAction
+}
+
+var activeKeymap = newKeymap()
+
+func parseKeymap(data []byte) (*keymap, []Conflict, error) {
+	var kmJson keymapJson
+	if err := json.Unmarshal(data, &kmJson); err != nil {
+		return nil, nil, err
+	}
+
+	preset, ok := kmJson.Presets[kmJson.Preset]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown keymap preset %q", kmJson.Preset)
+	}
+
+	km := newKeymap()
+	conflicts, err := km.bind(preset)
+	if err != nil {
+		return nil, nil, err
+	}
+	custom, err := km.bind(kmJson.Bindings)
+	if err != nil {
+		return nil, nil, err
+	}
+	return km, append(conflicts, custom...), nil
+}
+
+// LoadKeymap loads key bindings from the keymap file. The chosen preset is applied
+// first followed by any custom bindings. If any key is bound to more than one action,
+// the first binding is kept and a ConflictError is returned describing the rest.
+func LoadKeymap() error {
+	data, err := ioutil.ReadFile(keymapPath)
+	if err != nil {
+		return err
+	}
+	km, conflicts, err := parseKeymap(data)
+	if err != nil {
+		return err
+	}
+	activeKeymap = km
+	if len(conflicts) > 0 {
+		return ConflictError(conflicts)
+	}
+	return nil
+}
+
+// KeysFor returns the names of the keys bound to an action in a context.
+func KeysFor(context string, action PlayerAction) []string {
+	keys := activeKeymap.actions[context][action]
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.String()
+	}
+	return names
+}
+
+// HelpLines describes every bound action in a context, generated from the active keymap.
+func HelpLines(context string) []string {
+	actions := make([]PlayerAction, 0)
+	for action := range activeKeymap.actions[context] {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i] < actions[j] })
+
+	lines := make([]string, 0, len(actions))
+	for _, action := range actions {
+		description, ok := actionDescriptions[action]
+		if !ok {
+			description = action.String()
+		}
+		lines = append(lines, fmt.Sprintf("%-10s %s", strings.Join(KeysFor(context, action), " "), description))
+	}
+	return lines
+}
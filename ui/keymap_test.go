@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+func init() {
+	keymapPath = "../data/keymap.json"
+}
+
+func TestParseKey(t *testing.T) {
+	var tests = []struct {
+		name string
+		k    key
+	}{
+		{"Up", key{KeyArrowUp, 0}},
+		{"Enter", key{KeyEnter, 0}},
+		{"Ctrl+C", key{KeyCtrlC, 0}},
+		{"Ctrl+c", key{KeyCtrlC, 0}},
+		{"k", key{0, 'k'}},
+		{"?", key{0, '?'}},
+	}
+	for _, test := range tests {
+		k, err := parseKey(test.name)
+		if err != nil {
+			t.Errorf("Expected %s to be parsed but got error %s", test.name, err)
+		}
+		if k != test.k {
+			t.Errorf("Expected %s to be parsed as %v but was %v", test.name, test.k, k)
+		}
+	}
+
+	if _, err := parseKey("Hyper"); err == nil {
+		t.Error("Expected unknown key name to fail to parse but it was parsed")
+	}
+}
+
+func TestLoadKeymap(t *testing.T) {
+	if err := LoadKeymap(); err != nil {
+		t.Fatalf("Expected default keymap to load without conflicts but got %s", err)
+	}
+	if action := activeKeymap.action(GameContext, key{0, '8'}); action != MoveNorth {
+		t.Errorf("Expected '8' to be bound to MoveNorth but was %s", action)
+	}
+	if action := activeKeymap.action(BountyContext, key{0, 'c'}); action != Claim {
+		t.Errorf("Expected 'c' to be bound to Claim in bounty screen but was %s", action)
+	}
+}
+
+func TestPresetsHaveNoConflicts(t *testing.T) {
+	data, err := ioutil.ReadFile(keymapPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kmJson keymapJson
+	if err := json.Unmarshal(data, &kmJson); err != nil {
+		t.Fatal(err)
+	}
+	for name, preset := range kmJson.Presets {
+		if conflicts, err := newKeymap().bind(preset); err != nil || len(conflicts) > 0 {
+			t.Errorf("Expected preset %s to have no conflicts but had %v %v", name, conflicts, err)
+		}
+	}
+}
+
+func TestCustomBindingReplacesPreset(t *testing.T) {
+	data := []byte(`{"Preset": "p", "Presets": {"p": {"game": {"Talk": ["Ctrl+C"], "Wait": ["5"]}}},
+		"Bindings": {"game": {"Talk": ["T"]}}}`)
+	km, conflicts, err := parseKeymap(data)
+	if err != nil || len(conflicts) > 0 {
+		t.Fatalf("Expected keymap to be parsed without conflicts but had %v %v", conflicts, err)
+	}
+	if action := km.action(GameContext, key{0, 'T'}); action != Talk {
+		t.Errorf("Expected 'T' to be bound to Talk but was %s", action)
+	}
+	if action := km.action(GameContext, key{KeyCtrlC, 0}); action != NoAction {
+		t.Errorf("Expected Ctrl+C to be unbound but was %s", action)
+	}
+}
+
+func TestConflictDetection(t *testing.T) {
+	data := []byte(`{"Preset": "p", "Presets": {"p": {"game": {"Wait": ["5"], "Talk": ["t"]}}},
+		"Bindings": {"game": {"Read": ["5"]}}}`)
+	km, conflicts, err := parseKeymap(data)
+	if err != nil {
+		t.Fatalf("Expected keymap to be parsed but got %s", err)
+	}
+	if len(conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict but was %d", len(conflicts))
+	}
+	if c := conflicts[0]; c.Existing != Wait || c.Action != Read || c.Key != "5" {
+		t.Errorf("Expected conflict between Wait and Read on '5' but was %s", c)
+	}
+	if action := km.action(GameContext, key{0, '5'}); action != Wait {
+		t.Errorf("Expected '5' to remain bound to Wait but was %s", action)
+	}
+}
+
+func TestHelpLines(t *testing.T) {
+	data := []byte(`{"Preset": "p", "Presets": {"p": {"bounty": {"Exit": ["Esc", "Enter"], "Claim": ["c"]}}}}`)
+	km, _, _ := parseKeymap(data)
+	activeKeymap = km
+	lines := HelpLines(BountyContext)
+	expected := []string{"Esc Enter  Exit", "c          Claim bounty"}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines but was %d", len(expected), len(lines))
+	}
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("Expected line %q but was %q", expected[i], line)
+		}
+	}
+}
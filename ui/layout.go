@@ -0,0 +1,47 @@
+package ui
+
+// Number of rows below the viewer, for the message bar and status line
+const barRows = 2
+
+// Minimum width of the map viewer before a side panel is shown next to it
+const minViewerWidthWithPanel = 100
+
+const panelWidth = 32
+
+// Layout describes where each part of the game screen is drawn for a given terminal size.
+type Layout struct {
+	Width        int
+	Height       int
+	ViewerWidth  int
+	ViewerHeight int
+	MessageY     int
+	StatusY      int
+	PanelX       int
+	PanelWidth   int
+}
+
+// NewLayout fits the viewer, message bar and status line into a terminal of the given size.
+// If the terminal is wide enough, a side panel is placed to the right of the viewer.
+func NewLayout(width, height int) Layout {
+	l := Layout{Width: width, Height: height, ViewerWidth: width, ViewerHeight: height - barRows}
+	if width >= minViewerWidthWithPanel+panelWidth {
+		l.ViewerWidth = width - panelWidth
+		// Leave a column between the viewer and the panel
+		l.PanelX = l.ViewerWidth + 1
+		l.PanelWidth = panelWidth - 1
+	}
+	if l.ViewerWidth < 1 {
+		l.ViewerWidth = 1
+	}
+	if l.ViewerHeight < 1 {
+		l.ViewerHeight = 1
+	}
+	l.MessageY = l.ViewerHeight
+	l.StatusY = l.ViewerHeight + 1
+	return l
+}
+
+// HasPanel returns true if there is room for a side panel
+func (l Layout) HasPanel() bool {
+	return l.PanelWidth > 0
+}
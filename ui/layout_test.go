@@ -0,0 +1,32 @@
+package ui
+
+import "testing"
+
+func TestNewLayout(t *testing.T) {
+	testCases := []struct {
+		width, height int
+		expected      Layout
+	}{
+		{100, 27, Layout{100, 27, 100, 25, 25, 26, 0, 0}},
+		{80, 24, Layout{80, 24, 80, 22, 22, 23, 0, 0}},
+		{132, 40, Layout{132, 40, 100, 38, 38, 39, 101, 31}},
+		{200, 50, Layout{200, 50, 168, 48, 48, 49, 169, 31}},
+		{0, 0, Layout{0, 0, 1, 1, 1, 2, 0, 0}},
+	}
+
+	for _, testCase := range testCases {
+		layout := NewLayout(testCase.width, testCase.height)
+		if layout != testCase.expected {
+			t.Errorf("Expected layout for %dx%d to be %+v but was %+v", testCase.width, testCase.height, testCase.expected, layout)
+		}
+	}
+}
+
+func TestLayoutHasPanelOnlyWhenWide(t *testing.T) {
+	if NewLayout(131, 27).HasPanel() {
+		t.Error("Expected no panel for a terminal 131 wide")
+	}
+	if !NewLayout(132, 27).HasPanel() {
+		t.Error("Expected a panel for a terminal 132 wide")
+	}
+}
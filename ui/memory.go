@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrEndOfInput is panicked with when a Memory backend runs out of scripted input.
+var ErrEndOfInput = errors.New("no more scripted input")
+
+type memoryCell struct {
+	ch     rune
+	fg, bg Colour
+}
+
+// Memory is a backend that draws to an in-memory screen and reads input from a script.
+// Every time input is requested, the screen is captured as a frame, so tests can check what the player would have seen.
+type Memory struct {
+	width, height int
+	cells         [][]memoryCell
+	events        []Event
+	frames        []string
+}
+
+func NewMemory(width, height int, events ...Event) *Memory {
+	m := &Memory{width: width, height: height, events: events, frames: make([]string, 0)}
+	m.Clear()
+	return m
+}
+
+// Script adds events to the end of the scripted input
+func (m *Memory) Script(events ...Event) {
+	m.events = append(m.events, events...)
+}
+
+func (m *Memory) SetCell(x, y int, ch rune, fg, bg Colour) {
+	if x >= 0 && x < m.width && y >= 0 && y < m.height {
+		m.cells[y][x] = memoryCell{ch, fg, bg}
+	}
+}
+
+func (m *Memory) Clear() {
+	m.cells = make([][]memoryCell, m.height)
+	for y := range m.cells {
+		m.cells[y] = make([]memoryCell, m.width)
+		for x := range m.cells[y] {
+			m.cells[y][x] = memoryCell{' ', ColourDefault, ColourDefault}
+		}
+	}
+}
+
+func (m *Memory) Flush() {}
+
+func (m *Memory) Size() (int, int) {
+	return m.width, m.height
+}
+
+func (m *Memory) Close() {}
+
+// PollEvent captures the current screen as a frame and returns the next scripted event.
+// Resize events change the size of the screen.
+func (m *Memory) PollEvent() Event {
+	m.frames = append(m.frames, m.Snapshot())
+	if len(m.events) == 0 {
+		panic(ErrEndOfInput)
+	}
+	e := m.events[0]
+	m.events = m.events[1:]
+	if e.Type == EventResize {
+		m.width, m.height = e.Width, e.Height
+		m.Clear()
+	}
+	return e
+}
+
+// Snapshot returns the characters on screen, one line per row with trailing spaces removed.
+func (m *Memory) Snapshot() string {
+	lines := make([]string, m.height)
+	for y, row := range m.cells {
+		var line strings.Builder
+		for _, cell := range row {
+			line.WriteRune(cell.ch)
+		}
+		lines[y] = strings.TrimRight(line.String(), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Frames returns every frame captured so far
+func (m *Memory) Frames() []string {
+	return m.frames
+}
+
+// Remaining returns the number of scripted events that have not been read yet
+func (m *Memory) Remaining() int {
+	return len(m.events)
+}
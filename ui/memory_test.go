@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"testing"
+)
+
+func TestMemorySnapshot(t *testing.T) {
+	memory := NewMemory(10, 3)
+	Init(memory, memory)
+
+	WriteText(1, 0, "Howdy")
+	WriteHighlightedText(0, 2, "partner")
+	DrawElement(9, 1, NewElement('@', ColourWhite))
+
+	expected := " Howdy\n         @\npartner"
+	if memory.Snapshot() != expected {
+		t.Errorf("Expected snapshot to be %q but was %q", expected, memory.Snapshot())
+	}
+
+	ClearScreen()
+	if memory.Snapshot() != "\n\n" {
+		t.Errorf("Expected snapshot to be empty after clearing but was %q", memory.Snapshot())
+	}
+}
+
+func TestMemoryCapturesFrameWhenPolled(t *testing.T) {
+	memory := NewMemory(10, 1, CharEvent('y'), KeyEvent(KeyEnter))
+	Init(memory, memory)
+	activeKeymap, _, _ = parseKeymap([]byte(`{"Preset": "p", "Presets": {"p": {"game": {"Confirm": ["y"], "CancelAction": ["Enter"]}}}}`))
+
+	WriteText(0, 0, "Sure? [yn]")
+	if action := GetInput(); action != Confirm {
+		t.Errorf("Expected Confirm but was %s", action)
+	}
+	WriteText(0, 0, "Certain?  ")
+	if action := GetInput(); action != CancelAction {
+		t.Errorf("Expected CancelAction but was %s", action)
+	}
+
+	expected := []string{"Sure? [yn]", "Certain?"}
+	frames := memory.Frames()
+	if len(frames) != len(expected) {
+		t.Fatalf("Expected %d frames but was %d", len(expected), len(frames))
+	}
+	for i, frame := range frames {
+		if frame != expected[i] {
+			t.Errorf("Expected frame %d to be %q but was %q", i, expected[i], frame)
+		}
+	}
+}
+
+func TestMemoryResize(t *testing.T) {
+	memory := NewMemory(10, 1, ResizeEvent(20, 2), CharEvent('a'))
+	Init(memory, memory)
+	width, height := 0, 0
+	SetResizeHandler(func(w, h int) {
+		width, height = w, h
+	})
+	defer SetResizeHandler(nil)
+
+	if action := GetInput(); action != NoAction {
+		t.Errorf("Expected NoAction after resize but was %s", action)
+	}
+	if width != 20 || height != 2 {
+		t.Errorf("Expected resize handler to be called with 20x2 but was %dx%d", width, height)
+	}
+	if w, h := Size(); w != 20 || h != 2 {
+		t.Errorf("Expected size to be 20x2 but was %dx%d", w, h)
+	}
+}
+
+func TestMemoryPanicsAtEndOfInput(t *testing.T) {
+	memory := NewMemory(10, 1)
+	Init(memory, memory)
+	defer func() {
+		if r := recover(); r != ErrEndOfInput {
+			t.Errorf("Expected to panic with ErrEndOfInput but was %v", r)
+		}
+	}()
+	GetInput()
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	termbox "github.com/nsf/termbox-go"
+)
+
+// Termbox is a backend that plays the game in the terminal it is run from.
+type Termbox struct{}
+
+func NewTermbox() (*Termbox, error) {
+	if err := termbox.Init(); err != nil {
+		return nil, err
+	}
+	termbox.SetOutputMode(termbox.Output256)
+	return &Termbox{}, nil
+}
+
+func (t *Termbox) SetCell(x, y int, ch rune, fg, bg Colour) {
+	termbox.SetCell(x, y, ch, termbox.Attribute(fg), termbox.Attribute(bg))
+}
+
+func (t *Termbox) Clear() {
+	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+}
+
+func (t *Termbox) Flush() {
+	termbox.Flush()
+}
+
+func (t *Termbox) Size() (int, int) {
+	return termbox.Size()
+}
+
+func (t *Termbox) Close() {
+	termbox.Close()
+}
+
+// PollEvent ignores all termbox events other than key presses and resizes
+func (t *Termbox) PollEvent() Event {
+	for {
+		e := termbox.PollEvent()
+		switch e.Type {
+		case termbox.EventKey:
+			// Key constants share termbox's values
+			return Event{EventKey, Key(e.Key), e.Ch, 0, 0}
+		case termbox.EventResize:
+			return ResizeEvent(e.Width, e.Height)
+		}
+	}
+}
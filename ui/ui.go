@@ -1,9 +1,5 @@
 package ui
 
-import (
-	termbox "github.com/nsf/termbox-go"
-)
-
 // Inputs
 
 // PlayerAction is a type that represents player actions
@@ -30,6 +26,7 @@ const (
 	OpenDoor
 	ToggleCrouch
 	RangedAttack
+	Throw
 	PickUpItem
 	DropItem
 	ToggleInventory
@@ -41,11 +38,27 @@ const (
 	Talk
 	Buy
 	Sell
+	Repair
 	Claim
+	Deposit
+	Withdraw
+	Poker
+	Faro
+	Blackjack
+	Hit
+	Stand
+	Cheat
 	Read
 	Use
 	Pickpocket
 	Place
+	Help
+	WorldMap
+	Sleep
+	Advance
+	Travel
+	Descend
+	Ascend
 	Primary
 	Secondary
 	Confirm
@@ -85,138 +98,91 @@ const (
 	Select
 )
 
-// Init initialises the termbox instance
-func Init(width int) {
+var resizeHandler func(width, height int)
+
+var renderer Renderer
+var input InputSource
+
+// Init sets where the game is displayed and where input comes from
+func Init(r Renderer, in InputSource) {
+	renderer = r
+	input = in
+	width, _ := renderer.Size()
 	centre = width / 2
-	err := termbox.Init()
-	if err != nil {
-		panic(err)
-	}
-	termbox.SetOutputMode(termbox.Output256)
 }
 
-// Close closes the termbox instance
-func Close() {
-	termbox.Close()
+// Size returns the current width and height of the screen
+func Size() (int, int) {
+	return renderer.Size()
 }
 
-// GetInput waits for the user to enter a key.
-// Returns the action corresponding to the key entered.
-func GetInput() (action PlayerAction) {
-	e := termbox.PollEvent()
+// SetResizeHandler sets a function to be called whenever the screen is resized
+func SetResizeHandler(handler func(width, height int)) {
+	resizeHandler = handler
+}
 
-	switch e.Key {
-	case termbox.KeyArrowLeft:
-		action = MoveWest
-	case termbox.KeyArrowRight:
-		action = MoveEast
-	case termbox.KeyArrowUp:
-		action = MoveNorth
-	case termbox.KeyArrowDown:
-		action = MoveSouth
-	case termbox.KeySpace:
-		action = PrintMessages
-	case termbox.KeyCtrlC:
-		action = Talk
-	case termbox.KeyEsc:
-		action = Exit
-	case termbox.KeyEnter:
-		action = CancelAction
-	default:
-		{
-
-			switch e.Ch {
-			case '1':
-				action = MoveSouthWest
-			case '2':
-				action = MoveSouth
-			case '3':
-				action = MoveSouthEast
-			case '4':
-				action = MoveWest
-			case '5':
-				action = Wait
-			case '6':
-				action = MoveEast
-			case '7':
-				action = MoveNorthWest
-			case '8':
-				action = MoveNorth
-			case '9':
-				action = MoveNorthEast
-			case 'c':
-				action = CloseDoor
-			case 'o':
-				action = OpenDoor
-			case 'C':
-				action = ToggleCrouch
-			case 't':
-				action = RangedAttack
-			case ',':
-				action = PickUpItem
-			case 'd':
-				action = DropItem
-			case 'i':
-				action = ToggleInventory
-			case 'w':
-				action = WieldItem
-			case 'W':
-				action = WieldArmour
-			case 'l':
-				action = LoadWeapon
-			case 'e':
-				action = Consume
-			case 'm':
-				action = Mount
-			case 'b':
-				action = Buy
-			case 's':
-				action = Sell
-			case 'r':
-				action = Read
-			case 'a':
-				action = Use
-			case 'p':
-				action = Pickpocket
-			case 'P':
-				action = Place
-			case 'y':
-				action = Confirm
-			case 'n':
-				action = CancelAction
-			default:
-				action = NoAction
-			}
+func resize(width, height int) {
+	centre = width / 2
+	if resizeHandler != nil {
+		resizeHandler(width, height)
+	}
+}
+
+// pollKeyEvent waits for a key to be pressed, handling any resizes in the meantime
+func pollKeyEvent() Event {
+	for {
+		if e, resized := pollEvent(); !resized {
+			return e
 		}
 	}
-	return action
 }
 
-func GetBountyInput() (action PlayerAction) {
-	e := termbox.PollEvent()
+// pollEvent waits for a key press or resize, returning true if the screen was resized
+func pollEvent() (Event, bool) {
+	e := input.PollEvent()
+	if e.Type == EventResize {
+		resize(e.Width, e.Height)
+		return e, true
+	}
+	return e, false
+}
 
-	switch e.Key {
-	case termbox.KeyEsc:
-		action = Exit
-	case termbox.KeyEnter:
-		action = Exit
-	default:
-		{
-			if e.Ch == 'c' {
-				action = Claim
-			} else {
-				action = NoAction
-			}
-		}
+// Close closes the renderer
+func Close() {
+	renderer.Close()
+}
+
+// GetInput waits for the user to enter a key.
+// Returns the action corresponding to the key entered, or NoAction if the terminal was resized
+// so that the screen can be redrawn.
+func GetInput() PlayerAction {
+	e, resized := pollEvent()
+	if resized {
+		return NoAction
 	}
-	return action
+	return activeKeymap.action(GameContext, eventKey(e))
+}
+
+func GetBountyInput() PlayerAction {
+	e := pollKeyEvent()
+	return activeKeymap.action(BountyContext, eventKey(e))
+}
+
+func GetBankInput() PlayerAction {
+	e := pollKeyEvent()
+	return activeKeymap.action(BankContext, eventKey(e))
+}
+
+func GetGamblingInput() PlayerAction {
+	e := pollKeyEvent()
+	return activeKeymap.action(GamblingContext, eventKey(e))
 }
 
 // GetItemSelection returns a rune corresponding to the item that is selected.
 func GetItemSelection() (ItemSelection, rune) {
-	e := termbox.PollEvent()
+	e := pollKeyEvent()
 
-	if e.Key == termbox.KeyEnter {
+	if e.Key == KeyEnter {
 		return Cancel, 0
 	} else if e.Ch == '*' {
 		return All, 0
@@ -228,26 +194,18 @@ func GetItemSelection() (ItemSelection, rune) {
 }
 
 func EquippedSelection() PlayerAction {
-	e := termbox.PollEvent()
-
-	if e.Key == termbox.KeyEnter {
-		return CancelAction
-	} else if e.Ch == 'p' {
-		return Primary
-	} else if e.Ch == 's' {
-		return Secondary
-	}
-	return NoAction
+	e := pollKeyEvent()
+	return activeKeymap.action(EquippedContext, eventKey(e))
 }
 
 func TextInput() (TextInputAction, rune) {
-	e := termbox.PollEvent()
+	e := pollKeyEvent()
 	switch e.Key {
-	case termbox.KeyEnter:
+	case KeyEnter:
 		return Done, 0
-	case termbox.KeySpace:
+	case KeySpace:
 		return Character, ' '
-	case termbox.KeyBackspace | termbox.KeyBackspace2:
+	case KeyBackspace, KeyBackspace2:
 		return Erase, 0
 	default:
 		if e.Ch != 0 {
@@ -258,17 +216,17 @@ func TextInput() (TextInputAction, rune) {
 }
 
 func CreationInput() CreationAction {
-	e := termbox.PollEvent()
-	switch e.Key {
-	case termbox.KeyArrowUp:
+	e := pollKeyEvent()
+	switch activeKeymap.action(CreationContext, eventKey(e)) {
+	case MoveNorth:
 		return Up
-	case termbox.KeyArrowDown:
+	case MoveSouth:
 		return Down
-	case termbox.KeyArrowLeft:
+	case MoveWest:
 		return Left
-	case termbox.KeyArrowRight:
+	case MoveEast:
 		return Right
-	case termbox.KeyEnter:
+	case Confirm:
 		return Select
 	default:
 		return Invalid
@@ -283,76 +241,76 @@ type Cell struct {
 
 type Element struct {
 	char   rune
-	colour termbox.Attribute
-	bg     termbox.Attribute
+	colour Colour
+	bg     Colour
 }
 
 func NewCell(x int, y int) Cell {
 	return Cell{x, y}
 }
 
-func NewElement(char rune, colour termbox.Attribute) Element {
-	return Element{char, colour, termbox.ColorDefault}
+func NewElement(char rune, colour Colour) Element {
+	return Element{char, colour, ColourDefault}
 }
 
-func NewElementWithBg(char rune, colour, bg termbox.Attribute) Element {
+func NewElementWithBg(char rune, colour, bg Colour) Element {
 	return Element{char, colour, bg}
 }
 
 func EmptyElement() Element {
-	return Element{' ', termbox.ColorDefault, termbox.ColorDefault}
+	return Element{' ', ColourDefault, ColourDefault}
 }
 
 func ClearCells(cells []Cell) {
 	for _, cell := range cells {
-		termbox.SetCell(cell.x, cell.y, ' ', termbox.ColorDefault, termbox.ColorDefault)
+		renderer.SetCell(cell.x, cell.y, ' ', ColourDefault, ColourDefault)
 	}
-	termbox.Flush()
+	renderer.Flush()
 }
 
 func ClearScreen() {
-	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+	renderer.Clear()
 }
 
 func DrawElement(x, y int, elem Element) {
-	termbox.SetCell(x, y, elem.char, elem.colour, elem.bg)
-	termbox.Flush()
+	renderer.SetCell(x, y, elem.char, elem.colour, elem.bg)
+	renderer.Flush()
 }
 
 func WriteText(x, y int, msg string) {
 	for _, c := range msg {
-		termbox.SetCell(x, y, c, termbox.ColorWhite, termbox.ColorDefault)
+		renderer.SetCell(x, y, c, ColourWhite, ColourDefault)
 		x++
 	}
-	termbox.Flush()
+	renderer.Flush()
 }
 
 func WriteHighlightedText(x, y int, msg string) {
 	for _, c := range msg {
-		termbox.SetCell(x, y, c, termbox.ColorBlack, termbox.ColorWhite)
+		renderer.SetCell(x, y, c, ColourBlack, ColourWhite)
 		x++
 	}
-	termbox.Flush()
+	renderer.Flush()
 }
 
 func WriteTextCentred(y int, msg string) {
 	x := centre - len(msg)/2
 	for _, c := range msg {
-		termbox.SetCell(x, y, c, termbox.ColorWhite, termbox.ColorDefault)
+		renderer.SetCell(x, y, c, ColourWhite, ColourDefault)
 		x++
 	}
-	termbox.Flush()
+	renderer.Flush()
 }
 
 func RenderGrid(x, y int, elems [][]Element) {
 	currY := y
 	for _, row := range elems {
 		for i, elem := range row {
-			termbox.SetCell(x+i, currY, elem.char, elem.colour, elem.bg)
+			renderer.SetCell(x+i, currY, elem.char, elem.colour, elem.bg)
 		}
 		currY++
 	}
-	termbox.Flush()
+	renderer.Flush()
 
 }
 
@@ -0,0 +1,142 @@
+package world
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+type biome int
+
+const (
+	desert biome = iota
+	plains
+	forest
+	mountains
+	canyon
+	lake
+	river
+)
+
+// Elevation above which the land rises into mountains, and above which those become sheer cliffs
+const mountainElevation = 0.64
+const peakElevation = 0.7
+
+// Elevation below which wet land floods into lakes
+const lakeElevation = 0.34
+
+// Moisture above which desert gives way to plains, and plains to forest
+const plainsMoisture = 0.47
+const forestMoisture = 0.58
+
+// How close to a ridge line a canyon's floor and walls are
+const canyonFloor = 0.008
+const canyonWalls = 0.02
+
+// Towns are built where at least this much of the land suits them, unless nowhere does after enough attempts
+const townCoverage = 0.9
+const maxTownAttempts = 1000
+
+// How much of the world the canyon network covers
+const canyonCoverage = 0.6
+
+type scattered struct {
+	tile   string
+	chance float64
+}
+
+// Chance of each tile having something growing or lying on it
+var scatter = map[biome][]scattered{
+	desert: {{"cactus", 0.02}, {"rock", 0.01}},
+	plains: {{"trees", 0.01}},
+	forest: {{"trees", 0.35}},
+}
+
+var baseTiles = map[biome]string{
+	desert:    "sand",
+	plains:    "grass",
+	forest:    "grass",
+	mountains: "rock",
+	canyon:    "sand",
+	lake:      "water",
+}
+
+// Biome of every location in the world, which is only needed while generating it
+type biomeMap [][]biome
+
+// Covers the world in desert, plains, forests, mountains, canyons and lakes according to its elevation and moisture
+func generateBiomes(world worldmap.World) biomeMap {
+	width, height := world.Width(), world.Height()
+	elevation := newNoise(width, height, 128, 4)
+	moisture := newNoise(width, height, 192, 4)
+	ridges := newNoise(width, height, 96, 3)
+	canyons := newNoise(width, height, 256, 2)
+
+	biomes := make(biomeMap, height)
+	for y := range biomes {
+		biomes[y] = make([]biome, width)
+		for x := range biomes[y] {
+			e, m := elevation.at(x, y), moisture.at(x, y)
+			ridge := math.Abs(ridges.at(x, y) - 0.5)
+
+			b := desert
+			switch {
+			case e > mountainElevation:
+				b = mountains
+			case e < lakeElevation && m > plainsMoisture:
+				b = lake
+			case ridge < canyonWalls && m < plainsMoisture && canyons.at(x, y) < canyonCoverage:
+				b = canyon
+			case m > forestMoisture:
+				b = forest
+			case m > plainsMoisture:
+				b = plains
+			}
+			biomes[y][x] = b
+
+			tile := baseTiles[b]
+			switch {
+			case b == mountains && e > peakElevation:
+				tile = "cliff"
+			case b == canyon && ridge >= canyonFloor:
+				tile = "cliff"
+			default:
+				for _, s := range scatter[b] {
+					if rand.Float64() < s.chance {
+						tile = s.tile
+						break
+					}
+				}
+			}
+			world.NewTile(tile, x, y)
+		}
+	}
+	return biomes
+}
+
+// Fraction of an area made up of the given biomes
+func (biomes biomeMap) coverage(x1, y1, x2, y2 int, suitable []biome) float64 {
+	total, covered := 0, 0
+	for y := y1; y <= y2; y++ {
+		for x := x1; x <= x2; x++ {
+			total++
+			for _, b := range suitable {
+				if biomes[y][x] == b {
+					covered++
+					break
+				}
+			}
+		}
+	}
+	return float64(covered) / float64(total)
+}
+
+// Clears an area back to bare ground so that it can be built on
+func clearArea(world worldmap.World, x1, y1, x2, y2 int) {
+	for y := y1; y <= y2; y++ {
+		for x := x1; x <= x2; x++ {
+			world.NewTile("ground", x, y)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package world
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Smoothly varying random values across the world, made from several octaves of value noise
+type noise struct {
+	lattices [][][]float64
+	// Tiles between points on the coarsest lattice
+	scale float64
+}
+
+func newNoise(width, height int, scale float64, octaves int) noise {
+	lattices := make([][][]float64, octaves)
+	for o := range lattices {
+		spacing := scale / math.Pow(2, float64(o))
+		lattice := make([][]float64, int(float64(height)/spacing)+2)
+		for y := range lattice {
+			lattice[y] = make([]float64, int(float64(width)/spacing)+2)
+			for x := range lattice[y] {
+				lattice[y][x] = rand.Float64()
+			}
+		}
+		lattices[o] = lattice
+	}
+	return noise{lattices, scale}
+}
+
+// Value between 0 and 1 at x, y, with finer octaves contributing less
+func (n noise) at(x, y int) float64 {
+	total, amplitude, max := 0.0, 1.0, 0.0
+	for o, lattice := range n.lattices {
+		spacing := n.scale / math.Pow(2, float64(o))
+		fX, fY := float64(x)/spacing, float64(y)/spacing
+		lX, lY := int(fX), int(fY)
+		tX, tY := smoothstep(fX-float64(lX)), smoothstep(fY-float64(lY))
+
+		top := lerp(lattice[lY][lX], lattice[lY][lX+1], tX)
+		bottom := lerp(lattice[lY+1][lX], lattice[lY+1][lX+1], tX)
+		total += amplitude * lerp(top, bottom, tY)
+		max += amplitude
+		amplitude /= 2
+	}
+	return total / max
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
@@ -0,0 +1,161 @@
+package world
+
+import (
+	"math"
+
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// How far outside a town the track runs past it
+const trackOffset = 2
+
+// Lays a single line of track from town to town, nearest first, with a station in each town it passes
+func generateRailroad(world worldmap.World, biomes biomeMap, towns []worldmap.Town, buildings *[]worldmap.Building) worldmap.Railroad {
+	railroad := worldmap.Railroad{[]worldmap.Coordinates{}, []worldmap.Station{}}
+
+	candidates := make([]int, 0)
+	for i, t := range towns {
+		if !t.Farm {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) < 2 || worldConf.Stations < 2 {
+		return railroad
+	}
+
+	route := make([]worldmap.Coordinates, 0)
+	stops := make([]int, 0)
+	current := candidates[0]
+	candidates = candidates[1:]
+	for {
+		start, end := passingTrack(world, towns[current], route)
+		track := []worldmap.Coordinates{start}
+		if len(route) > 0 {
+			track = trackBetween(route[len(route)-1], start)
+		}
+		track = append(track, trackBetween(start, end)...)
+		if !clearForTrack(world, towns, *buildings, track) {
+			break
+		}
+		route = append(route, track...)
+		stops = append(stops, current)
+
+		if len(stops) == worldConf.Stations || len(candidates) == 0 {
+			break
+		}
+
+		// Head for the nearest town not yet on the line
+		nearest := 0
+		for i, c := range candidates {
+			if townDistance(towns[current], towns[c]) < townDistance(towns[current], towns[candidates[nearest]]) {
+				nearest = i
+			}
+		}
+		current = candidates[nearest]
+		candidates = append(candidates[:nearest], candidates[nearest+1:]...)
+	}
+
+	if len(stops) < 2 {
+		return railroad
+	}
+
+	for _, c := range route {
+		// Track crosses water by bridge
+		if b := biomes[c.Y][c.X]; b == river || b == lake {
+			world.NewTile("bridge", c.X, c.Y)
+		} else {
+			world.NewTile("railroad", c.X, c.Y)
+		}
+	}
+	railroad.Track = route
+
+	for _, i := range stops {
+		t := &towns[i]
+		if !generateBuildingInTown(world, t, buildings, worldmap.TrainStation) {
+			continue
+		}
+		door := *t.Buildings[len(t.Buildings)-1].DoorLocation
+		railroad.Stations = append(railroad.Stations, worldmap.Station{t.Name, nearestOnTrack(route, door)})
+	}
+
+	if len(railroad.Stations) < 2 {
+		railroad.Stations = []worldmap.Station{}
+	}
+	return railroad
+}
+
+// The track that runs alongside a town, parallel to its street, entered from the end nearest the line so far
+func passingTrack(world worldmap.World, t worldmap.Town, route []worldmap.Coordinates) (worldmap.Coordinates, worldmap.Coordinates) {
+	var start, end worldmap.Coordinates
+	if t.Horizontal {
+		y := t.TownArea.Y2() + trackOffset
+		if !world.IsValid(0, y) {
+			y = t.TownArea.Y1() - trackOffset
+		}
+		start, end = worldmap.Coordinates{t.TownArea.X1() - trackOffset, y}, worldmap.Coordinates{t.TownArea.X2() + trackOffset, y}
+	} else {
+		x := t.TownArea.X2() + trackOffset
+		if !world.IsValid(x, 0) {
+			x = t.TownArea.X1() - trackOffset
+		}
+		start, end = worldmap.Coordinates{x, t.TownArea.Y1() - trackOffset}, worldmap.Coordinates{x, t.TownArea.Y2() + trackOffset}
+	}
+	start, end = clamp(world, start), clamp(world, end)
+
+	if len(route) > 0 {
+		last := route[len(route)-1]
+		if worldmap.Distance(last.X, last.Y, end.X, end.Y) < worldmap.Distance(last.X, last.Y, start.X, start.Y) {
+			start, end = end, start
+		}
+	}
+	return start, end
+}
+
+func clamp(world worldmap.World, c worldmap.Coordinates) worldmap.Coordinates {
+	x := int(math.Max(0, math.Min(float64(world.Width()-1), float64(c.X))))
+	y := int(math.Max(0, math.Min(float64(world.Height()-1), float64(c.Y))))
+	return worldmap.Coordinates{x, y}
+}
+
+// Each tile of a straight stretch of track from one point to another, not including the first
+func trackBetween(from, to worldmap.Coordinates) []worldmap.Coordinates {
+	steps := int(math.Max(math.Abs(float64(to.X-from.X)), math.Abs(float64(to.Y-from.Y))))
+	track := make([]worldmap.Coordinates, 0, steps)
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := from.X + int(math.Round(t*float64(to.X-from.X)))
+		y := from.Y + int(math.Round(t*float64(to.Y-from.Y)))
+		track = append(track, worldmap.Coordinates{x, y})
+	}
+	return track
+}
+
+// Returns true if a stretch of track runs through no towns or buildings
+func clearForTrack(world worldmap.World, towns []worldmap.Town, buildings []worldmap.Building, track []worldmap.Coordinates) bool {
+	for _, c := range track {
+		if !world.IsValid(c.X, c.Y) || !outside(buildings, c.X, c.Y) {
+			return false
+		}
+		for _, t := range towns {
+			if c.X >= t.TownArea.X1() && c.X <= t.TownArea.X2() && c.Y >= t.TownArea.Y1() && c.Y <= t.TownArea.Y2() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func townDistance(t1, t2 worldmap.Town) float64 {
+	return worldmap.Distance((t1.TownArea.X1()+t1.TownArea.X2())/2, (t1.TownArea.Y1()+t1.TownArea.Y2())/2, (t2.TownArea.X1()+t2.TownArea.X2())/2, (t2.TownArea.Y1()+t2.TownArea.Y2())/2)
+}
+
+// Index of the tile of track closest to a location
+func nearestOnTrack(track []worldmap.Coordinates, c worldmap.Coordinates) int {
+	nearest := 0
+	for i, t := range track {
+		if worldmap.Distance(t.X, t.Y, c.X, c.Y) < worldmap.Distance(track[nearest].X, track[nearest].Y, c.X, c.Y) {
+			nearest = i
+		}
+	}
+	return nearest
+}
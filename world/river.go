@@ -0,0 +1,78 @@
+package world
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// How wide a river runs, and how far its course can wander sideways each step
+const minRiverWidth = 2
+const maxRiverWidth = 4
+const riverMeander = 0.15
+
+// Chance of a shallow stretch starting at any step, and how long it lasts
+const fordChance = 0.01
+const minFordLength = 3
+const maxFordLength = 8
+
+// How far from a river a town still counts as being on its bank, and for how many attempts towns hold out for one
+const riverbankDistance = 30
+const riverbankAttempts = maxTownAttempts / 2
+
+// Cuts a river from one edge of the world to the opposite one, wandering as it goes
+func generateRiver(world worldmap.World, biomes biomeMap) {
+	horizontal := rand.Intn(2) == 0
+	length, breadth := world.Height(), world.Width()
+	if horizontal {
+		length, breadth = world.Width(), world.Height()
+	}
+
+	// Keep the river's course away from the edges it runs alongside
+	course := float64(breadth/4 + rand.Intn(breadth/2))
+	drift := 0.0
+	width := minRiverWidth + rand.Intn(maxRiverWidth-minRiverWidth+1)
+	ford := 0
+
+	for along := 0; along < length; along++ {
+		drift = math.Max(-1, math.Min(1, drift+riverMeander*(2*rand.Float64()-1)))
+		course = math.Max(float64(maxRiverWidth), math.Min(float64(breadth-maxRiverWidth-1), course+drift))
+
+		if rand.Intn(20) == 0 {
+			width = int(math.Max(minRiverWidth, math.Min(maxRiverWidth, float64(width+rand.Intn(3)-1))))
+		}
+
+		if ford > 0 {
+			ford--
+		} else if rand.Float64() < fordChance {
+			ford = minFordLength + rand.Intn(maxFordLength-minFordLength+1)
+		}
+
+		tile := "water"
+		if ford > 0 {
+			tile = "ford"
+		}
+
+		for across := int(course) - width/2; across < int(course)-width/2+width; across++ {
+			x, y := across, along
+			if horizontal {
+				x, y = along, across
+			}
+			biomes[y][x] = river
+			world.NewTile(tile, x, y)
+		}
+	}
+}
+
+// Returns true if there is any of a biome within distance of an area
+func (biomes biomeMap) near(x1, y1, x2, y2, distance int, b biome) bool {
+	for y := int(math.Max(0, float64(y1-distance))); y <= int(math.Min(float64(len(biomes)-1), float64(y2+distance))); y++ {
+		for x := int(math.Max(0, float64(x1-distance))); x <= int(math.Min(float64(len(biomes[y])-1), float64(x2+distance))); x++ {
+			if biomes[y][x] == b {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package world
+
+import (
+	"math"
+
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// Follows each path between towns down the middle, leaving out any that stray off the edge of the world
+func generateRoads(world worldmap.World, towns []worldmap.Town, paths []path) []worldmap.Road {
+	roads := make([]worldmap.Road, 0)
+	for _, p := range paths {
+		if tiles, ok := roadAlong(world, p); ok {
+			roads = append(roads, worldmap.Road{towns[p.from].Name, towns[p.to].Name, tiles})
+		}
+	}
+	return roads
+}
+
+// Each tile down the middle of a path in turn, if all of it is in the world
+func roadAlong(world worldmap.World, p path) ([]worldmap.Coordinates, bool) {
+	curve := p.curves[p.width/2]
+	start, end := curve(0.0), curve(1.0)
+	minStep := 1.0 / (10 * math.Max(math.Abs(float64(end.X-start.X)), math.Abs(float64(end.Y-start.Y))))
+
+	road := make([]worldmap.Coordinates, 0)
+	for t := 0.0; t <= 1.0; t += minStep {
+		curr := curve(t)
+		if !world.IsValid(curr.X, curr.Y) {
+			return nil, false
+		}
+		if len(road) == 0 || road[len(road)-1] != curr {
+			road = append(road, curr)
+		}
+	}
+	return road, len(road) > 1
+}
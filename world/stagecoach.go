@@ -0,0 +1,24 @@
+package world
+
+import (
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// Picks out roads between towns for stagecoaches to run along, leaving out those to farms
+func generateStageLines(towns []worldmap.Town, roads []worldmap.Road) []int {
+	farms := make(map[string]bool)
+	for _, t := range towns {
+		farms[t.Name] = t.Farm
+	}
+
+	lines := make([]int, 0)
+	for i, r := range roads {
+		if len(lines) == worldConf.Stagecoaches {
+			break
+		}
+		if !farms[r.From] && !farms[r.To] {
+			lines = append(lines, i)
+		}
+	}
+	return lines
+}
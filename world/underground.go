@@ -0,0 +1,309 @@
+package world
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/npc"
+	"github.com/onorton/cowboysindians/worldmap"
+)
+
+// How many attempts are made to find a site in the mountains before settling for anywhere out of the way
+const maxUndergroundAttempts = 1000
+
+// How far from the edge of its chunk a mine or cave's entrance has to be, leaving room to dig around it
+const undergroundMargin = 8
+
+// How deep a mine can go, how many tunnels are dug on each level and how long each one runs
+const maxMineDepth = 2
+const mineTunnels = 5
+const tunnelLength = 80
+const tunnelTurnChance = 0.2
+
+// Chance of each wall along a mine's tunnels being a vein of ore, and of that ore being gold rather than silver
+const veinChance = 0.06
+const goldChance = 0.3
+
+// Chance of a tool left lying about on each level of a mine
+const abandonedToolChance = 0.5
+
+// Chance of each tile of a cave starting open, how many times it is smoothed out, and how big it has to end up
+const caveOpenness = 0.45
+const caveSmoothing = 4
+const minCaveSize = 300
+
+// Chance of a cave being used as a bandit hideout, how many bandits hide out in one, and how far they keep from the entrance
+const hideoutChance = 0.5
+const minHideoutBandits = 2
+const maxHideoutBandits = 4
+const hideoutDepth = 10
+
+// Digs out mines and caves beneath the world, returning them with their levels and any bandits hiding out in them
+func generateUnderground(world worldmap.World, biomes biomeMap, towns []worldmap.Town, buildings []worldmap.Building) ([]worldmap.Underground, []worldmap.World, []*npc.Npc) {
+	sites := make([]worldmap.Underground, 0)
+	levels := make([]worldmap.World, 0)
+	bandits := make([]*npc.Npc, 0)
+
+	for i := 0; i < worldConf.Mines+worldConf.Caves; i++ {
+		u := worldmap.Underground{}
+		u.Entrance = undergroundSite(world, biomes, towns, buildings, sites)
+		u.FirstLevel = len(levels)
+		world.NewTile("stairs down", u.Entrance.X, u.Entrance.Y)
+
+		origin := worldmap.LevelOrigin(u.Entrance)
+		start := worldmap.Coordinates{u.Entrance.X - origin.X, u.Entrance.Y - origin.Y}
+		if i < worldConf.Mines {
+			u.T = worldmap.Mine
+			u.Name = npc.Names.Mines[rand.Intn(len(npc.Names.Mines))] + " Mine"
+			u.Depth = 1 + rand.Intn(maxMineDepth)
+			for depth := 1; depth <= u.Depth; depth++ {
+				level := worldmap.NewLevel()
+				start = generateMineLevel(level, start, depth < u.Depth)
+				levels = append(levels, level)
+			}
+		} else {
+			u.T = worldmap.Cave
+			u.Name = npc.Names.Caves[rand.Intn(len(npc.Names.Caves))]
+			u.Depth = 1
+			level, floor := generateCaveLevel(start)
+			if rand.Float64() < hideoutChance {
+				u.Hideout = true
+				bandits = append(bandits, generateHideout(level, origin, start, floor)...)
+			}
+			levels = append(levels, level)
+		}
+		sites = append(sites, u)
+	}
+	return sites, levels, bandits
+}
+
+// Finds somewhere for the entrance of a mine or cave, preferably in the mountains and never in a town or under a chunk that already has one
+func undergroundSite(world worldmap.World, biomes biomeMap, towns []worldmap.Town, buildings []worldmap.Building, sites []worldmap.Underground) worldmap.Coordinates {
+	for attempts := 0; ; attempts++ {
+		x := rand.Intn(world.Width())
+		y := rand.Intn(world.Height())
+		location := worldmap.Coordinates{x, y}
+
+		origin := worldmap.LevelOrigin(location)
+		if x-origin.X < undergroundMargin || x-origin.X >= worldmap.LevelSize-undergroundMargin || y-origin.Y < undergroundMargin || y-origin.Y >= worldmap.LevelSize-undergroundMargin {
+			continue
+		}
+		if attempts < maxUndergroundAttempts && biomes[y][x] != mountains {
+			continue
+		}
+		// Only untouched ground, so roads, railroads and rivers are left alone
+		if baseTile, ok := baseTiles[biomes[y][x]]; !ok || biomes[y][x] == lake || !world.TileIs(baseTile, x, y) {
+			continue
+		}
+		if world.IsOccupied(x, y) || !outside(buildings, x, y) || inTownArea(towns, x, y) {
+			continue
+		}
+
+		taken := false
+		for _, site := range sites {
+			if worldmap.SameChunk(site.Entrance, location) {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return location
+		}
+	}
+}
+
+// Digs tunnels out from the stairs up, with veins of ore along their walls, returning where the stairs down are if it has them
+func generateMineLevel(level worldmap.World, start worldmap.Coordinates, deeper bool) worldmap.Coordinates {
+	dug := []worldmap.Coordinates{start}
+	open := map[worldmap.Coordinates]bool{start: true}
+	directions := []worldmap.Coordinates{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+
+	for i := 0; i < mineTunnels; i++ {
+		location := dug[rand.Intn(len(dug))]
+		direction := directions[rand.Intn(len(directions))]
+		for j := 0; j < tunnelLength; j++ {
+			if rand.Float64() < tunnelTurnChance {
+				direction = directions[rand.Intn(len(directions))]
+			}
+			next := worldmap.Coordinates{location.X + direction.X, location.Y + direction.Y}
+			// Leave solid rock around the edge of the level
+			if next.X < 1 || next.X >= worldmap.LevelSize-1 || next.Y < 1 || next.Y >= worldmap.LevelSize-1 {
+				direction = worldmap.Coordinates{-direction.X, -direction.Y}
+				continue
+			}
+			location = next
+			if !open[location] {
+				open[location] = true
+				dug = append(dug, location)
+			}
+		}
+	}
+
+	for _, location := range dug {
+		level.NewTile("ground", location.X, location.Y)
+	}
+
+	for _, location := range dug {
+		for _, direction := range directions {
+			x, y := location.X+direction.X, location.Y+direction.Y
+			if open[worldmap.Coordinates{x, y}] || rand.Float64() >= veinChance {
+				continue
+			}
+			if rand.Float64() < goldChance {
+				level.NewTile("gold vein", x, y)
+			} else {
+				level.NewTile("silver vein", x, y)
+			}
+		}
+	}
+
+	level.NewTile("stairs up", start.X, start.Y)
+	if rand.Float64() < abandonedToolChance {
+		tool := dug[rand.Intn(len(dug))]
+		level.PlaceItem(tool.X, tool.Y, item.NewNormalItem([]string{"pickaxe", "lantern"}[rand.Intn(2)]))
+	}
+
+	if !deeper {
+		return start
+	}
+	// The way down is at the far end of the workings
+	stairs := start
+	for _, location := range dug {
+		if distance(location, start) > distance(stairs, start) {
+			stairs = location
+		}
+	}
+	level.NewTile("stairs down", stairs.X, stairs.Y)
+	return stairs
+}
+
+// Grows a cave out of the rock around the stairs up, returning it along with every tile of its floor
+func generateCaveLevel(start worldmap.Coordinates) (worldmap.World, []worldmap.Coordinates) {
+	for {
+		open := make([][]bool, worldmap.LevelSize)
+		for y := range open {
+			open[y] = make([]bool, worldmap.LevelSize)
+			for x := range open[y] {
+				open[y][x] = rand.Float64() < caveOpenness
+			}
+		}
+
+		for i := 0; i < caveSmoothing; i++ {
+			open = smoothCave(open)
+			// Keep the entrance clear, so the cave is always reachable from the stairs
+			for y := start.Y - 1; y <= start.Y+1; y++ {
+				for x := start.X - 1; x <= start.X+1; x++ {
+					open[y][x] = true
+				}
+			}
+		}
+
+		floor := reachable(open, start)
+		if len(floor) < minCaveSize {
+			continue
+		}
+
+		level := worldmap.NewLevel()
+		for _, location := range floor {
+			level.NewTile("ground", location.X, location.Y)
+		}
+		level.NewTile("stairs up", start.X, start.Y)
+		return level, floor
+	}
+}
+
+// Opens up tiles surrounded by open space and fills in those surrounded by rock, leaving the edge of the level solid
+func smoothCave(open [][]bool) [][]bool {
+	smoothed := make([][]bool, worldmap.LevelSize)
+	for y := range smoothed {
+		smoothed[y] = make([]bool, worldmap.LevelSize)
+		for x := range smoothed[y] {
+			if x == 0 || y == 0 || x == worldmap.LevelSize-1 || y == worldmap.LevelSize-1 {
+				continue
+			}
+			walls := 0
+			for dY := -1; dY <= 1; dY++ {
+				for dX := -1; dX <= 1; dX++ {
+					if !open[y+dY][x+dX] {
+						walls++
+					}
+				}
+			}
+			smoothed[y][x] = walls < 5
+		}
+	}
+	return smoothed
+}
+
+// Every open tile that can be reached from start
+func reachable(open [][]bool, start worldmap.Coordinates) []worldmap.Coordinates {
+	seen := map[worldmap.Coordinates]bool{start: true}
+	queue := []worldmap.Coordinates{start}
+	for i := 0; i < len(queue); i++ {
+		location := queue[i]
+		for _, next := range []worldmap.Coordinates{{location.X + 1, location.Y}, {location.X - 1, location.Y}, {location.X, location.Y + 1}, {location.X, location.Y - 1}} {
+			if next.X < 0 || next.Y < 0 || next.X >= worldmap.LevelSize || next.Y >= worldmap.LevelSize || !open[next.Y][next.X] || seen[next] {
+				continue
+			}
+			seen[next] = true
+			queue = append(queue, next)
+		}
+	}
+	return queue
+}
+
+// Hides a band of outlaws, each with a lit lantern, deep in a cave around a campfire along with a chest of their loot
+func generateHideout(level worldmap.World, origin, start worldmap.Coordinates, floor []worldmap.Coordinates) []*npc.Npc {
+	hidden := make([]worldmap.Coordinates, 0)
+	for _, location := range floor {
+		if distance(location, start) >= hideoutDepth {
+			hidden = append(hidden, location)
+		}
+	}
+	if len(hidden) == 0 {
+		hidden = floor[1:]
+	}
+
+	n := minHideoutBandits + rand.Intn(maxHideoutBandits-minHideoutBandits+1)
+	bandits := make([]*npc.Npc, 0)
+	for i := 0; i < n && len(hidden) > 0; i++ {
+		j := rand.Intn(len(hidden))
+		location := hidden[j]
+		hidden = append(hidden[:j], hidden[j+1:]...)
+
+		bandit := npc.NewEnemy("bandit", origin.X+location.X, origin.Y+location.Y, nil)
+		bandit.SetDepth(1)
+		lantern := item.NewNormalItem("lantern")
+		lantern.Component("light").(*item.LightComponent).Toggle()
+		bandit.PickupItem(lantern)
+		bandits = append(bandits, bandit)
+	}
+	if len(bandits) == 0 {
+		return bandits
+	}
+
+	contents := []*item.Item{item.Money(500 + rand.Intn(4500))}
+	for i := 0; i < 1+rand.Intn(3); i++ {
+		contents = append(contents, item.GenerateItem())
+	}
+	placeContainer(level, 1, 1, worldmap.LevelSize-1, worldmap.LevelSize-1, item.NewNormalItem("chest"), bandits[0].GetID(), contents)
+	if len(hidden) > 0 {
+		camp := hidden[rand.Intn(len(hidden))]
+		level.PlaceItem(camp.X, camp.Y, item.NewNormalItem("campfire"))
+	}
+	return bandits
+}
+
+func inTownArea(towns []worldmap.Town, x, y int) bool {
+	for _, t := range towns {
+		if x >= t.TownArea.X1() && x <= t.TownArea.X2() && y >= t.TownArea.Y1() && y <= t.TownArea.Y2() {
+			return true
+		}
+	}
+	return false
+}
+
+func distance(a, b worldmap.Coordinates) float64 {
+	return math.Sqrt(math.Pow(float64(a.X-b.X), 2) + math.Pow(float64(a.Y-b.Y), 2))
+}
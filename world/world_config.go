@@ -10,16 +10,26 @@ type worldConfig struct {
 	Height       int
 	Towns        int
 	Farms        int
+	Rivers       int
+	Stations     int
+	Stagecoaches int
+	Mines        int
+	Caves        int
 	OutBuildings int
 	Mounts       int
 	Enemies      int
 	Npcs         int
 }
 
-var worldConf = fetchWorldConfig()
+var worldConf = fetchWorldConfig("data/world.json")
 
-func fetchWorldConfig() worldConfig {
-	data, err := ioutil.ReadFile("data/world.json")
+// UseConfig generates worlds from settings other than the game's own, such as a smaller world for tests
+func UseConfig(filename string) {
+	worldConf = fetchWorldConfig(filename)
+}
+
+func fetchWorldConfig(filename string) worldConfig {
+	data, err := ioutil.ReadFile(filename)
 	check(err)
 	var wc worldConfig
 	err = json.Unmarshal(data, &wc)
@@ -2,6 +2,8 @@ package world
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"math"
 	"math/rand"
@@ -24,27 +26,38 @@ func check(err error) {
 func GenerateWorld(filename string) (*player.Player, []*npc.Npc) {
 	logging.Info("Creating world")
 	world := worldmap.NewWorld(worldConf.Width, worldConf.Height)
+	biomes := generateBiomes(world)
+	for i := 0; i < worldConf.Rivers; i++ {
+		generateRiver(world, biomes)
+	}
 
 	towns := make([]worldmap.Town, 0)
 	buildings := make([]worldmap.Building, 0)
 
 	for i := 0; i < worldConf.Towns; i++ {
-		generateTown(world, &towns, &buildings)
+		generateTown(world, biomes, &towns, &buildings)
 	}
 
 	for i := 0; i < worldConf.Farms; i++ {
-		generateFarm(world, &towns, &buildings)
+		generateFarm(world, biomes, &towns, &buildings)
 	}
 
-	generatePaths(world, towns)
+	setMarkups(towns)
+	paths := generatePaths(world, biomes, towns)
 
 	// Generate buildings outside towns
 	for i := 0; i < worldConf.OutBuildings; i++ {
 		generateBuildingOutsideTown(world, &towns, &buildings)
 	}
 
+	railroad := generateRailroad(world, biomes, towns, &buildings)
+	roads := generateRoads(world, towns, paths)
+	stageLines := generateStageLines(towns, roads)
+
+	underground, levels, hideouts := generateUnderground(world, biomes, towns, buildings)
+
 	placeSignposts(world, towns)
-	addItemsToBuildings(world, buildings)
+	addItemsToBuildings(world, towns, buildings)
 	logging.Info("World created")
 
 	location := generatePlayerLocation(world, towns)
@@ -58,6 +71,7 @@ func GenerateWorld(filename string) (*player.Player, []*npc.Npc) {
 	logging.Info("NPCs generated")
 
 	npcs = append(npcs, enemies...)
+	npcs = append(npcs, hideouts...)
 	// Add mounts generated by npcs
 	for _, npc := range npcs {
 		if mount := npc.Mount(); mount != nil {
@@ -66,10 +80,30 @@ func GenerateWorld(filename string) (*player.Player, []*npc.Npc) {
 	}
 	npcs = append(npcs, mounts...)
 
+	townsJson, err := json.Marshal(towns)
+	check(err)
+	railroadJson, err := json.Marshal(railroad)
+	check(err)
+	roadsJson, err := json.Marshal(roads)
+	check(err)
+	stageLinesJson, err := json.Marshal(stageLines)
+	check(err)
+	undergroundJson, err := json.Marshal(underground)
+	check(err)
 	worldJson, err := world.MarshalJSON()
 	check(err)
+	levelsJson, err := worldmap.MarshalLevels(levels)
+	check(err)
 	buffer := bytes.NewBufferString("{")
+	buffer.WriteString(fmt.Sprintf("\"Towns\": %s, ", townsJson))
+	buffer.WriteString(fmt.Sprintf("\"Railroad\": %s, ", railroadJson))
+	buffer.WriteString(fmt.Sprintf("\"Roads\": %s, ", roadsJson))
+	buffer.WriteString(fmt.Sprintf("\"StageLines\": %s, ", stageLinesJson))
+	buffer.WriteString(fmt.Sprintf("\"Underground\": %s, ", undergroundJson))
 	buffer.Write(worldJson)
+	// Levels underground follow the surface, one to a line in the same way
+	buffer.WriteString(", ")
+	buffer.Write(levelsJson)
 	buffer.WriteString("}")
 
 	err = ioutil.WriteFile(filename, buffer.Bytes(), 0644)
@@ -77,8 +111,29 @@ func GenerateWorld(filename string) (*player.Player, []*npc.Npc) {
 	return p, npcs
 }
 
-func addItemsToBuildings(world worldmap.World, buildings []worldmap.Building) {
+// Distance from the nearest other town at which a town's shops charge the most
+const maxMarkupDistance = 500.0
+
+// Towns far from others charge more, up to half as much again
+func setMarkups(towns []worldmap.Town) {
+	for i, t := range towns {
+		nearest := maxMarkupDistance
+		x, y := (t.TownArea.X1()+t.TownArea.X2())/2, (t.TownArea.Y1()+t.TownArea.Y2())/2
+		for j, other := range towns {
+			if i == j {
+				continue
+			}
+			oX, oY := (other.TownArea.X1()+other.TownArea.X2())/2, (other.TownArea.Y1()+other.TownArea.Y2())/2
+			nearest = math.Min(nearest, worldmap.Distance(x, y, oX, oY))
+		}
+		towns[i].Markup = 0.5 * nearest / maxMarkupDistance
+	}
+}
+
+func addItemsToBuildings(world worldmap.World, towns []worldmap.Town, buildings []worldmap.Building) {
 	for _, b := range buildings {
+		// Anything kept in containers belongs to the town
+		owner := findTown(towns, b).Name
 
 		// Consider inner area (exclude walls)
 		x1, y1 := b.Area.X1()+1, b.Area.Y1()+1
@@ -98,7 +153,55 @@ func addItemsToBuildings(world worldmap.World, buildings []worldmap.Building) {
 			}
 		}
 
-		// If Saloon, place chairs and tables
+		// Every home has a bed to sleep in
+		if b.T == worldmap.Residential {
+			for {
+				x := x1 + rand.Intn(x2-x1)
+				y := y1 + rand.Intn(y2-y1)
+
+				if world.IsPassable(x, y) {
+					world.PlaceItem(x, y, item.NewNormalItem("bed"))
+					break
+				}
+			}
+		}
+
+		switch b.T {
+		case worldmap.Residential:
+			contents := make([]*item.Item, 0)
+			numOfItems := 1 + rand.Intn(3)
+			for i := 0; i < numOfItems; i++ {
+				contents = append(contents, item.GenerateItem())
+			}
+			contents = append(contents, item.Money(100+rand.Intn(1900)))
+			placeContainer(world, x1, y1, x2, y2, item.NewNormalItem("chest of drawers"), owner, contents)
+		case worldmap.GunShop:
+			strongbox := item.NewNormalItem("strongbox")
+			strongbox.Component("container").(*item.ContainerComponent).Lock(keyValue)
+			placeContainer(world, x1, y1, x2, y2, strongbox, owner, []*item.Item{item.Money(1000 + rand.Intn(4000))})
+		case worldmap.Sheriff:
+			// Confiscated gold is kept safe by the sheriff
+			safe := item.NewNormalItem("safe")
+			safe.Component("container").(*item.ContainerComponent).Lock(keyValue)
+			contents := []*item.Item{item.Money(5000 + rand.Intn(10000))}
+			numOfBars := 1 + rand.Intn(3)
+			for i := 0; i < numOfBars; i++ {
+				contents = append(contents, item.NewNormalItem("gold bar"))
+			}
+			placeContainer(world, x1, y1, x2, y2, safe, owner, contents)
+		case worldmap.Bank:
+			// The vault has its own lock, so it has to be cracked
+			vault := item.NewNormalItem("vault")
+			vault.Component("container").(*item.ContainerComponent).Lock(int32(rand.Int() + 1))
+			contents := []*item.Item{item.Money(20000 + rand.Intn(30000))}
+			numOfBars := 2 + rand.Intn(4)
+			for i := 0; i < numOfBars; i++ {
+				contents = append(contents, item.NewNormalItem("gold bar"))
+			}
+			placeContainer(world, x1, y1, x2, y2, vault, owner, contents)
+		}
+
+		// If Saloon, place chairs and tables, each lit by a lamp
 		if b.T == worldmap.Saloon {
 
 			// Chairs and tables should not be close to counter or front door
@@ -121,6 +224,9 @@ func addItemsToBuildings(world worldmap.World, buildings []worldmap.Building) {
 					// If a free 3x3 space exists, add a table and four chairs
 					if x >= x1 && x+2 <= x2 && y >= y1 && y+2 <= y2 {
 						world.PlaceItem(x+1, y+1, item.NewNormalItem("table"))
+						lamp := item.NewNormalItem("oil lamp")
+						lamp.TransferOwner(owner)
+						world.PlaceItem(x+1, y+1, lamp)
 						world.PlaceItem(x, y+1, item.NewNormalItem("chair"))
 						world.PlaceItem(x+1, y, item.NewNormalItem("chair"))
 						world.PlaceItem(x+2, y+1, item.NewNormalItem("chair"))
@@ -129,7 +235,7 @@ func addItemsToBuildings(world worldmap.World, buildings []worldmap.Building) {
 				}
 			}
 
-		} else if b.T != worldmap.Sheriff {
+		} else if b.T != worldmap.Sheriff && b.T != worldmap.Bank && b.T != worldmap.TrainStation {
 
 			buildingArea := (x2 - x1) * (y2 - y1)
 
@@ -152,6 +258,28 @@ func addItemsToBuildings(world worldmap.World, buildings []worldmap.Building) {
 
 }
 
+// Places a container filled with items somewhere inside a building
+func placeContainer(world worldmap.World, x1, y1, x2, y2 int, container *item.Item, owner string, contents []*item.Item) {
+	container.TransferOwner(owner)
+	cc := container.Component("container").(*item.ContainerComponent)
+	for _, itm := range contents {
+		if cc.Fits(itm) {
+			itm.TransferOwner(owner)
+			cc.Put(itm)
+		}
+	}
+
+	for {
+		x := x1 + rand.Intn(x2-x1)
+		y := y1 + rand.Intn(y2-y1)
+
+		if world.IsPassable(x, y) {
+			world.PlaceItem(x, y, container)
+			return
+		}
+	}
+}
+
 // Generate a rectangular building and place on map
 func generateBuildingOutsideTown(world worldmap.World, towns *[]worldmap.Town, buildings *[]worldmap.Building) {
 	width := world.Width()
@@ -193,6 +321,7 @@ func generateBuildingOutsideTown(world worldmap.World, towns *[]worldmap.Town, b
 		validBuilding = isValid(x1, y1, width, height) && isValid(x2, y2, width, height) && !overlap(*buildings, b) && !inTowns(*towns, b)
 
 		if validBuilding {
+			clearArea(world, x1, y1, x2, y2)
 			// Add walls
 			for x := x1; x <= x2; x++ {
 				world.NewTile("wall", x, y1)
@@ -274,17 +403,17 @@ func randomBuildingType(buildings *[]worldmap.Building) worldmap.BuildingType {
 		return worldmap.Residential
 	} else {
 		for {
-			commercialType := worldmap.BuildingType(rand.Intn(3) + 1)
-			// Only one sheriff
-			if commercialType == worldmap.Sheriff {
+			commercialType := worldmap.BuildingType(rand.Intn(5) + 1)
+			// Only one sheriff and one bank
+			if commercialType == worldmap.Sheriff || commercialType == worldmap.Bank {
 
-				sheriffExists := false
+				alreadyExists := false
 				for _, b := range *buildings {
-					if b.T == worldmap.Sheriff {
-						sheriffExists = true
+					if b.T == commercialType {
+						alreadyExists = true
 					}
 				}
-				if !sheriffExists {
+				if !alreadyExists {
 					return commercialType
 				}
 			} else {
@@ -294,10 +423,16 @@ func randomBuildingType(buildings *[]worldmap.Building) worldmap.BuildingType {
 	}
 }
 
-func generateBuildingInTown(world worldmap.World, t *worldmap.Town, buildings *[]worldmap.Building, buildingType worldmap.BuildingType) {
+// Number of tries at fitting a building into a town before giving up
+const maxBuildingAttempts = 1000
+
+func generateBuildingInTown(world worldmap.World, t *worldmap.Town, buildings *[]worldmap.Building, buildingType worldmap.BuildingType) bool {
 	validBuilding := false
 	// Keeps trying until a usable building position and size found
-	for !validBuilding {
+	for attempts := 0; !validBuilding; attempts++ {
+		if attempts == maxBuildingAttempts {
+			return false
+		}
 
 		// Must be at least 3 in each dimension
 
@@ -475,13 +610,14 @@ func generateBuildingInTown(world worldmap.World, t *worldmap.Town, buildings *[
 			t.Buildings = append(t.Buildings, b)
 		}
 	}
+	return true
 }
 
 func generateRandomBuildingInTown(world worldmap.World, t *worldmap.Town, buildings *[]worldmap.Building) {
 	generateBuildingInTown(world, t, buildings, randomBuildingType(buildings))
 }
 
-func validTown(world worldmap.World, towns *[]worldmap.Town, buildings *[]worldmap.Building, minimum, maximum int) *worldmap.Town {
+func validTown(world worldmap.World, biomes biomeMap, suitable []biome, towns *[]worldmap.Town, buildings *[]worldmap.Building, minimum, maximum int) *worldmap.Town {
 	// Generate area of town
 
 	width := world.Width()
@@ -489,7 +625,7 @@ func validTown(world worldmap.World, towns *[]worldmap.Town, buildings *[]worldm
 
 	valid := false
 
-	for !valid {
+	for attempts := 0; !valid; attempts++ {
 		townWidth := minimum + rand.Intn(maximum-minimum)
 		townHeight := minimum + rand.Intn(maximum-minimum)
 
@@ -534,7 +670,16 @@ func validTown(world worldmap.World, towns *[]worldmap.Town, buildings *[]worldm
 		t := worldmap.NewTown(generateTownName(), x1, y1, x2, y2, streetX1, streetY1, streetX2, streetY2, horizontalStreet, false)
 
 		valid := isValid(x1, y1, width, height) && isValid(x2, y2, width, height) && !townsOverlap(*towns, *t)
+		// Settle for anywhere if nowhere suitable can be found
+		if valid && attempts < maxTownAttempts {
+			valid = biomes.coverage(x1, y1, x2, y2, suitable) >= townCoverage
+		}
+		// Hold out for a riverbank for a while, but without building over the river itself
+		if valid && attempts < riverbankAttempts {
+			valid = biomes.coverage(x1, y1, x2, y2, []biome{river}) == 0 && biomes.near(x1, y1, x2, y2, riverbankDistance, river)
+		}
 		if valid {
+			clearArea(world, x1, y1, x2, y2)
 			return t
 		}
 	}
@@ -542,8 +687,8 @@ func validTown(world worldmap.World, towns *[]worldmap.Town, buildings *[]worldm
 }
 
 // Generate small town (single street with buildings)
-func generateTown(world worldmap.World, towns *[]worldmap.Town, buildings *[]worldmap.Building) {
-	town := validTown(world, towns, buildings, 20, 50)
+func generateTown(world worldmap.World, biomes biomeMap, towns *[]worldmap.Town, buildings *[]worldmap.Building) {
+	town := validTown(world, biomes, []biome{desert, plains}, towns, buildings, 20, 50)
 	townWidth := 0
 	if town.Horizontal {
 		townWidth = town.TownArea.X2() - town.TownArea.X1()
@@ -559,8 +704,8 @@ func generateTown(world worldmap.World, towns *[]worldmap.Town, buildings *[]wor
 	*towns = append(*towns, *town)
 }
 
-func generateFarm(world worldmap.World, towns *[]worldmap.Town, buildings *[]worldmap.Building) {
-	town := validTown(world, towns, buildings, 30, 60)
+func generateFarm(world worldmap.World, biomes biomeMap, towns *[]worldmap.Town, buildings *[]worldmap.Building) {
+	town := validTown(world, biomes, []biome{plains}, towns, buildings, 30, 60)
 	town.Farm = true
 
 	// Generate fields
@@ -638,9 +783,12 @@ func generateTownName() string {
 type path struct {
 	curves []func(float64) worldmap.Coordinates
 	width  int
+	// Towns the path joins
+	from int
+	to   int
 }
 
-func generatePaths(world worldmap.World, towns []worldmap.Town) {
+func generatePaths(world worldmap.World, biomes biomeMap, towns []worldmap.Town) []path {
 	// Create tiles in towns
 	for _, t := range towns {
 		for y := t.StreetArea.Y1(); y <= t.StreetArea.Y2(); y++ {
@@ -793,18 +941,19 @@ func generatePaths(world worldmap.World, towns []worldmap.Town) {
 				}
 			}
 		}
-		paths[i] = path{curves, width}
+		paths[i] = path{curves, width, c.first, c.second}
 	}
 
 	// Create tiles for paths
 
 	for _, path := range paths {
-		generatePath(world, path)
+		generatePath(world, biomes, path)
 	}
+	return paths
 
 }
 
-func generatePath(world worldmap.World, path path) {
+func generatePath(world worldmap.World, biomes biomeMap, path path) {
 	start := path.curves[0](0.0)
 	end := path.curves[0](1.0)
 
@@ -814,7 +963,12 @@ func generatePath(world worldmap.World, path path) {
 		for t := 0.0; t <= 1.0; t += minStep {
 			curr := curve(t)
 			if curr.X >= 0 && curr.X < world.Width() && curr.Y >= 0 && curr.Y < world.Height() {
-				world.NewTile("path", curr.X, curr.Y)
+				// Roads cross water by bridge
+				if b := biomes[curr.Y][curr.X]; b == river || b == lake {
+					world.NewTile("bridge", curr.X, curr.Y)
+				} else {
+					world.NewTile("path", curr.X, curr.Y)
+				}
 			}
 		}
 	}
@@ -928,6 +1082,12 @@ func generateNpcs(m worldmap.World, towns []worldmap.Town, buildings []worldmap.
 			for j := 0; j < numPatrons; j++ {
 				placeNpcInBuilding(m, &npcs, findTown(towns, b), b, "bar patron")
 			}
+		case worldmap.Doctor:
+			placeNpcInBuilding(m, &npcs, findTown(towns, b), b, "doctor")
+		case worldmap.TrainStation:
+			placeNpcInBuilding(m, &npcs, findTown(towns, b), b, "station agent")
+		case worldmap.Bank:
+			placeNpcInBuilding(m, &npcs, findTown(towns, b), b, "teller")
 		case worldmap.Sheriff:
 			placeNpcInBuilding(m, &npcs, findTown(towns, b), b, "sheriff")
 			numDeputies := rand.Intn(3)
@@ -13,10 +13,15 @@ const (
 	GunShop
 	Saloon
 	Sheriff
+	Doctor
+	Bank
+	TrainStation
+	// A travelling trader's wagon, met out on the road rather than built in town
+	Wagon
 )
 
 func (t BuildingType) String() string {
-	return [...]string{"Residential", "GunShop", "Saloon", "Sheriff"}[t]
+	return [...]string{"Residential", "GunShop", "Saloon", "Sheriff", "Doctor", "Bank", "TrainStation", "Wagon"}[t]
 }
 
 func NewBuilding(x1, y1, x2, y2 int, t BuildingType) Building {
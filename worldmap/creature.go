@@ -10,7 +10,32 @@ import (
 	"github.com/onorton/cowboysindians/ui"
 )
 
-var Attributes []string = []string{"str", "dex"}
+var Attributes []string = []string{"str", "dex", "con", "cha", "per"}
+
+// Score of an attribute that gives neither a bonus nor a penalty
+const AverageScore = 10
+
+// AddAttributes gives a creature an average score in any attribute it does not already have
+func AddAttributes(attributes map[string]*Attribute) {
+	for _, attr := range Attributes {
+		if _, ok := attributes[attr]; !ok {
+			attributes[attr] = NewAttribute(AverageScore, AverageScore)
+		}
+	}
+}
+
+// Bonus returns the bonus given by an attribute, which is zero for attributes a creature doesn't have
+func Bonus(attributes map[string]*Attribute, attr string) int {
+	if a, ok := attributes[attr]; ok {
+		return GetBonus(a.Value())
+	}
+	return 0
+}
+
+// VisionDistance is how far a creature can see with their perception
+func VisionDistance(attributes map[string]*Attribute) int {
+	return 20 + 2*Bonus(attributes, "per")
+}
 
 type Attribute struct {
 	value   int
@@ -46,6 +71,17 @@ func (a *Attribute) AddEffect(e *item.Effect) {
 	a.value = int(math.Max(0.0, math.Min(float64(a.max), float64(a.value))))
 }
 
+// RemoveOngoing removes an ongoing effect with the given value, returning false if there isn't one
+func (a *Attribute) RemoveOngoing(effect int) bool {
+	for i, e := range a.effects {
+		if e.Ongoing() && e.Value() == effect {
+			a.effects = append(a.effects[:i], a.effects[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 func (a *Attribute) Effects() []*item.Effect {
 	return a.effects
 }
@@ -143,6 +179,18 @@ type Creature interface {
 	Update()
 	GetID() string
 	SetMap(*Map)
+	GetDepth() int
+	SetDepth(int)
+}
+
+// LightSource is anything that may carry a light to see by in the dark
+type LightSource interface {
+	LightRadius() int
+}
+
+// Swimmer is a creature that may be able to cross deep water, such as someone on horseback
+type Swimmer interface {
+	CanSwim() bool
 }
 
 type hasPosition interface {
@@ -0,0 +1,156 @@
+package worldmap
+
+import (
+	"math/rand"
+
+	"github.com/onorton/cowboysindians/icon"
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/ui"
+)
+
+// Turns wooden walls and the like burn for before leaving ruins
+const terrainBurnTime = 12
+
+// Turns furniture burns for before it is gone
+const itemBurnTime = 6
+
+// Turns something with nothing to burn, like spilt spirits, stays alight
+const spiritBurnTime = 2
+
+// Turns smoke hangs around after drifting off a fire
+const smokeTime = 3
+
+// Chance each turn of a fire catching on each flammable tile next to it
+const spreadChance = 0.1
+
+var fireDamage = item.NewDamage(6, 1, 0)
+
+var fireIcon = icon.NewIcon('^', ui.ColourRed)
+var smokeIcon = icon.NewIcon('░', ui.ColourWhite)
+
+// How many turns a tile would burn for once alight
+func (grid *Grid) fuel(x, y int) int {
+	if grid.flammable(x, y) {
+		return terrainBurnTime
+	}
+	for _, itm := range grid.items[y][x] {
+		if itm.HasComponent("flammable") {
+			return itemBurnTime
+		}
+	}
+	return 0
+}
+
+func (m Map) IsBurning(x, y int) bool {
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	return chunk.fire[cY][cX] > 0
+}
+
+func (m Map) hasSmoke(x, y int) bool {
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	return chunk.smoke[cY][cX] > 0
+}
+
+// Ignite sets a location alight, burning for as long as there is fuel or briefly if there is none
+func (m Map) Ignite(x, y int) {
+	if !m.IsValid(x, y) || m.IsBurning(x, y) {
+		return
+	}
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	chunk.fire[cY][cX] = chunk.fuel(cX, cY)
+	if chunk.fire[cY][cX] == 0 {
+		chunk.fire[cY][cX] = spiritBurnTime
+	}
+}
+
+func (m Map) Extinguish(x, y int) {
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	chunk.fire[cY][cX] = 0
+}
+
+// UpdateFire burns and spreads fires across the active chunks, hurting anyone caught in them
+func (m Map) UpdateFire() {
+	pX, pY := m.player.GetCoordinates()
+	centre := globalToChunkCoordinates(pX, pY)
+
+	burning := make([]Coordinates, 0)
+	for i, row := range m.activeChunks {
+		for j, chunk := range row {
+			if chunk == nil {
+				continue
+			}
+			originX, originY := (centre.ChunkX+j-1)*chunkSize, (centre.ChunkY+i-1)*chunkSize
+			for y := range chunk.fire {
+				for x, turns := range chunk.fire[y] {
+					if chunk.smoke[y][x] > 0 {
+						chunk.smoke[y][x]--
+					}
+					if turns > 0 {
+						burning = append(burning, Coordinates{originX + x, originY + y})
+					}
+				}
+			}
+		}
+	}
+
+	// Only fires already burning at the start of the turn spread
+	for _, l := range burning {
+		m.burn(l.X, l.Y)
+	}
+}
+
+func (m Map) burn(x, y int) {
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	if m.exposedTo(x, y, Rain) && rand.Float64() < rainDouseChance {
+		chunk.fire[cY][cX] = 0
+		return
+	}
+
+	if c := chunk.c[cY][cX]; c != nil && !c.IsDead() {
+		if c.GetAlignment() == Player {
+			message.Enqueue("You are burned by the flames!")
+		}
+		c.TakeDamage(fireDamage, item.Effects{}, 0)
+	}
+
+	// Smoke drifts off the fire onto a neighbouring tile
+	sX, sY := x+rand.Intn(3)-1, y+rand.Intn(3)-1
+	if m.IsValid(sX, sY) && !m.IsBurning(sX, sY) {
+		smokeChunk, sCX, sCY := m.globalToChunkAndLocal(sX, sY)
+		smokeChunk.smoke[sCY][sCX] = smokeTime
+	}
+
+	for i := -1; i <= 1; i++ {
+		for j := -1; j <= 1; j++ {
+			nX, nY := x+j, y+i
+			if !m.IsValid(nX, nY) || m.IsBurning(nX, nY) {
+				continue
+			}
+			neighbour, nCX, nCY := m.globalToChunkAndLocal(nX, nY)
+			if neighbour.fuel(nCX, nCY) > 0 && rand.Float64() < spreadChance {
+				m.Ignite(nX, nY)
+			}
+		}
+	}
+
+	chunk.fire[cY][cX]--
+	if chunk.fire[cY][cX] == 0 {
+		chunk.burnOut(cX, cY)
+	}
+}
+
+// Once a fire dies down, walls it burnt are left in ruins and furniture is gone
+func (grid *Grid) burnOut(x, y int) {
+	if grid.flammable(x, y) {
+		grid.newTile("ruins", x, y)
+	}
+
+	remaining := make([]*item.Item, 0, len(grid.items[y][x]))
+	for _, itm := range grid.items[y][x] {
+		if !itm.HasComponent("flammable") {
+			remaining = append(remaining, itm)
+		}
+	}
+	grid.items[y][x] = remaining
+}
@@ -22,6 +22,14 @@ type TileAttributes struct {
 	Passable     bool
 	BlocksVision bool
 	Door         bool
+	Destructible bool
+	Flammable    bool
+	// Turns it takes on average to cross, where anything under two is easy going
+	MoveCost int
+	// 1 for stairs leading down a level, -1 for those leading up
+	Stairs int
+	// What is dug out of the tile if it is an ore vein
+	Ore string
 }
 
 var terrainDataPath string = "data/terrain.json"
@@ -43,6 +51,9 @@ type Grid struct {
 	blocksVision [][]bool
 	c            [][]Creature
 	items        [][][]*item.Item
+	// Turns each tile has left to burn and to stay filled with smoke
+	fire  [][]int
+	smoke [][]int
 }
 
 func NewGrid(width int, height int) *Grid {
@@ -53,6 +64,8 @@ func NewGrid(width int, height int) *Grid {
 	blocksVision := make([][]bool, height)
 	c := make([][]Creature, height)
 	items := make([][][]*item.Item, height)
+	fire := make([][]int, height)
+	smoke := make([][]int, height)
 
 	grid := &Grid{}
 
@@ -63,6 +76,8 @@ func NewGrid(width int, height int) *Grid {
 		blocksVision[y] = make([]bool, width)
 		c[y] = make([]Creature, width)
 		items[y] = make([][]*item.Item, width)
+		fire[y] = make([]int, width)
+		smoke[y] = make([]int, width)
 	}
 
 	grid.terrain = terrain
@@ -71,6 +86,8 @@ func NewGrid(width int, height int) *Grid {
 	grid.blocksVision = blocksVision
 	grid.c = c
 	grid.items = items
+	grid.fire = fire
+	grid.smoke = smoke
 
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
@@ -102,9 +119,44 @@ func (grid *Grid) newTile(tileType string, x, y int) {
 	grid.blocksVision[y][x] = terrain.BlocksVision
 }
 
+// Tile types aren't kept once placed, so terrain is recognised by its icon
+func (grid *Grid) terrainIs(x, y int, matches func(TileAttributes) bool) bool {
+	for _, terrain := range terrainData {
+		if matches(terrain) && terrain.Icon == grid.terrain[y][x] {
+			return true
+		}
+	}
+	return false
+}
+
+func (grid *Grid) destructible(x, y int) bool {
+	return grid.terrainIs(x, y, func(terrain TileAttributes) bool { return terrain.Destructible })
+}
+
+func (grid *Grid) flammable(x, y int) bool {
+	return grid.terrainIs(x, y, func(terrain TileAttributes) bool { return terrain.Flammable })
+}
+
+func (grid *Grid) deepWater(x, y int) bool {
+	return grid.terrain[y][x] == terrainData["water"].Icon
+}
+
+func (grid *Grid) water(x, y int) bool {
+	return grid.deepWater(x, y) || grid.terrain[y][x] == terrainData["ford"].Icon
+}
+
+func (grid *Grid) moveCost(x, y int) int {
+	for _, terrain := range terrainData {
+		if terrain.Icon == grid.terrain[y][x] && terrain.MoveCost > 1 {
+			return terrain.MoveCost
+		}
+	}
+	return 1
+}
+
 func (grid *Grid) MarshalJSON() ([]byte, error) {
 	buffer := bytes.NewBufferString("{")
-	keys := []string{"Terrain", "Passable", "Door", "BlocksVision", "Items"}
+	keys := []string{"Terrain", "Passable", "Door", "BlocksVision", "Items", "Fire", "Smoke"}
 
 	gridValues := map[string]interface{}{
 		"Terrain":      grid.terrain,
@@ -112,6 +164,8 @@ func (grid *Grid) MarshalJSON() ([]byte, error) {
 		"Door":         grid.door,
 		"BlocksVision": grid.blocksVision,
 		"Items":        grid.items,
+		"Fire":         grid.fire,
+		"Smoke":        grid.smoke,
 	}
 
 	length := len(gridValues)
@@ -141,6 +195,8 @@ func (grid *Grid) UnmarshalJSON(data []byte) error {
 		Door         [][]*doorComponent
 		BlocksVision [][]bool
 		Items        [][][]*item.Item
+		Fire         [][]int
+		Smoke        [][]int
 	}
 	v := gridJson{}
 
@@ -153,6 +209,17 @@ func (grid *Grid) UnmarshalJSON(data []byte) error {
 	grid.door = v.Door
 	grid.blocksVision = v.BlocksVision
 	grid.items = v.Items
+	grid.fire = v.Fire
+	grid.smoke = v.Smoke
+	// Chunks saved before fires could start have none burning
+	if grid.fire == nil {
+		grid.fire = make([][]int, grid.height())
+		grid.smoke = make([][]int, grid.height())
+		for y := 0; y < grid.height(); y++ {
+			grid.fire[y] = make([]int, grid.width())
+			grid.smoke[y] = make([]int, grid.width())
+		}
+	}
 	grid.c = make([][]Creature, grid.height())
 	for y := 0; y < grid.height(); y++ {
 		grid.c[y] = make([]Creature, grid.width())
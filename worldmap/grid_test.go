@@ -76,6 +76,15 @@ func TestNewTileAddsDifferentTileToLocation(t *testing.T) {
 		{"counter", false, false, false},
 		{"path", true, false, false},
 		{"ground", true, false, false},
+		{"sand", true, false, false},
+		{"grass", true, false, false},
+		{"rock", true, false, false},
+		{"cliff", false, true, false},
+		{"cactus", false, false, false},
+		{"trees", true, true, false},
+		{"water", false, false, false},
+		{"ford", true, false, false},
+		{"bridge", true, false, false},
 	}
 
 	for _, testCase := range testCases {
@@ -95,3 +104,87 @@ func TestNewTileAddsDifferentTileToLocation(t *testing.T) {
 	}
 
 }
+
+func TestDestructibleTiles(t *testing.T) {
+	grid := NewGrid(5, 6)
+
+	testCases := map[string]bool{
+		"wall":         true,
+		"window":       true,
+		"door":         true,
+		"counter flap": true,
+		"counter":      true,
+		"path":         false,
+		"ground":       false,
+		"rubble":       false,
+	}
+
+	for tileType, destructible := range testCases {
+		grid.newTile(tileType, 1, 1)
+		if grid.destructible(1, 1) != destructible {
+			t.Errorf("Expected destructibility of tile %s to be %t but was %t", tileType, destructible, grid.destructible(1, 1))
+		}
+	}
+}
+
+func TestMoveCost(t *testing.T) {
+	grid := NewGrid(5, 6)
+
+	testCases := map[string]int{
+		"ground": 1,
+		"path":   1,
+		"grass":  1,
+		"sand":   2,
+		"rock":   2,
+		"trees":  2,
+		"ford":   3,
+		"bridge": 1,
+	}
+
+	for tileType, cost := range testCases {
+		grid.newTile(tileType, 1, 1)
+		if grid.moveCost(1, 1) != cost {
+			t.Errorf("Expected movement cost of tile %s to be %d but was %d", tileType, cost, grid.moveCost(1, 1))
+		}
+	}
+}
+
+func TestFlammableTerrainBurnsDownToRuins(t *testing.T) {
+	grid := NewGrid(5, 6)
+
+	grid.newTile("wall", 1, 1)
+	if grid.fuel(1, 1) != terrainBurnTime {
+		t.Errorf("Expected wall to burn for %d turns but it would burn for %d", terrainBurnTime, grid.fuel(1, 1))
+	}
+
+	grid.burnOut(1, 1)
+	if grid.flammable(1, 1) || !grid.passable[1][1] || grid.blocksVision[1][1] {
+		t.Error("Expected burnt out wall to leave passable ruins but it did not")
+	}
+
+	if grid.fuel(2, 2) != 0 {
+		t.Errorf("Expected bare ground not to burn but it would burn for %d turns", grid.fuel(2, 2))
+	}
+}
+
+func TestOnlyWaterIsTooDeepToWade(t *testing.T) {
+	grid := NewGrid(5, 6)
+
+	testCases := []struct {
+		tileType string
+		water    bool
+		deep     bool
+	}{
+		{"water", true, true},
+		{"ford", true, false},
+		{"bridge", false, false},
+		{"grass", false, false},
+	}
+
+	for _, testCase := range testCases {
+		grid.newTile(testCase.tileType, 1, 1)
+		if grid.water(1, 1) != testCase.water || grid.deepWater(1, 1) != testCase.deep {
+			t.Errorf("Expected tile %s to be water %t and deep %t but was %t and %t", testCase.tileType, testCase.water, testCase.deep, grid.water(1, 1), grid.deepWater(1, 1))
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package worldmap
+
+import (
+	"math"
+
+	"github.com/onorton/cowboysindians/message"
+)
+
+// Turns in a day, each one a minute, and the hour the first day begins at
+const dayLength = 24 * 60
+const startHour = 8
+
+// How far things can be seen in dim light and by starlight
+const dimVisionDistance = 10
+const starlightDistance = 4
+
+// How far around it a fire lights up
+const fireLightRadius = 5
+
+type TimeOfDay int
+
+const (
+	Dawn TimeOfDay = iota
+	Day
+	Dusk
+	Night
+)
+
+func (t TimeOfDay) String() string {
+	return [...]string{"Dawn", "Day", "Dusk", "Night"}[t]
+}
+
+// Light is how brightly lit a location is, from pitch dark to broad daylight or lamplight
+type Light int
+
+const (
+	Dark Light = iota
+	Starlight
+	Dim
+	Bright
+)
+
+// How light it is out in the open at each time of day
+var ambientLight = map[TimeOfDay]Light{
+	Dawn:  Dim,
+	Day:   Bright,
+	Dusk:  Dim,
+	Night: Starlight,
+}
+
+// Lighting keeps track of the time of day and which locations around the player lamps, lanterns and fires light up
+type Lighting struct {
+	time int
+	lit  map[Coordinates]bool
+}
+
+func NewLighting() *Lighting {
+	return &Lighting{0, map[Coordinates]bool{}}
+}
+
+func timeOfDay(time int) TimeOfDay {
+	hour := (time + startHour*60) % dayLength / 60
+	switch {
+	case hour >= 5 && hour < 7:
+		return Dawn
+	case hour >= 7 && hour < 18:
+		return Day
+	case hour >= 18 && hour < 20:
+		return Dusk
+	}
+	return Night
+}
+
+func (m Map) TimeOfDay() TimeOfDay {
+	return timeOfDay(m.lighting.time)
+}
+
+// TimeStatus describes the time of day for the status line, or returns an empty string in the day or when there is no telling underground
+func (m Map) TimeStatus() string {
+	if t := m.TimeOfDay(); t != Day && !m.Underground() {
+		return t.String()
+	}
+	return ""
+}
+
+// UpdateLighting moves the time of day on and works out again what is lit up around the player
+func (m Map) UpdateLighting(time int) {
+	// Until the time has first been set there is no change to announce
+	started := m.lighting.time > 0
+	before := m.TimeOfDay()
+	m.lighting.time = time
+	if after := m.TimeOfDay(); started && after != before && !m.Underground() {
+		switch after {
+		case Dawn:
+			message.Enqueue("The sky begins to lighten.")
+		case Dusk:
+			message.Enqueue("The sun sinks towards the horizon.")
+		case Night:
+			message.Enqueue("Night falls.")
+		}
+	}
+	m.relight()
+}
+
+// Lights up everywhere within reach of a fire, a lamp or lantern lying about, or anyone carrying a lit one
+func (m Map) relight() {
+	m.lighting.lit = map[Coordinates]bool{}
+	pX, pY := m.player.GetCoordinates()
+	centre := globalToChunkCoordinates(pX, pY)
+
+	for i, row := range m.activeChunks {
+		for j, chunk := range row {
+			if chunk == nil {
+				continue
+			}
+			originX, originY := (centre.ChunkX+j-1)*chunkSize, (centre.ChunkY+i-1)*chunkSize
+			for y := range chunk.fire {
+				for x := range chunk.fire[y] {
+					if chunk.fire[y][x] > 0 {
+						m.lightUp(originX+x, originY+y, fireLightRadius)
+					}
+					for _, itm := range chunk.items[y][x] {
+						if itm.LightRadius() > 0 {
+							m.lightUp(originX+x, originY+y, itm.LightRadius())
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for _, c := range m.creatures {
+		if light, ok := c.(LightSource); ok && light.LightRadius() > 0 && m.IsActive(c) {
+			x, y := c.GetCoordinates()
+			m.lightUp(x, y, light.LightRadius())
+		}
+	}
+}
+
+func (m Map) lightUp(x0, y0, radius int) {
+	for y := y0 - radius; y <= y0+radius; y++ {
+		for x := x0 - radius; x <= x0+radius; x++ {
+			if Distance(x0, y0, x, y) <= float64(radius) {
+				m.lighting.lit[Coordinates{x, y}] = true
+			}
+		}
+	}
+}
+
+// LightAt returns how brightly lit a location is. Anyone carrying a light stands out however dark it is around them.
+func (m Map) LightAt(x, y int) Light {
+	if !m.IsValid(x, y) {
+		return Dark
+	}
+	if m.lighting.lit[Coordinates{x, y}] {
+		return Bright
+	}
+	if light, ok := m.GetCreature(x, y).(LightSource); ok && light.LightRadius() > 0 {
+		return Bright
+	}
+	if m.Underground() {
+		return Dark
+	}
+
+	light := ambientLight[m.TimeOfDay()]
+	// Buildings are darker inside than out
+	if m.Sheltered(x, y) {
+		light--
+	}
+	return light
+}
+
+// How lit a location looks, where anyone crouching in the shadows is as good as in the pitch dark
+func (m Map) visibleLight(x, y int) Light {
+	light := m.LightAt(x, y)
+	if light >= Dim || !m.IsValid(x, y) {
+		return light
+	}
+	if c := m.GetCreature(x, y); c != nil && c.IsCrouching() {
+		return Dark
+	}
+	return light
+}
+
+// InShadow returns true if a location is too dark for anyone to see much there
+func (m Map) InShadow(x, y int) bool {
+	return m.LightAt(x, y) < Dim
+}
+
+// How far away something can be seen in a light
+func (l Light) visibleWithin() float64 {
+	switch l {
+	case Dim:
+		return dimVisionDistance
+	case Starlight:
+		return starlightDistance
+	case Dark:
+		return darkVisionDistance
+	}
+	return math.Inf(1)
+}
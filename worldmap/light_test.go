@@ -0,0 +1,98 @@
+package worldmap
+
+import (
+	"testing"
+)
+
+type testViewer struct {
+	testCreature
+	light     int
+	crouching bool
+}
+
+func (c *testViewer) GetVisionDistance() int { return 20 }
+func (c *testViewer) LightRadius() int       { return c.light }
+func (c *testViewer) IsCrouching() bool      { return c.crouching }
+
+// A map of open ground at a time of day, with the player in the middle
+func lightTestMap(time int) Map {
+	m := Map{width: chunkSize, height: chunkSize, weather: NewWeather(chunkSize, chunkSize), lighting: NewLighting()}
+	m.player = &testViewer{testCreature{32, 32}, 0, false}
+	m.activeChunks[1][1] = NewGrid(chunkSize, chunkSize)
+	m.lighting.time = time
+	return m
+}
+
+func TestTimeOfDayFollowsTheClock(t *testing.T) {
+	testCases := map[int]TimeOfDay{
+		1:             Day,
+		11 * 60:       Dusk,
+		14 * 60:       Night,
+		21*60 + 30:    Dawn,
+		dayLength + 1: Day,
+	}
+
+	for time, expected := range testCases {
+		if timeOfDay(time) != expected {
+			t.Errorf("Expected it to be %s at turn %d but was %s", expected, time, timeOfDay(time))
+		}
+	}
+}
+
+func TestNightShortensVision(t *testing.T) {
+	m := lightTestMap(14 * 60)
+
+	if !m.IsVisible(m.player, 32+starlightDistance, 32) {
+		t.Error("Expected to see nearby by starlight")
+	}
+	if m.IsVisible(m.player, 32+starlightDistance+1, 32) {
+		t.Error("Expected not to see far in the dark")
+	}
+
+	m.lighting.time = 1
+	if !m.IsVisible(m.player, 50, 32) {
+		t.Error("Expected to see far by day")
+	}
+}
+
+func TestFireLightsUpTheDark(t *testing.T) {
+	m := lightTestMap(14 * 60)
+	m.activeChunks[1][1].fire[32][48] = 3
+	m.relight()
+
+	if !m.IsVisible(m.player, 48+fireLightRadius-1, 32) {
+		t.Error("Expected to see what a fire lights up however far away")
+	}
+	if m.IsVisible(m.player, 48+fireLightRadius+1, 32) {
+		t.Error("Expected not to see past the reach of a fire's light")
+	}
+}
+
+func TestLightCarriedIsSeenFromAfar(t *testing.T) {
+	m := lightTestMap(14 * 60)
+	carrier := &testViewer{testCreature{50, 32}, 6, false}
+	m.Move(carrier, 50, 32)
+
+	if !m.IsVisible(m.player, 50, 32) {
+		t.Error("Expected someone carrying a light to be seen in the dark from afar")
+	}
+
+	m.Move(carrier, 40, 32)
+	carrier.light = 0
+	if m.IsVisible(m.player, 40, 32) {
+		t.Error("Expected someone without a light to go unseen in the dark")
+	}
+}
+
+func TestCrouchingHidesInShadow(t *testing.T) {
+	m := lightTestMap(14 * 60)
+	lurker := &testViewer{testCreature{34, 32}, 0, true}
+	m.Move(lurker, 34, 32)
+
+	if m.IsVisible(m.player, 34, 32) {
+		t.Error("Expected someone crouching in the shadows to go unseen")
+	}
+	if !m.IsVisible(m.player, 33, 32) {
+		t.Error("Expected an empty spot nearby to be seen by starlight")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math"
+	"math/rand"
 	"os"
 	"strings"
 
@@ -22,8 +23,18 @@ type Map struct {
 	activeChunks [3][3]*Grid
 	filename     string
 	v            *Viewer
+	overview     *Overview
+	weather      *Weather
+	lighting     *Lighting
+	railroad     Railroad
+	train        *Train
+	roads        []Road
+	stageLines   []int
+	stagecoaches []*Stagecoach
+	underground  []Underground
 	width        int
 	height       int
+	towns        []Town
 	player       Creature
 	creatures    []Creature
 }
@@ -40,13 +51,19 @@ type ChunkCoordinates struct {
 }
 
 type worldState struct {
-	Height int
-	Width  int
+	Height      int
+	Width       int
+	Towns       []Town
+	Railroad    Railroad
+	Roads       []Road
+	StageLines  []int
+	Underground []Underground
 }
 
-func NewMap(filename string, viewer *Viewer, player Creature, creatures []Creature) *Map {
+func NewMap(filename string, viewer *Viewer, overview *Overview, weather *Weather, train *Train, stagecoaches []*Stagecoach, player Creature, creatures []Creature) *Map {
 	newMap := new(Map)
 	newMap.v = viewer
+	newMap.overview = overview
 	newMap.filename = filename
 
 	data, err := ioutil.ReadFile(filename)
@@ -57,6 +74,33 @@ func NewMap(filename string, viewer *Viewer, player Creature, creatures []Creatu
 
 	newMap.width = state.Width
 	newMap.height = state.Height
+	newMap.towns = state.Towns
+
+	// New games, and games saved before the overview existed, start with nothing explored
+	if newMap.overview == nil {
+		newMap.overview = NewOverview(newMap.width, newMap.height)
+	}
+	newMap.weather = weather
+	if newMap.weather == nil {
+		newMap.weather = NewWeather(newMap.width, newMap.height)
+	}
+	newMap.lighting = NewLighting()
+	newMap.railroad = state.Railroad
+	newMap.train = train
+	if newMap.train == nil {
+		newMap.train = NewTrain()
+	}
+	newMap.roads = state.Roads
+	newMap.stageLines = state.StageLines
+	newMap.stagecoaches = stagecoaches
+	if newMap.stagecoaches == nil {
+		newMap.stagecoaches = make([]*Stagecoach, len(newMap.stageLines))
+		for i := range newMap.stageLines {
+			newMap.stagecoaches[i] = NewStagecoach(i)
+		}
+	}
+
+	newMap.underground = state.Underground
 
 	newMap.player = player
 	newMap.creatures = creatures
@@ -69,24 +113,13 @@ func NewMap(filename string, viewer *Viewer, player Creature, creatures []Creatu
 func (m *Map) LoadActiveChunks() {
 
 	m.activeChunks = [3][3]*Grid{}
-	pX, pY := m.player.GetCoordinates()
-	newPlayerLocation := globalToChunkCoordinates(pX, pY)
 
 	file, err := os.Open(m.filename)
 	check(err)
 	defer file.Close()
 
 	reader := bufio.NewReader(file)
-	// Determine what the line numbers are for the chunks
-	lineToChunks := map[int]Coordinates{}
-	for y := 0; y < 3; y++ {
-		for x := 0; x < 3; x++ {
-			index := m.verticalChunks()*(newPlayerLocation.ChunkY+y-1) + newPlayerLocation.ChunkX + x - 1
-			if index >= 0 && index < m.verticalChunks()*m.horizontalChunks() {
-				lineToChunks[index] = Coordinates{x, y}
-			}
-		}
-	}
+	lineToChunks := m.activeLines()
 	currentLine := 1
 	reader.ReadString('\n')
 	for {
@@ -106,35 +139,51 @@ func (m *Map) LoadActiveChunks() {
 
 	// Place creatures
 	for _, c := range m.creatures {
-		x, y := c.GetCoordinates()
-		if m.InActiveChunks(x, y) {
+		if m.IsActive(c) {
+			x, y := c.GetCoordinates()
 			m.Move(c, x, y)
 		}
 	}
+	for _, s := range m.stagecoaches {
+		m.seat(s)
+	}
+	m.relight()
 }
 
-func (m *Map) SaveChunks() {
+// Determine what the line numbers are for the chunks around the player, and where each goes among the active chunks
+func (m Map) activeLines() map[int]Coordinates {
 	pX, pY := m.player.GetCoordinates()
-	newPlayerLocation := globalToChunkCoordinates(pX, pY)
-
-	file, err := os.Open(m.filename)
-	check(err)
-	outputFile, err := os.Create("tmp_" + m.filename)
-	check(err)
-
-	reader := bufio.NewReader(file)
-	writer := bufio.NewWriter(outputFile)
-	// Determine what the line numbers are for the chunks
+	playerLocation := globalToChunkCoordinates(pX, pY)
 
 	lineToChunks := map[int]Coordinates{}
+	// Underground there is only the level the player is on, with solid rock all around it
+	if m.Underground() {
+		if u, ok := m.undergroundAt(pX, pY); ok {
+			lineToChunks[m.levelLine(u, m.Depth())] = Coordinates{1, 1}
+		}
+		return lineToChunks
+	}
+
 	for y := 0; y < 3; y++ {
 		for x := 0; x < 3; x++ {
-			index := m.verticalChunks()*(newPlayerLocation.ChunkY+y-1) + newPlayerLocation.ChunkX + x - 1
+			index := m.verticalChunks()*(playerLocation.ChunkY+y-1) + playerLocation.ChunkX + x - 1
 			if index >= 0 && index < m.verticalChunks()*m.horizontalChunks() {
 				lineToChunks[index] = Coordinates{x, y}
 			}
 		}
 	}
+	return lineToChunks
+}
+
+func (m *Map) SaveChunks() {
+	file, err := os.Open(m.filename)
+	check(err)
+	outputFile, err := os.Create("tmp_" + m.filename)
+	check(err)
+
+	reader := bufio.NewReader(file)
+	writer := bufio.NewWriter(outputFile)
+	lineToChunks := m.activeLines()
 	currentLine := 1
 	firstLine, err := reader.ReadString('\n')
 	check(err)
@@ -145,7 +194,11 @@ func (m *Map) SaveChunks() {
 			chunkData, err := m.activeChunks[coordinates.Y][coordinates.X].MarshalJSON()
 			check(err)
 			writer.Write(chunkData)
-			writer.WriteString(",\n")
+			// The last chunk of a list has no comma after it
+			if strings.HasSuffix(line, ",\n") {
+				writer.WriteString(",")
+			}
+			writer.WriteString("\n")
 		} else {
 			writer.WriteString(line)
 		}
@@ -233,6 +286,12 @@ func NewViewer(x, y, viewerWidth, viewerHeight int) *Viewer {
 	return &Viewer{x - viewerWidth/2, y - viewerHeight/2, viewerWidth, viewerHeight}
 }
 
+// ResizeViewer changes the size of the viewer, keeping the player in the centre
+func (m *Map) ResizeViewer(width, height int) {
+	x, y := m.player.GetCoordinates()
+	*m.v = *NewViewer(x, y, width, height)
+}
+
 func (m Map) GetViewerX() int {
 	return m.v.x
 }
@@ -267,13 +326,30 @@ func (m Map) IsPassable(x, y int) bool {
 	if !m.InActiveChunks(x, y) {
 		return false
 	}
+	if _, ok := m.trainCar(x, y); ok {
+		return false
+	}
+	if _, _, ok := m.stagecoachAt(x, y); ok {
+		return false
+	}
 	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
 	return chunk.passable[cY][cX]
 }
 
+// SlowGoing returns true if someone stepping onto x, y is held up by rough terrain,
+// so that crossing it takes as many turns as its movement cost on average
+func (m Map) SlowGoing(x, y int) bool {
+	if !m.IsValid(x, y) {
+		return false
+	}
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	cost := chunk.moveCost(cX, cY)
+	return cost > 1 && rand.Float64() < 1-1/float64(cost)
+}
+
 func (m Map) blocksVision(x, y int) bool {
 	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
-	return chunk.blocksVision[cY][cX]
+	return chunk.blocksVision[cY][cX] || chunk.smoke[cY][cX] > 0
 }
 
 func (m Map) IsOccupied(x, y int) bool {
@@ -288,10 +364,21 @@ func (m Map) HasItems(x, y int) bool {
 // Bresenham algorithm to check if creature c can see square x1, y1.
 func (m Map) IsVisible(c CanSee, x1, y1 int) bool {
 	x0, y0 := c.GetCoordinates()
+	// Only the level the player is on is loaded, so those on other levels see nothing of it
+	if creature, ok := c.(Creature); ok && creature.GetDepth() != m.Depth() {
+		return false
+	}
 	distance := Distance(x0, y0, x1, y1)
 	if distance > float64(c.GetVisionDistance()) {
 		return false
 	}
+	// Past the reach of their own light, anyone can only see as far as it is light enough to
+	if distance > float64(m.darkVision(c)) && distance > m.visibleLight(x1, y1).visibleWithin() {
+		return false
+	}
+	if !m.Underground() && m.weather.at(x0, y0) == DustStorm && distance > stormVisionDistance {
+		return false
+	}
 
 	crouching := false
 	if canCrouch, ok := c.(CanCrouch); ok {
@@ -572,6 +659,57 @@ func (m *Map) PlaceItem(x, y int, itm *item.Item) {
 	chunk.items[cY][cX] = append([]*item.Item{itm}, chunk.items[cY][cX]...)
 }
 
+// RemoveItem takes a particular item from a location, returning false if it isn't there
+func (m *Map) RemoveItem(x, y int, itm *item.Item) bool {
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	items := chunk.items[cY][cX]
+	for i, other := range items {
+		if other == itm {
+			chunk.items[cY][cX] = append(items[:i], items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Where something thrown from x0, y0 at x1, y1 comes down, on the first creature in its way
+// or just short of anything it can't pass through
+func (m Map) LandingSpot(x0, y0, x1, y1 int) (int, int) {
+	dx, dy := x1-x0, y1-y0
+	steps := int(math.Max(math.Abs(float64(dx)), math.Abs(float64(dy))))
+	x, y := x0, y0
+	for i := 1; i <= steps; i++ {
+		nextX := x0 + int(math.Round(float64(dx*i)/float64(steps)))
+		nextY := y0 + int(math.Round(float64(dy*i)/float64(steps)))
+		if !m.IsPassable(nextX, nextY) {
+			break
+		}
+		x, y = nextX, nextY
+		if m.IsOccupied(x, y) {
+			break
+		}
+	}
+	return x, y
+}
+
+// Blast reduces walls, windows and doors at a location to rubble and breaks open any locked containers there
+func (m Map) Blast(x, y int) {
+	if !m.IsValid(x, y) {
+		return
+	}
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	if chunk.destructible(cX, cY) {
+		chunk.newTile("rubble", cX, cY)
+	}
+	for _, itm := range chunk.items[cY][cX] {
+		if itm.HasComponent("container") {
+			if container := itm.Component("container").(*item.ContainerComponent); container.Locked() {
+				container.ToggleLocked()
+			}
+		}
+	}
+}
+
 func (m Map) GetWidth() int {
 	return m.width
 }
@@ -580,6 +718,14 @@ func (m Map) GetHeight() int {
 	return m.height
 }
 
+func (m Map) Overview() *Overview {
+	return m.overview
+}
+
+func (m Map) Towns() []Town {
+	return m.towns
+}
+
 func (m Map) horizontalChunks() int {
 	return m.width / chunkSize
 }
@@ -641,7 +787,7 @@ func (m *Map) MovePlayer(player Creature, x, y int) {
 
 func (m Map) Move(c Creature, x, y int) {
 
-	if !m.IsPassable(x, y) {
+	if !m.IsPassable(x, y) && !m.canSwim(c, x, y) {
 		return
 	}
 
@@ -684,8 +830,19 @@ func (m *Map) RenderTile(x, y int) ui.Element {
 		return ui.EmptyElement()
 	}
 
-	if m.GetCreature(x, y) != nil {
-		return m.GetCreature(x, y).Render()
+	if c := m.GetCreature(x, y); c != nil {
+		if _, part, ok := m.stagecoachAt(x, y); ok && part == stagecoachLength-1 {
+			return m.renderDriver(c)
+		}
+		return c.Render()
+	} else if car, ok := m.trainCar(x, y); ok {
+		return m.renderTrain(car)
+	} else if _, part, ok := m.stagecoachAt(x, y); ok {
+		return m.renderStagecoach(part)
+	} else if m.IsBurning(x, y) {
+		return fireIcon.Render()
+	} else if m.hasSmoke(x, y) {
+		return smokeIcon.Render()
 	} else if m.IsPassable(x, y) {
 		if m.HasItems(x, y) {
 			// pick an item that gives cover if it exists
@@ -705,6 +862,16 @@ func (m *Map) RenderTile(x, y int) ui.Element {
 	return chunk.terrain[cY][cX].Render()
 }
 
+// AddCreature brings a new creature into the world, such as guards jumping down from a train
+func (m *Map) AddCreature(c Creature) {
+	m.creatures = append(m.creatures, c)
+	c.SetMap(m)
+	if m.IsActive(c) {
+		x, y := c.GetCoordinates()
+		m.Move(c, x, y)
+	}
+}
+
 func (m Map) DeleteCreature(c Creature) {
 	x, y := c.GetCoordinates()
 	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
@@ -713,6 +880,10 @@ func (m Map) DeleteCreature(c Creature) {
 
 func (m Map) Render() {
 	player := m.GetPlayer()
+	pX, pY := player.GetCoordinates()
+	if !m.Underground() {
+		m.overview.Explore(pX, pY, player.GetVisionDistance())
+	}
 
 	elems := make([][]ui.Element, m.v.height, m.v.height)
 
@@ -746,6 +917,39 @@ func (m Map) Door(x, y int) *doorComponent {
 	return chunk.door[cY][cX]
 }
 
+// HasItem returns true if an item with the given name lies at a location
+func (m Map) HasItem(x, y int, name string) bool {
+	return m.Item(x, y, name) != nil
+}
+
+// Item returns the first item with the given name lying at a location, leaving it in place
+func (m Map) Item(x, y int, name string) *item.Item {
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	for _, itm := range chunk.items[cY][cX] {
+		if itm.GetName() == name {
+			return itm
+		}
+	}
+	return nil
+}
+
+// Container returns the first container lying at a location, leaving it in place
+func (m Map) Container(x, y int) *item.Item {
+	if safe := m.expressSafe(x, y); safe != nil {
+		return safe
+	}
+	if strongbox := m.stagecoachStrongbox(x, y); strongbox != nil {
+		return strongbox
+	}
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	for _, itm := range chunk.items[cY][cX] {
+		if itm.HasComponent("container") {
+			return itm
+		}
+	}
+	return nil
+}
+
 func (m Map) ToggleDoor(x, y int, open bool) {
 	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
 
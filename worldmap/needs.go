@@ -0,0 +1,122 @@
+package worldmap
+
+import (
+	"github.com/onorton/cowboysindians/item"
+)
+
+// Needs grow by one every turn and have to be kept in check to survive
+var Needs []string = []string{"hunger", "thirst", "fatigue"}
+
+const maxNeed = 1000
+
+// Fatigue recovered each turn by sleeping without a bed
+const GroundRest = 3
+
+type NeedLevel int
+
+const (
+	Satisfied NeedLevel = iota
+	Mild
+	Severe
+	Critical
+)
+
+var needDescriptions = map[string][]string{
+	"hunger":  []string{"", "Hungry", "Famished", "Starving"},
+	"thirst":  []string{"", "Thirsty", "Parched", "Dehydrated"},
+	"fatigue": []string{"", "Tired", "Exhausted", "Collapsing"},
+}
+
+// Attribute penalised at each level of a need
+var needPenalties = map[string]string{
+	"hunger":  "str",
+	"thirst":  "dex",
+	"fatigue": "dex",
+}
+
+// AddNeeds gives a creature any needs it does not already have
+func AddNeeds(attributes map[string]*Attribute) {
+	for _, need := range Needs {
+		if _, ok := attributes[need]; !ok {
+			attributes[need] = NewAttribute(0, maxNeed)
+			attributes[need].AddEffect(item.NewOngoingEffect(1))
+		}
+	}
+}
+
+// Level returns how badly a need is not being met. Creatures without the need are always satisfied.
+func Level(a *Attribute) NeedLevel {
+	if a == nil {
+		return Satisfied
+	}
+	switch {
+	case a.value > a.max*9/10:
+		return Critical
+	case a.value > a.max*3/4:
+		return Severe
+	case a.value > a.max/2:
+		return Mild
+	}
+	return Satisfied
+}
+
+// NeedStatus describes a need for the status line, or returns an empty string if it is satisfied
+func NeedStatus(need string, a *Attribute) string {
+	return needDescriptions[need][Level(a)]
+}
+
+// ApplyNeeds penalises attributes for unmet needs until the next turn.
+// Critical hunger and thirst also cause the loss of hit points.
+func ApplyNeeds(attributes map[string]*Attribute) {
+	for _, need := range Needs {
+		a, ok := attributes[need]
+		if !ok {
+			continue
+		}
+
+		level := Level(a)
+		if level >= Severe {
+			penalty := -2 * int(level-Mild)
+			attributes[needPenalties[need]].AddEffect(item.NewEffect(penalty, 1, false))
+		}
+
+		if level == Critical && need != "fatigue" && a.value%10 == 0 {
+			attributes["hp"].AddEffect(item.NewInstantEffect(-1))
+		}
+	}
+}
+
+// Starving returns true if a creature is losing hit points to hunger or thirst
+func Starving(attributes map[string]*Attribute) bool {
+	for _, need := range []string{"hunger", "thirst"} {
+		if a, ok := attributes[need]; ok && Level(a) == Critical {
+			return true
+		}
+	}
+	return false
+}
+
+// Starved returns true if a creature has died from hunger or thirst
+func Starved(attributes map[string]*Attribute) bool {
+	for _, need := range []string{"hunger", "thirst"} {
+		if a, ok := attributes[need]; ok && a.value == a.max {
+			return true
+		}
+	}
+	return false
+}
+
+// Rest returns how much fatigue is recovered each turn by sleeping at x, y
+func (m Map) Rest(x, y int) int {
+	rest := GroundRest
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	if chunk == nil {
+		return rest
+	}
+	for _, itm := range chunk.items[cY][cX] {
+		if bed, ok := itm.Component("bed").(item.BedComponent); ok && bed.Rest > rest {
+			rest = bed.Rest
+		}
+	}
+	return rest
+}
@@ -0,0 +1,433 @@
+package worldmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/onorton/cowboysindians/icon"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/ui"
+)
+
+// Number of tiles along each side of the area summarised by a single overview cell
+const overviewResolution = 8
+
+var unexploredTownIcon = icon.NewIcon('*', ui.ColourWhite)
+var markerIcon = icon.NewIcon('X', ui.ColourRed)
+var cursorIcon = icon.NewIcon('+', ui.ColourYellow)
+
+type summaryCell struct {
+	terrain  icon.Icon
+	building bool
+	path     bool
+}
+
+// Overview is a downsampled map of the whole world. It remembers which areas
+// the player has explored and any markers they have placed.
+type Overview struct {
+	explored [][]bool
+	markers  []Marker
+	summary  [][]*summaryCell
+}
+
+type Marker struct {
+	Location Coordinates
+	Label    string
+}
+
+// Landmark is a point of interest shown on the overview that the map does not know about itself, such as a bounty.
+type Landmark struct {
+	location Coordinates
+	icon     icon.Icon
+	label    string
+}
+
+func NewOverview(width, height int) *Overview {
+	explored := make([][]bool, height/overviewResolution)
+	for i := range explored {
+		explored[i] = make([]bool, width/overviewResolution)
+	}
+	return &Overview{explored, make([]Marker, 0), nil}
+}
+
+func NewLandmark(x, y int, symbol rune, label string) Landmark {
+	return Landmark{Coordinates{x, y}, icon.NewIcon(symbol, ui.ColourGreen), label}
+}
+
+func (o *Overview) width() int {
+	return len(o.explored[0])
+}
+
+func (o *Overview) height() int {
+	return len(o.explored)
+}
+
+// Explore marks all cells within distance of x, y as explored
+func (o *Overview) Explore(x, y, distance int) {
+	for cY := (y - distance) / overviewResolution; cY <= (y+distance)/overviewResolution; cY++ {
+		for cX := (x - distance) / overviewResolution; cX <= (x+distance)/overviewResolution; cX++ {
+			if cX >= 0 && cX < o.width() && cY >= 0 && cY < o.height() {
+				o.explored[cY][cX] = true
+			}
+		}
+	}
+}
+
+func (o *Overview) isExplored(x, y int) bool {
+	cX, cY := x/overviewResolution, y/overviewResolution
+	return cX >= 0 && cX < o.width() && cY >= 0 && cY < o.height() && o.explored[cY][cX]
+}
+
+// AddMarker places a marker, replacing any marker already at that location
+func (o *Overview) AddMarker(x, y int, label string) {
+	o.RemoveMarker(x, y)
+	o.markers = append(o.markers, Marker{Coordinates{x, y}, label})
+}
+
+func (o *Overview) RemoveMarker(x, y int) {
+	for i, m := range o.markers {
+		if m.Location.X == x && m.Location.Y == y {
+			o.markers = append(o.markers[:i], o.markers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (o *Overview) Markers() []Marker {
+	return o.markers
+}
+
+func (o *Overview) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+
+	explored := make([]string, len(o.explored))
+	for i, row := range o.explored {
+		var rowString strings.Builder
+		for _, e := range row {
+			if e {
+				rowString.WriteRune('1')
+			} else {
+				rowString.WriteRune('0')
+			}
+		}
+		explored[i] = rowString.String()
+	}
+
+	exploredValue, err := json.Marshal(explored)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer.WriteString(fmt.Sprintf("\"Explored\":%s,", exploredValue))
+
+	markersValue, err := json.Marshal(o.markers)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer.WriteString(fmt.Sprintf("\"Markers\":%s", markersValue))
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (o *Overview) UnmarshalJSON(data []byte) error {
+
+	type overviewJson struct {
+		Explored []string
+		Markers  []Marker
+	}
+
+	var v overviewJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	o.explored = make([][]bool, len(v.Explored))
+	for i, row := range v.Explored {
+		o.explored[i] = make([]bool, len(row))
+		for j, e := range row {
+			o.explored[i][j] = e == '1'
+		}
+	}
+	o.markers = v.Markers
+	if o.markers == nil {
+		o.markers = make([]Marker, 0)
+	}
+
+	return nil
+}
+
+func summariseGrid(terrain [][]icon.Icon, door [][]*doorComponent) [][]*summaryCell {
+	height, width := len(terrain)/overviewResolution, len(terrain[0])/overviewResolution
+	pathIcon := terrainData["path"].Icon
+
+	summary := make([][]*summaryCell, height)
+	for cY := range summary {
+		summary[cY] = make([]*summaryCell, width)
+		for cX := range summary[cY] {
+			cell := &summaryCell{}
+			// The most common terrain in the cell represents it
+			counts := make(map[icon.Icon]int)
+			for y := cY * overviewResolution; y < (cY+1)*overviewResolution; y++ {
+				for x := cX * overviewResolution; x < (cX+1)*overviewResolution; x++ {
+					t := terrain[y][x]
+					counts[t]++
+					if counts[t] > counts[cell.terrain] {
+						cell.terrain = t
+					}
+					cell.building = cell.building || door[y][x] != nil
+					cell.path = cell.path || t == pathIcon
+				}
+			}
+			summary[cY][cX] = cell
+		}
+	}
+	return summary
+}
+
+// Summarises the whole world by reading every chunk from the world file.
+// Active chunks are summarised from memory since they may have changed.
+func (m *Map) summariseWorld() {
+	o := m.overview
+	o.summary = make([][]*summaryCell, m.height/overviewResolution)
+	for i := range o.summary {
+		o.summary[i] = make([]*summaryCell, m.width/overviewResolution)
+	}
+
+	file, err := os.Open(m.filename)
+	check(err)
+	defer file.Close()
+
+	type chunkJson struct {
+		Terrain [][]icon.Icon
+		Door    [][]*doorComponent
+	}
+
+	reader := bufio.NewReader(file)
+	reader.ReadString('\n')
+	for index := 0; index < m.verticalChunks()*m.horizontalChunks(); index++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		chunk := chunkJson{}
+		err = json.Unmarshal([]byte(strings.Trim(line, ",\n")), &chunk)
+		check(err)
+		m.copySummary(index%m.horizontalChunks(), index/m.horizontalChunks(), summariseGrid(chunk.Terrain, chunk.Door))
+	}
+	m.summariseActiveChunks()
+}
+
+func (m *Map) summariseActiveChunks() {
+	// Levels underground have no place on the map of the surface
+	if m.Underground() {
+		return
+	}
+	pX, pY := m.player.GetCoordinates()
+	playerLocation := globalToChunkCoordinates(pX, pY)
+	for y, row := range m.activeChunks {
+		for x, chunk := range row {
+			if chunk != nil {
+				m.copySummary(playerLocation.ChunkX+x-1, playerLocation.ChunkY+y-1, summariseGrid(chunk.terrain, chunk.door))
+			}
+		}
+	}
+}
+
+func (m *Map) copySummary(chunkX, chunkY int, summary [][]*summaryCell) {
+	for y, row := range summary {
+		for x, cell := range row {
+			m.overview.summary[chunkY*len(summary)+y][chunkX*len(row)+x] = cell
+		}
+	}
+}
+
+// Computes how many tiles each overview element covers horizontally and vertically
+func (m *Map) overviewScale() (int, int) {
+	scaleX := (m.width + m.v.width - 1) / m.v.width
+	scaleY := (m.height + m.v.height - 1) / m.v.height
+	return scaleX, scaleY
+}
+
+func (m *Map) renderOverviewCell(x1, y1, x2, y2 int) ui.Element {
+	o := m.overview
+	var terrain *icon.Icon
+	path := false
+	for y := y1; y < y2 && y < m.height; y += overviewResolution {
+		for x := x1; x < x2 && x < m.width; x += overviewResolution {
+			if !o.isExplored(x, y) {
+				continue
+			}
+			cell := o.summary[y/overviewResolution][x/overviewResolution]
+			if cell.building {
+				return terrainData["wall"].Icon.Render()
+			}
+			path = path || cell.path
+			if terrain == nil {
+				terrain = &cell.terrain
+			}
+		}
+	}
+	if path {
+		return terrainData["path"].Icon.Render()
+	}
+	if terrain != nil {
+		return terrain.Render()
+	}
+	return ui.EmptyElement()
+}
+
+func writeLabel(elems [][]ui.Element, x, y int, label string) {
+	if y < 0 || y >= len(elems) {
+		return
+	}
+	x -= len(label) / 2
+	for _, c := range label {
+		if x >= 0 && x < len(elems[y]) {
+			elems[y][x] = ui.NewElement(c, ui.ColourWhite)
+		}
+		x++
+	}
+}
+
+func (m *Map) renderOverview(cursor Coordinates, landmarks []Landmark) {
+	scaleX, scaleY := m.overviewScale()
+	elems := make([][]ui.Element, m.v.height)
+	for y := range elems {
+		elems[y] = make([]ui.Element, m.v.width)
+		for x := range elems[y] {
+			elems[y][x] = m.renderOverviewCell(x*scaleX, y*scaleY, (x+1)*scaleX, (y+1)*scaleY)
+		}
+	}
+
+	for _, t := range m.towns {
+		x, y := (t.TownArea.X1()+t.TownArea.X2())/2/scaleX, (t.TownArea.Y1()+t.TownArea.Y2())/2/scaleY
+		if elems[y][x] == ui.EmptyElement() {
+			elems[y][x] = unexploredTownIcon.Render()
+		}
+		writeLabel(elems, x, y+1, t.Name)
+	}
+
+	for _, l := range landmarks {
+		elems[l.location.Y/scaleY][l.location.X/scaleX] = l.icon.Render()
+	}
+
+	for _, marker := range m.overview.markers {
+		elems[marker.Location.Y/scaleY][marker.Location.X/scaleX] = markerIcon.Render()
+	}
+
+	pX, pY := m.player.GetCoordinates()
+	elems[pY/scaleY][pX/scaleX] = m.player.Render()
+	elems[cursor.Y][cursor.X] = cursorIcon.Render()
+
+	ui.RenderGrid(0, 0, elems)
+}
+
+// Describes what is under the cursor on the overview
+func (m *Map) describeOverviewCell(cursor Coordinates, landmarks []Landmark) string {
+	scaleX, scaleY := m.overviewScale()
+	under := func(c Coordinates) bool {
+		return c.X/scaleX == cursor.X && c.Y/scaleY == cursor.Y
+	}
+	descriptions := make([]string, 0)
+
+	if x, y := m.player.GetCoordinates(); under(Coordinates{x, y}) {
+		descriptions = append(descriptions, "You")
+	}
+	for _, t := range m.towns {
+		if under(Coordinates{(t.TownArea.X1() + t.TownArea.X2()) / 2, (t.TownArea.Y1() + t.TownArea.Y2()) / 2}) {
+			descriptions = append(descriptions, t.Name)
+		}
+	}
+	for _, l := range landmarks {
+		if under(l.location) {
+			descriptions = append(descriptions, l.label)
+		}
+	}
+	for _, marker := range m.overview.markers {
+		if under(marker.Location) {
+			descriptions = append(descriptions, marker.Label)
+		}
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// ShowOverview displays the overview of the whole world along with the given landmarks.
+// The player can move a cursor around to place and remove markers until they exit,
+// or pick a town they have been to before to travel to.
+func (m *Map) ShowOverview(landmarks []Landmark) (Town, bool) {
+	if m.overview.summary == nil {
+		message.PrintMessage("Drawing map...")
+		m.summariseWorld()
+	} else {
+		m.summariseActiveChunks()
+	}
+
+	scaleX, scaleY := m.overviewScale()
+	pX, pY := m.player.GetCoordinates()
+	cursor := Coordinates{pX / scaleX, pY / scaleY}
+
+	for {
+		ui.ClearScreen()
+		m.renderOverview(cursor, landmarks)
+		message.PrintMessage(m.describeOverviewCell(cursor, landmarks) + " ")
+
+		action := ui.GetInput()
+		if action.IsMovementAction() {
+			x, y := cursor.X, cursor.Y
+			switch action {
+			case ui.MoveNorth, ui.MoveNorthWest, ui.MoveNorthEast:
+				y--
+			case ui.MoveSouth, ui.MoveSouthWest, ui.MoveSouthEast:
+				y++
+			}
+			switch action {
+			case ui.MoveWest, ui.MoveNorthWest, ui.MoveSouthWest:
+				x--
+			case ui.MoveEast, ui.MoveNorthEast, ui.MoveSouthEast:
+				x++
+			}
+			if x >= 0 && x < m.v.width && x*scaleX < m.width && y >= 0 && y < m.v.height && y*scaleY < m.height {
+				cursor = Coordinates{x, y}
+			}
+			continue
+		}
+
+		// Markers are placed in the middle of the area under the cursor
+		x, y := cursor.X*scaleX+scaleX/2, cursor.Y*scaleY+scaleY/2
+		switch action {
+		case ui.Place:
+			if label := message.RequestInput("Marker label:"); label != "" {
+				m.overview.AddMarker(x, y, label)
+			}
+		case ui.DropItem:
+			for _, marker := range m.overview.markers {
+				if marker.Location.X/scaleX == cursor.X && marker.Location.Y/scaleY == cursor.Y {
+					m.overview.RemoveMarker(marker.Location.X, marker.Location.Y)
+					break
+				}
+			}
+		case ui.Travel:
+			for _, t := range m.towns {
+				if (t.TownArea.X1()+t.TownArea.X2())/2/scaleX != cursor.X || (t.TownArea.Y1()+t.TownArea.Y2())/2/scaleY != cursor.Y {
+					continue
+				}
+				if m.Discovered(t) {
+					ui.ClearScreen()
+					return t, true
+				}
+				message.PrintMessage(fmt.Sprintf("You don't know the way to %s yet.", t.Name))
+				ui.GetInput()
+			}
+		case ui.Exit, ui.CancelAction, ui.WorldMap:
+			ui.ClearScreen()
+			return Town{}, false
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package worldmap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExploreMarksCellsWithinDistance(t *testing.T) {
+	overview := NewOverview(128, 64)
+	overview.Explore(20, 20, 4)
+
+	testCases := []struct {
+		x, y     int
+		explored bool
+	}{
+		{20, 20, true},
+		{16, 16, true},
+		{23, 23, true},
+		{15, 20, false},
+		{32, 20, false},
+		{20, 40, false},
+	}
+
+	for _, testCase := range testCases {
+		if overview.isExplored(testCase.x, testCase.y) != testCase.explored {
+			t.Errorf("Expected exploration of %d, %d to be %t but was %t", testCase.x, testCase.y, testCase.explored, overview.isExplored(testCase.x, testCase.y))
+		}
+	}
+}
+
+func TestAddMarkerReplacesMarkerAtSameLocation(t *testing.T) {
+	overview := NewOverview(128, 64)
+	overview.AddMarker(10, 10, "Camp")
+	overview.AddMarker(10, 10, "Hideout")
+	overview.AddMarker(50, 10, "Mine")
+
+	if len(overview.Markers()) != 2 {
+		t.Fatalf("Expected 2 markers but there were %d", len(overview.Markers()))
+	}
+	if overview.Markers()[0].Label != "Hideout" || overview.Markers()[1].Label != "Mine" {
+		t.Errorf("Expected markers to be Hideout and Mine but were %s and %s", overview.Markers()[0].Label, overview.Markers()[1].Label)
+	}
+
+	overview.RemoveMarker(50, 10)
+	if len(overview.Markers()) != 1 {
+		t.Errorf("Expected 1 marker after removal but there were %d", len(overview.Markers()))
+	}
+}
+
+func TestOverviewMarshalling(t *testing.T) {
+	overview := NewOverview(128, 64)
+	overview.Explore(100, 8, 0)
+	overview.AddMarker(3, 4, "Camp")
+
+	data, err := json.Marshal(overview)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmarshalled := &Overview{}
+	if err := json.Unmarshal(data, unmarshalled); err != nil {
+		t.Fatal(err)
+	}
+
+	if unmarshalled.width() != overview.width() || unmarshalled.height() != overview.height() {
+		t.Errorf("Expected size to be %dx%d but was %dx%d", overview.width(), overview.height(), unmarshalled.width(), unmarshalled.height())
+	}
+	if !unmarshalled.isExplored(100, 8) || unmarshalled.isExplored(0, 0) {
+		t.Error("Expected explored cells to be preserved")
+	}
+	if len(unmarshalled.Markers()) != 1 || unmarshalled.Markers()[0] != (Marker{Coordinates{3, 4}, "Camp"}) {
+		t.Errorf("Expected markers to be preserved but were %v", unmarshalled.Markers())
+	}
+}
+
+func TestSummariseGridFindsBuildingsAndPaths(t *testing.T) {
+	grid := NewGrid(16, 16)
+	grid.newTile("door", 2, 2)
+	grid.newTile("path", 12, 12)
+
+	summary := summariseGrid(grid.terrain, grid.door)
+
+	if !summary[0][0].building || summary[0][0].path {
+		t.Error("Expected cell with door to be a building and not a path")
+	}
+	if !summary[1][1].path || summary[1][1].building {
+		t.Error("Expected cell with path to be a path and not a building")
+	}
+	if summary[0][1].terrain != terrainData["ground"].Icon {
+		t.Error("Expected empty cell to be ground")
+	}
+}
@@ -0,0 +1,336 @@
+package worldmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"github.com/onorton/cowboysindians/icon"
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/ui"
+)
+
+// Number of tiles a train covers, from the engine back to the express car
+const trainLength = 5
+
+// Tiles a train travels each turn
+const trainSpeed = 2
+
+// Turns a train waits at each station before leaving
+const stationStop = 30
+
+// Cost of a ticket in cents for each tile of track travelled
+const fareRate = 2
+
+// Number of guards riding in the express car
+const expressGuards = 3
+
+var engineIcon = icon.NewIcon(9608, 245)
+var carIcon = icon.NewIcon(9644, 95)
+var expressCarIcon = icon.NewIcon(9635, 4)
+
+// Station is a stop on the railroad, Stop tiles along its track
+type Station struct {
+	Name string
+	Stop int
+}
+
+// Railroad is a single line of track that runs through each of its stations
+type Railroad struct {
+	Track    []Coordinates
+	Stations []Station
+}
+
+func (r Railroad) station(position int) bool {
+	for _, s := range r.Stations {
+		if s.Stop == position {
+			return true
+		}
+	}
+	return false
+}
+
+// Train runs back and forth along the railroad, waiting at every station it comes to.
+// The express car at the back carries a safe.
+type Train struct {
+	position  int
+	direction int
+	waiting   int
+	heldUp    bool
+	safe      *item.Item
+}
+
+func NewTrain() *Train {
+	return &Train{trainLength - 1, 1, stationStop, false, newExpressSafe()}
+}
+
+// The express safe is loaded with a fresh shipment of money and gold at the end of each line
+func newExpressSafe() *item.Item {
+	safe := item.NewNormalItem("express safe")
+	safe.TransferOwner("Railroad")
+	cc := safe.Component("container").(*item.ContainerComponent)
+	cc.Lock(int32(rand.Int() + 1))
+
+	contents := []*item.Item{item.Money(5000 + rand.Intn(15000))}
+	numOfBars := rand.Intn(3)
+	for i := 0; i < numOfBars; i++ {
+		contents = append(contents, item.NewNormalItem("gold bar"))
+	}
+	for _, itm := range contents {
+		itm.TransferOwner("Railroad")
+		cc.Put(itm)
+	}
+	return safe
+}
+
+// Location along the track of each car, from the engine back
+func (t *Train) cars() []int {
+	cars := make([]int, trainLength)
+	for i := range cars {
+		cars[i] = t.position - t.direction*i
+	}
+	return cars
+}
+
+// Moves the train on by a tile, returning where it was stopped if something is in the way
+func (t *Train) advance(r Railroad, blocked func(Coordinates) bool) (Coordinates, bool) {
+	next := t.position + t.direction
+	if next < 0 || next >= len(r.Track) {
+		// At the end of the line the engine runs round to the other end of the train
+		t.position -= t.direction * (trainLength - 1)
+		t.direction = -t.direction
+		if len(t.safe.Component("container").(*item.ContainerComponent).GetItems()) == 0 {
+			t.safe = newExpressSafe()
+		}
+		return Coordinates{}, false
+	}
+
+	if blocked(r.Track[next]) {
+		return r.Track[next], true
+	}
+
+	t.position = next
+	if r.station(next) {
+		t.waiting = stationStop
+		t.heldUp = false
+	}
+	return Coordinates{}, false
+}
+
+func (t *Train) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	buffer.WriteString(fmt.Sprintf("\"Position\":%d,", t.position))
+	buffer.WriteString(fmt.Sprintf("\"Direction\":%d,", t.direction))
+	buffer.WriteString(fmt.Sprintf("\"Waiting\":%d,", t.waiting))
+
+	heldUpValue, err := json.Marshal(t.heldUp)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"HeldUp\":%s,", heldUpValue))
+
+	safeValue, err := json.Marshal(t.safe)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Safe\":%s", safeValue))
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (t *Train) UnmarshalJSON(data []byte) error {
+
+	type trainJson struct {
+		Position  int
+		Direction int
+		Waiting   int
+		HeldUp    bool
+		Safe      *item.Item
+	}
+
+	var v trainJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	t.position = v.Position
+	t.direction = v.Direction
+	t.waiting = v.Waiting
+	t.heldUp = v.HeldUp
+	t.safe = v.Safe
+	return nil
+}
+
+func (m Map) Train() *Train {
+	return m.train
+}
+
+func (m Map) hasRailroad() bool {
+	return len(m.railroad.Track) > 0
+}
+
+// UpdateTrain moves the train along the line, returning where guards jump down from the express car if the player holds it up
+func (m Map) UpdateTrain() []Coordinates {
+	if !m.hasRailroad() {
+		return nil
+	}
+
+	t := m.train
+	if t.waiting > 0 {
+		t.waiting--
+		return nil
+	}
+
+	for i := 0; i < trainSpeed; i++ {
+		location, blocked := t.advance(m.railroad, func(c Coordinates) bool {
+			return m.surfaceActive(c.X, c.Y) && m.IsOccupied(c.X, c.Y)
+		})
+		if t.waiting > 0 {
+			return nil
+		}
+		if !blocked {
+			continue
+		}
+
+		if m.GetCreature(location.X, location.Y) == m.player && !t.heldUp {
+			t.heldUp = true
+			message.Enqueue("The train screeches to a halt in front of you!")
+			message.Enqueue("Guards jump down from the express car!")
+			return m.guardPositions()
+		}
+		return nil
+	}
+	return nil
+}
+
+// Free locations beside the express car for its guards
+func (m Map) guardPositions() []Coordinates {
+	cars := m.train.cars()
+	return m.freePositions(m.railroad.Track[cars[len(cars)-1]], expressGuards)
+}
+
+// Up to n free locations close by a point, nearest first
+func (m Map) freePositions(centre Coordinates, n int) []Coordinates {
+	positions := make([]Coordinates, 0, n)
+	for r := 1; r <= 3 && len(positions) < n; r++ {
+		for y := centre.Y - r; y <= centre.Y+r; y++ {
+			for x := centre.X - r; x <= centre.X+r; x++ {
+				if len(positions) == n {
+					return positions
+				}
+				if !m.IsValid(x, y) || !m.IsPassable(x, y) || m.IsOccupied(x, y) {
+					continue
+				}
+				taken := false
+				for _, p := range positions {
+					taken = taken || p == (Coordinates{x, y})
+				}
+				if !taken {
+					positions = append(positions, Coordinates{x, y})
+				}
+			}
+		}
+	}
+	return positions
+}
+
+// The car of the train at x, y, if there is one, where 0 is the engine
+func (m Map) trainCar(x, y int) (int, bool) {
+	if !m.hasRailroad() || m.Underground() {
+		return 0, false
+	}
+	for i, position := range m.train.cars() {
+		if position >= 0 && position < len(m.railroad.Track) && m.railroad.Track[position] == (Coordinates{x, y}) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (m Map) renderTrain(car int) ui.Element {
+	switch car {
+	case 0:
+		return engineIcon.Render()
+	case trainLength - 1:
+		return expressCarIcon.Render()
+	}
+	return carIcon.Render()
+}
+
+// The safe in the express car, if the express car is at x, y
+func (m Map) expressSafe(x, y int) *item.Item {
+	if car, ok := m.trainCar(x, y); ok && car == trainLength-1 {
+		return m.train.safe
+	}
+	return nil
+}
+
+// NearestStation is the index of the station closest to x, y, or -1 if there is no railroad
+func (m Map) NearestStation(x, y int) int {
+	nearest, nearestDistance := -1, 0.0
+	for i, s := range m.railroad.Stations {
+		stop := m.railroad.Track[s.Stop]
+		if d := Distance(x, y, stop.X, stop.Y); nearest == -1 || d < nearestDistance {
+			nearest, nearestDistance = i, d
+		}
+	}
+	return nearest
+}
+
+func (m Map) Stations() []Station {
+	return m.railroad.Stations
+}
+
+// Fare is the price of a ticket between two stations
+func (m Map) Fare(from, to int) int {
+	distance := m.railroad.Stations[to].Stop - m.railroad.Stations[from].Stop
+	if distance < 0 {
+		distance = -distance
+	}
+	return distance * fareRate
+}
+
+// InStation returns true if the train is standing at a station waiting for passengers
+func (m Map) InStation(station int) bool {
+	return m.hasRailroad() && m.train.waiting > 0 && m.train.position == m.railroad.Stations[station].Stop
+}
+
+// NextTrain is the number of turns until the train next stands at a station, according to the timetable
+func (m Map) NextTrain(station int) int {
+	t := *m.train
+	turns := 0
+	for ; t.waiting == 0 || t.position != m.railroad.Stations[station].Stop; turns++ {
+		if t.waiting > 0 {
+			t.waiting--
+			continue
+		}
+		for i := 0; i < trainSpeed && t.waiting == 0; i++ {
+			t.advance(m.railroad, func(Coordinates) bool { return false })
+		}
+	}
+	return turns
+}
+
+// RideTrain takes the player and the train from one station to another, leaving the player on the platform
+func (m *Map) RideTrain(from, to int) {
+	t := m.train
+	t.position = m.railroad.Stations[to].Stop
+	t.direction = 1
+	if m.railroad.Stations[to].Stop < m.railroad.Stations[from].Stop {
+		t.direction = -1
+	}
+	t.waiting = stationStop
+	t.heldUp = false
+
+	// The platform is just ahead of the engine
+	platform := t.position + t.direction
+	if platform < 0 || platform >= len(m.railroad.Track) {
+		platform = t.position - t.direction*trainLength
+	}
+	location := m.railroad.Track[platform]
+	m.TeleportPlayer(location.X, location.Y)
+}
@@ -0,0 +1,84 @@
+package worldmap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func straightRailroad(length int, stops ...int) Railroad {
+	track := make([]Coordinates, length)
+	for i := range track {
+		track[i] = Coordinates{i, 0}
+	}
+	stations := make([]Station, len(stops))
+	for i, stop := range stops {
+		stations[i] = Station{"Town", stop}
+	}
+	return Railroad{track, stations}
+}
+
+func notBlocked(Coordinates) bool {
+	return false
+}
+
+func TestTrainWaitsAtStations(t *testing.T) {
+	railroad := straightRailroad(20, 6)
+	train := &Train{5, 1, 0, true, nil}
+
+	train.advance(railroad, notBlocked)
+
+	if train.position != 6 {
+		t.Errorf("Expected the train to move on to 6 but was at %d", train.position)
+	}
+	if train.waiting != stationStop {
+		t.Errorf("Expected the train to wait %d turns at the station but was %d", stationStop, train.waiting)
+	}
+	if train.heldUp {
+		t.Error("Expected the train to no longer be held up once it reached a station")
+	}
+}
+
+func TestTrainStopsWhenBlocked(t *testing.T) {
+	railroad := straightRailroad(20)
+	train := &Train{5, 1, 0, false, nil}
+
+	location, blocked := train.advance(railroad, func(c Coordinates) bool { return c == Coordinates{6, 0} })
+
+	if !blocked || location != (Coordinates{6, 0}) {
+		t.Errorf("Expected the train to be blocked at 6, 0 but was blocked %t at %v", blocked, location)
+	}
+	if train.position != 5 {
+		t.Errorf("Expected the train to stay at 5 but was at %d", train.position)
+	}
+}
+
+func TestTrainCarsTrailEngine(t *testing.T) {
+	train := &Train{10, -1, 0, false, nil}
+	cars := train.cars()
+
+	if len(cars) != trainLength {
+		t.Fatalf("Expected %d cars but there were %d", trainLength, len(cars))
+	}
+	for i, position := range cars {
+		if position != 10+i {
+			t.Errorf("Expected car %d to be at %d but was at %d", i, 10+i, position)
+		}
+	}
+}
+
+func TestTrainMarshalling(t *testing.T) {
+	train := &Train{7, -1, 12, true, nil}
+
+	data, err := json.Marshal(train)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmarshalled := &Train{}
+	if err := json.Unmarshal(data, unmarshalled); err != nil {
+		t.Fatal(err)
+	}
+
+	if *unmarshalled != *train {
+		t.Errorf("Expected %v but got %v", *train, *unmarshalled)
+	}
+}
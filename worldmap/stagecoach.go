@@ -0,0 +1,485 @@
+package worldmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"github.com/onorton/cowboysindians/icon"
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+	"github.com/onorton/cowboysindians/ui"
+)
+
+// Number of tiles a stagecoach covers, its team of horses ahead of the coach
+const stagecoachLength = 3
+
+// Turns a stagecoach waits in town before setting off again
+const stagecoachStop = 50
+
+// Cost of a seat in cents for each tile of road travelled
+const stagecoachFareRate = 3
+
+// Chance each turn of a coach out on the road, out of sight, being held up by bandits
+const banditAttackChance = 0.0005
+
+// Size of the gang that holds up a coach, and most passengers a coach carries
+const minGang = 2
+const maxGang = 4
+const maxPassengers = 2
+
+var teamIcon = icon.NewIcon(104, 4)
+var stagecoachIcon = icon.NewIcon(67, 4)
+
+// Stagecoach runs back and forth along its stage line, a road between two towns, carrying a strongbox.
+// Its team and crew are brought out the first time it comes into view and stay with it until it is stopped.
+type Stagecoach struct {
+	line      int
+	position  int
+	direction int
+	waiting   int
+	heldUp    bool
+	strongbox *item.Item
+	// Ids of the horses in harness, and of the driver followed by everyone riding inside
+	team []string
+	crew []string
+}
+
+// HoldUp is a stagecoach being stopped on the road, by the player or by bandits
+type HoldUp struct {
+	Destination string
+	Location    Coordinates
+	ByBandits   bool
+	Stolen      int
+	// Where the gang is found
+	Positions []Coordinates
+}
+
+// Crewing is a coach that has come into view without its team and crew
+type Crewing struct {
+	Team       []Coordinates
+	Coach      Coordinates
+	Passengers int
+	stagecoach *Stagecoach
+}
+
+func NewStagecoach(line int) *Stagecoach {
+	return &Stagecoach{line, stagecoachLength - 1, 1, stagecoachStop, false, newStagecoachStrongbox(), nil, nil}
+}
+
+// Each coach sets off with a strongbox of mail and money
+func newStagecoachStrongbox() *item.Item {
+	strongbox := item.NewNormalItem("strongbox")
+	strongbox.TransferOwner("Stage line")
+	cc := strongbox.Component("container").(*item.ContainerComponent)
+	cc.Lock(int32(rand.Int() + 1))
+	money := item.Money(2000 + rand.Intn(8000))
+	money.TransferOwner("Stage line")
+	cc.Put(money)
+	return strongbox
+}
+
+// Location along the road of the team then the coach
+func (s *Stagecoach) tiles() []int {
+	tiles := make([]int, stagecoachLength)
+	for i := range tiles {
+		tiles[i] = s.position - s.direction*i
+	}
+	return tiles
+}
+
+// Moves the coach on by a tile, returning where it was stopped if something is in the way
+func (s *Stagecoach) advance(r Road, blocked func(Coordinates) bool) (Coordinates, bool) {
+	next := s.position + s.direction
+	if next < 0 || next >= len(r.Tiles) {
+		// The team is turned round in town, where the coach waits for passengers
+		s.position -= s.direction * (stagecoachLength - 1)
+		s.direction = -s.direction
+		s.waiting = stagecoachStop
+		if len(s.strongbox.Component("container").(*item.ContainerComponent).GetItems()) == 0 {
+			s.strongbox = newStagecoachStrongbox()
+		}
+		return Coordinates{}, false
+	}
+
+	if blocked(r.Tiles[next]) {
+		return r.Tiles[next], true
+	}
+	s.position = next
+	return Coordinates{}, false
+}
+
+// Where the coach is bound for
+func (s *Stagecoach) destination(r Road) string {
+	if s.direction > 0 {
+		return r.To
+	}
+	return r.From
+}
+
+// Takes everything out of the strongbox, returning what it was worth
+func (s *Stagecoach) emptyStrongbox() int {
+	cc := s.strongbox.Component("container").(*item.ContainerComponent)
+	stolen := 0
+	for len(cc.GetItems()) > 0 {
+		for selection := range cc.GetItems() {
+			stolen += cc.Take(selection).GetValue()
+			break
+		}
+	}
+	return stolen
+}
+
+func (s *Stagecoach) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	buffer.WriteString(fmt.Sprintf("\"Line\":%d,", s.line))
+	buffer.WriteString(fmt.Sprintf("\"Position\":%d,", s.position))
+	buffer.WriteString(fmt.Sprintf("\"Direction\":%d,", s.direction))
+	buffer.WriteString(fmt.Sprintf("\"Waiting\":%d,", s.waiting))
+
+	heldUpValue, err := json.Marshal(s.heldUp)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"HeldUp\":%s,", heldUpValue))
+
+	strongboxValue, err := json.Marshal(s.strongbox)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Strongbox\":%s,", strongboxValue))
+
+	teamValue, err := json.Marshal(s.team)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Team\":%s,", teamValue))
+
+	crewValue, err := json.Marshal(s.crew)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Crew\":%s", crewValue))
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (s *Stagecoach) UnmarshalJSON(data []byte) error {
+
+	type stagecoachJson struct {
+		Line      int
+		Position  int
+		Direction int
+		Waiting   int
+		HeldUp    bool
+		Strongbox *item.Item
+		Team      []string
+		Crew      []string
+	}
+
+	var v stagecoachJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	s.line = v.Line
+	s.position = v.Position
+	s.direction = v.Direction
+	s.waiting = v.Waiting
+	s.heldUp = v.HeldUp
+	s.strongbox = v.Strongbox
+	s.team = v.Team
+	s.crew = v.Crew
+	return nil
+}
+
+// The road a coach's stage line runs along
+func (m Map) stageRoad(s *Stagecoach) Road {
+	return m.roads[m.stageLines[s.line]]
+}
+
+func (m Map) Stagecoaches() []*Stagecoach {
+	return m.stagecoaches
+}
+
+// UpdateStagecoaches drives each coach along its road, returning any that were held up this turn
+func (m Map) UpdateStagecoaches() []HoldUp {
+	holdUps := make([]HoldUp, 0)
+	for _, s := range m.stagecoaches {
+		r := m.stageRoad(s)
+		team := r.Tiles[s.position]
+		inView := m.surfaceActive(team.X, team.Y)
+
+		if s.heldUp {
+			// Once out of sight, the company sends another coach out
+			if !inView {
+				*s = *NewStagecoach(s.line)
+			}
+			continue
+		}
+
+		if s.waiting > 0 {
+			s.waiting--
+			continue
+		}
+
+		if !inView && rand.Float64() < banditAttackChance {
+			holdUps = append(holdUps, m.banditHoldUp(s, r))
+		}
+
+		location, blocked := s.advance(r, func(c Coordinates) bool {
+			return m.surfaceActive(c.X, c.Y) && m.IsOccupied(c.X, c.Y)
+		})
+		m.seat(s)
+		// Out on the open road, anyone standing in its way is taken for a road agent
+		if blocked && m.GetCreature(location.X, location.Y) == m.player && !m.inTown(location.X, location.Y) {
+			holdUps = append(holdUps, m.playerHoldUp(s, r))
+		}
+	}
+	return holdUps
+}
+
+// UncrewedStagecoaches returns the coaches in view that still need their team and crew bringing out
+func (m Map) UncrewedStagecoaches() []Crewing {
+	crewings := make([]Crewing, 0)
+	for _, s := range m.stagecoaches {
+		r := m.stageRoad(s)
+		tiles := s.tiles()
+		coach := r.Tiles[tiles[stagecoachLength-1]]
+		if s.heldUp || len(s.team) > 0 || !m.surfaceActive(coach.X, coach.Y) {
+			continue
+		}
+		team := make([]Coordinates, stagecoachLength-1)
+		for i := range team {
+			team[i] = r.Tiles[tiles[i]]
+		}
+		crewings = append(crewings, Crewing{team, coach, rand.Intn(maxPassengers + 1), s})
+	}
+	return crewings
+}
+
+// Board puts the team in harness and the driver and passengers on a coach
+func (m *Map) Board(crewing Crewing, team, crew []Creature) {
+	s := crewing.stagecoach
+	for _, c := range append(team, crew...) {
+		m.creatures = append(m.creatures, c)
+		c.SetMap(m)
+	}
+	s.team = creatureIds(team)
+	s.crew = creatureIds(crew)
+	m.seat(s)
+}
+
+func creatureIds(creatures []Creature) []string {
+	ids := make([]string, len(creatures))
+	for i, c := range creatures {
+		ids[i] = c.GetID()
+	}
+	return ids
+}
+
+// The team and crew of a coach still with it
+func (m Map) aboard(ids []string) []Creature {
+	creatures := make([]Creature, 0, len(ids))
+	for _, id := range ids {
+		if c := m.CreatureById(id); c != nil && !c.IsDead() {
+			creatures = append(creatures, c)
+		}
+	}
+	return creatures
+}
+
+// Keeps the team in harness ahead of the coach, the driver up on it and everyone else inside as it moves
+func (m Map) seat(s *Stagecoach) {
+	r := m.stageRoad(s)
+	tiles := s.tiles()
+	team, crew := m.aboard(s.team), m.aboard(s.crew)
+
+	// Everyone leaves their place first, as the team swaps ends when the coach turns round
+	for _, c := range append(team, crew...) {
+		m.unplace(c)
+	}
+	for i, c := range team {
+		tile := r.Tiles[tiles[i]]
+		m.place(c, tile.X, tile.Y)
+	}
+	coach := r.Tiles[tiles[stagecoachLength-1]]
+	for i, c := range crew {
+		if i == 0 {
+			m.place(c, coach.X, coach.Y)
+		} else {
+			c.SetCoordinates(coach.X, coach.Y)
+		}
+	}
+}
+
+// Puts a creature at x, y even if it can't walk there, such as a horse in harness
+func (m Map) place(c Creature, x, y int) {
+	c.SetCoordinates(x, y)
+	if c.GetDepth() != m.Depth() {
+		return
+	}
+	if chunk, cX, cY := m.globalToChunkAndLocal(x, y); chunk != nil {
+		chunk.c[cY][cX] = c
+	}
+}
+
+// Takes a creature off the map, leaving anyone else who has taken its place
+func (m Map) unplace(c Creature) {
+	if c.GetDepth() != m.Depth() {
+		return
+	}
+	x, y := c.GetCoordinates()
+	if chunk, cX, cY := m.globalToChunkAndLocal(x, y); chunk != nil && chunk.c[cY][cX] == c {
+		chunk.c[cY][cX] = nil
+	}
+}
+
+// OnStagecoach returns true if a creature is in harness or aboard a coach, going wherever it takes them
+func (m Map) OnStagecoach(c Creature) bool {
+	return m.carrying(c) != nil
+}
+
+// The coach a creature is in harness or aboard, if any
+func (m Map) carrying(c Creature) *Stagecoach {
+	for _, s := range m.stagecoaches {
+		for _, id := range append(append([]string{}, s.team...), s.crew...) {
+			if id == c.GetID() {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// StopStagecoach pulls up the coach a creature is on when it is attacked, so that everyone on board can defend themselves
+func (m Map) StopStagecoach(c Creature) {
+	if s := m.carrying(c); s != nil {
+		s.heldUp = true
+		m.disembark(s)
+	}
+}
+
+// Everyone climbs down from the coach and the team is left standing in harness, no longer going anywhere
+func (m Map) disembark(s *Stagecoach) {
+	crew := m.aboard(s.crew)
+	coach := m.stageRoad(s).Tiles[s.tiles()[stagecoachLength-1]]
+	positions := m.freePositions(coach, len(crew))
+	for i, c := range crew {
+		m.unplace(c)
+		if i < len(positions) {
+			m.place(c, positions[i].X, positions[i].Y)
+		}
+	}
+	s.team = nil
+	s.crew = nil
+}
+
+// The coach stops, and everyone on board climbs down to defend it
+func (m Map) playerHoldUp(s *Stagecoach, r Road) HoldUp {
+	s.heldUp = true
+	message.Enqueue("The stagecoach pulls up in front of you!")
+	message.Enqueue("The driver reaches for his gun as the shotgun messenger jumps down.")
+	m.disembark(s)
+
+	coach := r.Tiles[s.tiles()[stagecoachLength-1]]
+	return HoldUp{s.destination(r), coach, false, 0, nil}
+}
+
+// Bandits rob the coach and make off along the road behind it
+func (m Map) banditHoldUp(s *Stagecoach, r Road) HoldUp {
+	gang := minGang + rand.Intn(maxGang-minGang+1)
+	positions := make([]Coordinates, 0, gang)
+	for i := 0; i < gang; i++ {
+		behind := s.position - s.direction*(stagecoachLength+i)
+		if behind >= 0 && behind < len(r.Tiles) {
+			positions = append(positions, r.Tiles[behind])
+		}
+	}
+	return HoldUp{s.destination(r), r.Tiles[s.position], true, s.emptyStrongbox(), positions}
+}
+
+// The coach at x, y and which part of it is there, where the team comes before the coach
+func (m Map) stagecoachAt(x, y int) (*Stagecoach, int, bool) {
+	if m.Underground() {
+		return nil, 0, false
+	}
+	for _, s := range m.stagecoaches {
+		road := m.stageRoad(s).Tiles
+		for i, position := range s.tiles() {
+			// A coach that has been held up is no longer pulled by its team
+			if s.heldUp && i < stagecoachLength-1 {
+				continue
+			}
+			if position >= 0 && position < len(road) && road[position] == (Coordinates{x, y}) {
+				return s, i, true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+func (m Map) renderStagecoach(part int) ui.Element {
+	if part < stagecoachLength-1 {
+		return teamIcon.Render()
+	}
+	return stagecoachIcon.Render()
+}
+
+type hasIcon interface {
+	GetIcon() icon.Icon
+}
+
+// The driver sitting up on the coach
+func (m Map) renderDriver(c Creature) ui.Element {
+	if driver, ok := c.(hasIcon); ok {
+		return icon.MergeIcons(driver.GetIcon(), stagecoachIcon)
+	}
+	return c.Render()
+}
+
+// The strongbox on the coach at x, y, if there is one
+func (m Map) stagecoachStrongbox(x, y int) *item.Item {
+	if s, part, ok := m.stagecoachAt(x, y); ok && part == stagecoachLength-1 {
+		return s.strongbox
+	}
+	return nil
+}
+
+// BoardingStagecoach returns the town a coach at x, y is bound for and its fare, if it is waiting for passengers
+func (m Map) BoardingStagecoach(x, y int) (string, int, bool) {
+	s, _, ok := m.stagecoachAt(x, y)
+	if !ok || s.heldUp || s.waiting == 0 {
+		return "", 0, false
+	}
+	r := m.stageRoad(s)
+	return s.destination(r), len(r.Tiles) * stagecoachFareRate, true
+}
+
+// RideStagecoach takes the player and the coach at x, y to the other end of the line, leaving the player beside it
+func (m *Map) RideStagecoach(x, y int) {
+	s, _, ok := m.stagecoachAt(x, y)
+	if !ok {
+		return
+	}
+	r := m.stageRoad(s)
+	if s.direction > 0 {
+		s.position = len(r.Tiles) - stagecoachLength
+		s.direction = -1
+	} else {
+		s.position = stagecoachLength - 1
+		s.direction = 1
+	}
+	s.waiting = stagecoachStop
+
+	coach := r.Tiles[s.tiles()[stagecoachLength-1]]
+	m.TeleportPlayer(coach.X, coach.Y)
+	// Step down from the coach once the chunks around it are loaded, and the driver takes his seat again
+	if positions := m.freePositions(coach, 1); len(positions) > 0 {
+		m.Move(m.player, positions[0].X, positions[0].Y)
+	}
+	m.seat(s)
+}
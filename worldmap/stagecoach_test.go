@@ -0,0 +1,155 @@
+package worldmap
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func straightRoad(length int) Road {
+	road := make([]Coordinates, length)
+	for i := range road {
+		road[i] = Coordinates{0, i}
+	}
+	return Road{"Tombstone", "Dodge", road}
+}
+
+func TestStagecoachTeamLeadsCoach(t *testing.T) {
+	stagecoach := &Stagecoach{0, 4, -1, 0, false, nil, nil, nil}
+	tiles := stagecoach.tiles()
+
+	if len(tiles) != stagecoachLength || tiles[0] != 4 || tiles[1] != 5 || tiles[2] != 6 {
+		t.Errorf("Expected the team at 4 and 5 and the coach at 6 but was %v", tiles)
+	}
+}
+
+func TestStagecoachStopsWhenBlocked(t *testing.T) {
+	road := straightRoad(10)
+	stagecoach := &Stagecoach{0, 3, 1, 0, false, nil, nil, nil}
+
+	location, blocked := stagecoach.advance(road, func(c Coordinates) bool { return c == Coordinates{0, 4} })
+
+	if !blocked || location != (Coordinates{0, 4}) {
+		t.Errorf("Expected the coach to be blocked at 0, 4 but was blocked %t at %v", blocked, location)
+	}
+	if stagecoach.position != 3 {
+		t.Errorf("Expected the coach to stay at 3 but was at %d", stagecoach.position)
+	}
+}
+
+func TestStagecoachBoundForEndOfLine(t *testing.T) {
+	road := straightRoad(10)
+
+	if destination := (&Stagecoach{0, 3, 1, 0, false, nil, nil, nil}).destination(road); destination != "Dodge" {
+		t.Errorf("Expected a coach heading down the road to be bound for Dodge but was bound for %s", destination)
+	}
+	if destination := (&Stagecoach{0, 3, -1, 0, false, nil, nil, nil}).destination(road); destination != "Tombstone" {
+		t.Errorf("Expected a coach heading back up the road to be bound for Tombstone but was bound for %s", destination)
+	}
+}
+
+func TestStagecoachMarshalling(t *testing.T) {
+	stagecoach := &Stagecoach{2, 17, -1, 8, true, nil, []string{"lead", "wheeler"}, []string{"driver", "passenger"}}
+
+	data, err := json.Marshal(stagecoach)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmarshalled := &Stagecoach{}
+	if err := json.Unmarshal(data, unmarshalled); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(unmarshalled, stagecoach) {
+		t.Errorf("Expected %v but got %v", *stagecoach, *unmarshalled)
+	}
+}
+
+type crewMember struct {
+	testCreature
+	id string
+}
+
+func (c *crewMember) GetID() string { return c.id }
+
+// A coach setting off along a road across the player's chunk, with its team and crew on board
+func crewedStagecoachTestMap() (Map, []Creature, []Creature) {
+	m := lightTestMap(0)
+	m.roads = []Road{horizontalRoad("Tombstone", "Dodge", 20, 40, 30)}
+	m.stageLines = []int{0}
+	m.stagecoaches = []*Stagecoach{{0, stagecoachLength - 1, 1, 0, false, nil, nil, nil}}
+
+	team := []Creature{&crewMember{id: "lead"}, &crewMember{id: "wheeler"}}
+	crew := []Creature{&crewMember{id: "driver"}, &crewMember{id: "passenger"}}
+	crewing := m.UncrewedStagecoaches()
+	if len(crewing) == 1 {
+		m.Board(crewing[0], team, crew)
+	}
+	return m, team, crew
+}
+
+func TestStagecoachCarriesTeamAndCrew(t *testing.T) {
+	m, team, crew := crewedStagecoachTestMap()
+	m.UpdateStagecoaches()
+
+	// The lead horse has moved on to the fourth tile of the road, with the wheeler and then the coach behind it
+	for i, c := range team {
+		if x, y := c.GetCoordinates(); m.GetCreature(x, y) != c || x != 23-i {
+			t.Errorf("Expected %s in harness at %d, 30 but was at %d, %d", c.GetID(), 23-i, x, y)
+		}
+	}
+	if x, y := crew[0].GetCoordinates(); m.GetCreature(x, y) != crew[0] || x != 21 {
+		t.Errorf("Expected the driver up on the coach at 21, 30 but was at %d, %d", x, y)
+	}
+	if x, y := crew[1].GetCoordinates(); x != 21 || y != 30 {
+		t.Errorf("Expected the passenger inside the coach at 21, 30 but was at %d, %d", x, y)
+	}
+	for _, c := range append(team, crew...) {
+		if !m.OnStagecoach(c) {
+			t.Errorf("Expected %s to be on the stagecoach", c.GetID())
+		}
+	}
+	if len(m.UncrewedStagecoaches()) != 0 {
+		t.Error("Expected a coach with a crew not to need another")
+	}
+}
+
+func TestStagecoachTurnsRoundWithItsTeam(t *testing.T) {
+	m, team, crew := crewedStagecoachTestMap()
+	s := m.stagecoaches[0]
+	// Arriving at the end of the road, with the lead horse on the last tile, before turning round
+	s.position = len(m.roads[0].Tiles) - 1
+	m.seat(s)
+	s.position -= stagecoachLength - 1
+	s.direction = -1
+	m.seat(s)
+
+	// The team is led round to the other end of the coach
+	for i, c := range team {
+		if x, y := c.GetCoordinates(); m.GetCreature(x, y) != c || x != 38+i {
+			t.Errorf("Expected %s in harness at %d, 30 but was at %d, %d", c.GetID(), 38+i, x, y)
+		}
+	}
+	if x, y := crew[0].GetCoordinates(); m.GetCreature(x, y) != crew[0] || x != 40 {
+		t.Errorf("Expected the driver up on the coach at 40, 30 but was at %d, %d", x, y)
+	}
+}
+
+func TestAttackedStagecoachStopsAndEveryoneClimbsDown(t *testing.T) {
+	m, team, crew := crewedStagecoachTestMap()
+	m.StopStagecoach(team[0])
+
+	for _, c := range crew {
+		if x, y := c.GetCoordinates(); m.GetCreature(x, y) != c || !m.IsPassable(x, y) {
+			t.Errorf("Expected %s to climb down beside the coach but was at %d, %d", c.GetID(), x, y)
+		}
+	}
+	for _, c := range append(team, crew...) {
+		if m.OnStagecoach(c) {
+			t.Errorf("Expected %s to be free of the stagecoach", c.GetID())
+		}
+	}
+	if x, y := team[0].GetCoordinates(); !m.IsPassable(x, y) {
+		t.Error("Expected the team to be left standing on the open road")
+	}
+}
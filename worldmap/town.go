@@ -7,6 +7,8 @@ type Town struct {
 	Horizontal bool
 	Farm       bool
 	Buildings  []Building
+	// How much more shops charge and pay than usual, higher in towns far from others
+	Markup float64
 }
 
 func NewTown(name string, x1, y1, x2, y2, sX1, sY1, sX2, sY2 int, horizontal, farm bool) *Town {
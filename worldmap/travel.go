@@ -0,0 +1,211 @@
+package worldmap
+
+import (
+	"math/rand"
+)
+
+// Chance of running into someone on each tile of a journey
+const encounterChance = 0.003
+
+// How far from the player those they run into on the road turn up
+const minEncounterDistance = 5
+const maxEncounterDistance = 9
+
+// How far out from the trader a wagon's sides are
+const wagonSize = 2
+
+// Road is a path between two towns, tile by tile from one to the other
+type Road struct {
+	From  string
+	To    string
+	Tiles []Coordinates
+}
+
+// Encounter is who the player runs into on the road while travelling
+type Encounter int
+
+const (
+	NoEncounter Encounter = iota
+	Bandits
+	Wildlife
+	Trader
+)
+
+func (m Map) townAt(x, y int) (Town, bool) {
+	for _, t := range m.towns {
+		if x >= t.TownArea.X1() && x <= t.TownArea.X2() && y >= t.TownArea.Y1() && y <= t.TownArea.Y2() {
+			return t, true
+		}
+	}
+	return Town{}, false
+}
+
+func (m Map) inTown(x, y int) bool {
+	_, ok := m.townAt(x, y)
+	return ok
+}
+
+// Discovered returns true if the player has seen a town for themselves
+func (m Map) Discovered(t Town) bool {
+	return m.overview.isExplored((t.TownArea.X1()+t.TownArea.X2())/2, (t.TownArea.Y1()+t.TownArea.Y2())/2)
+}
+
+// Journey is the shortest way along the roads from the town the player is in to another, tile by tile
+func (m Map) Journey(destination Town) ([]Coordinates, bool) {
+	pX, pY := m.player.GetCoordinates()
+	start, ok := m.townAt(pX, pY)
+	if !ok || start.Name == destination.Name {
+		return nil, false
+	}
+
+	// Dijkstra's algorithm over the towns, remembering the road taken into each
+	distances := map[string]int{start.Name: 0}
+	via := map[string]int{}
+	visited := map[string]bool{}
+	for {
+		current, found := "", false
+		for name, d := range distances {
+			if !visited[name] && (!found || d < distances[current]) {
+				current, found = name, true
+			}
+		}
+		if !found {
+			return nil, false
+		}
+		if current == destination.Name {
+			break
+		}
+		visited[current] = true
+
+		for i, r := range m.roads {
+			next := ""
+			if r.From == current {
+				next = r.To
+			} else if r.To == current {
+				next = r.From
+			} else {
+				continue
+			}
+			if d, ok := distances[next]; !ok || distances[current]+len(r.Tiles) < d {
+				distances[next] = distances[current] + len(r.Tiles)
+				via[next] = i
+			}
+		}
+	}
+
+	// Work back from the destination, laying each road end to end
+	legs := make([][]Coordinates, 0)
+	for current := destination.Name; current != start.Name; {
+		r := m.roads[via[current]]
+		leg := make([]Coordinates, len(r.Tiles))
+		copy(leg, r.Tiles)
+		if r.From == current {
+			for i, j := 0, len(leg)-1; i < j; i, j = i+1, j-1 {
+				leg[i], leg[j] = leg[j], leg[i]
+			}
+			current = r.To
+		} else {
+			current = r.From
+		}
+		legs = append([][]Coordinates{leg}, legs...)
+	}
+
+	route := make([]Coordinates, 0)
+	for _, leg := range legs {
+		route = append(route, leg...)
+	}
+	return route, true
+}
+
+// Moves the player straight to anywhere in the world, loading the chunks around where they end up and centring the view on them
+func (m *Map) TeleportPlayer(x, y int) {
+	oldX, oldY := m.player.GetCoordinates()
+	chunk, cX, cY := m.globalToChunkAndLocal(oldX, oldY)
+	chunk.c[cY][cX] = nil
+
+	m.SaveChunks()
+	m.player.SetCoordinates(x, y)
+	m.LoadActiveChunks()
+	m.ResizeViewer(m.v.width, m.v.height)
+}
+
+// RandomEncounter returns who the player runs into on a tile of their journey, if anyone
+func (m Map) RandomEncounter() Encounter {
+	if rand.Float64() >= encounterChance {
+		return NoEncounter
+	}
+	return Encounter(1 + rand.Intn(3))
+}
+
+// EncounterPositions picks up to n free locations a little way off from the player for those they run into
+func (m Map) EncounterPositions(n int) []Coordinates {
+	pX, pY := m.player.GetCoordinates()
+	positions := make([]Coordinates, 0, n)
+	for attempts := 0; attempts < 50*n && len(positions) < n; attempts++ {
+		d := minEncounterDistance + rand.Intn(maxEncounterDistance-minEncounterDistance+1)
+		x, y := pX+rand.Intn(2*d+1)-d, pY+rand.Intn(2*d+1)-d
+		if Distance(pX, pY, x, y) < minEncounterDistance || !m.IsValid(x, y) || !m.IsPassable(x, y) || m.IsOccupied(x, y) {
+			continue
+		}
+		taken := false
+		for _, p := range positions {
+			taken = taken || p == (Coordinates{x, y})
+		}
+		if !taken {
+			positions = append(positions, Coordinates{x, y})
+		}
+	}
+	return positions
+}
+
+// EnemiesInSight returns true if the player can see anyone hostile
+func (m Map) EnemiesInSight() bool {
+	for _, c := range m.creatures {
+		x, y := c.GetCoordinates()
+		if c.GetAlignment() == Enemy && !c.IsDead() && m.IsActive(c) && m.IsVisible(m.player, x, y) {
+			return true
+		}
+	}
+	return false
+}
+
+// PitchWagon sets up a trader's wagon around x, y, walled in like any other building with its door facing the player,
+// returning false if anything is in the way
+func (m Map) PitchWagon(x, y int) (Building, bool) {
+	wagon := NewBuilding(x-wagonSize, y-wagonSize, x+wagonSize, y+wagonSize, Wagon)
+	x1, y1, x2, y2 := wagon.Area.X1(), wagon.Area.Y1(), wagon.Area.X2(), wagon.Area.Y2()
+	for wY := y1; wY <= y2; wY++ {
+		for wX := x1; wX <= x2; wX++ {
+			if !m.IsValid(wX, wY) || !m.IsPassable(wX, wY) || m.IsOccupied(wX, wY) {
+				return Building{}, false
+			}
+		}
+	}
+
+	for wY := y1; wY <= y2; wY++ {
+		for wX := x1; wX <= x2; wX++ {
+			chunk, cX, cY := m.globalToChunkAndLocal(wX, wY)
+			if wX == x1 || wX == x2 || wY == y1 || wY == y2 {
+				chunk.newTile("wall", cX, cY)
+			} else {
+				chunk.newTile("ground", cX, cY)
+			}
+		}
+	}
+
+	pX, pY := m.player.GetCoordinates()
+	dx, dy := pX-x, pY-y
+	door := Coordinates{x, y1}
+	switch {
+	case dx*dx > dy*dy && dx > 0:
+		door = Coordinates{x2, y}
+	case dx*dx > dy*dy:
+		door = Coordinates{x1, y}
+	case dy > 0:
+		door = Coordinates{x, y2}
+	}
+	chunk, cX, cY := m.globalToChunkAndLocal(door.X, door.Y)
+	chunk.newTile("door", cX, cY)
+	wagon.DoorLocation = &door
+	return wagon, true
+}
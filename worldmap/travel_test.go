@@ -0,0 +1,84 @@
+package worldmap
+
+import (
+	"testing"
+)
+
+func horizontalRoad(from, to string, x1, x2, y int) Road {
+	step := 1
+	if x2 < x1 {
+		step = -1
+	}
+	tiles := make([]Coordinates, 0)
+	for x := x1; x != x2+step; x += step {
+		tiles = append(tiles, Coordinates{x, y})
+	}
+	return Road{from, to, tiles}
+}
+
+func journeyTestMap() Map {
+	towns := []Town{*NewTown("Tombstone", 0, 0, 4, 4, 0, 2, 4, 2, true, false), *NewTown("Dodge", 10, 0, 14, 4, 10, 2, 14, 2, true, false), *NewTown("Abilene", 20, 0, 24, 4, 20, 2, 24, 2, true, false)}
+	// Abilene to Dodge is laid down the other way round, so has to be travelled backwards
+	roads := []Road{horizontalRoad("Tombstone", "Dodge", 4, 10, 2), horizontalRoad("Abilene", "Dodge", 20, 14, 2)}
+	return Map{roads: roads, towns: towns, player: &testCreature{2, 2}}
+}
+
+func TestJourneyAlongRoads(t *testing.T) {
+	m := journeyTestMap()
+
+	route, ok := m.Journey(m.towns[2])
+	if !ok {
+		t.Fatal("Expected a route from Tombstone to Abilene but there was none")
+	}
+	if route[0] != (Coordinates{4, 2}) || route[len(route)-1] != (Coordinates{20, 2}) {
+		t.Errorf("Expected the route to run from 4, 2 to 20, 2 but ran from %v to %v", route[0], route[len(route)-1])
+	}
+}
+
+func TestJourneyNeedsRoad(t *testing.T) {
+	m := journeyTestMap()
+	m.roads = m.roads[:1]
+
+	if _, ok := m.Journey(m.towns[2]); ok {
+		t.Error("Expected no route to a town with no road to it")
+	}
+}
+
+func TestJourneyStartsInTown(t *testing.T) {
+	m := journeyTestMap()
+	m.player = &testCreature{7, 8}
+
+	if _, ok := m.Journey(m.towns[1]); ok {
+		t.Error("Expected no route when the player is not in a town")
+	}
+}
+
+func TestWagonIsWalledInWithDoorFacingPlayer(t *testing.T) {
+	m := lightTestMap(1)
+
+	wagon, ok := m.PitchWagon(32, 40)
+	if !ok {
+		t.Fatal("Expected a wagon to be pitched on open ground")
+	}
+	if wagon.DoorLocation == nil || *wagon.DoorLocation != (Coordinates{32, 38}) || !m.IsDoor(32, 38) {
+		t.Errorf("Expected the wagon's door at 32, 38 facing the player but was %v", wagon.DoorLocation)
+	}
+	if m.IsPassable(30, 38) || m.IsPassable(34, 42) {
+		t.Error("Expected the wagon to be walled in")
+	}
+	if !m.IsPassable(32, 40) || !wagon.Inside(32, 40) {
+		t.Error("Expected room inside the wagon for the trader")
+	}
+}
+
+func TestWagonNeedsRoom(t *testing.T) {
+	m := lightTestMap(1)
+	m.Move(&testCreature{33, 41}, 33, 41)
+
+	if _, ok := m.PitchWagon(32, 40); ok {
+		t.Error("Expected no wagon to be pitched where someone is standing")
+	}
+	if !m.IsPassable(30, 38) {
+		t.Error("Expected nothing to be built when there is no room")
+	}
+}
@@ -0,0 +1,187 @@
+package worldmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Surface is the depth of the world above ground, with each level below it one deeper
+const Surface = 0
+
+// LevelSize is how many tiles across every level underground is, being the size of the chunk above it
+const LevelSize = chunkSize
+
+// How far anyone can see in the pitch dark without a light, which is only what is right next to them
+const darkVisionDistance = 1
+
+// Lines of the world file between the last chunk of the surface and the first level underground
+const levelsHeader = 2
+
+type UndergroundType int
+
+const (
+	Mine UndergroundType = iota
+	Cave
+)
+
+func (t UndergroundType) String() string {
+	return [...]string{"Mine", "Cave"}[t]
+}
+
+// Underground is a mine or cave reached by stairs from the surface, each of its levels
+// lying beneath the chunk of its entrance
+type Underground struct {
+	Name     string
+	T        UndergroundType
+	Entrance Coordinates
+	Depth    int
+	// Where its first level is among all the levels in the world file
+	FirstLevel int
+	Hideout    bool
+}
+
+// NewLevel creates a level underground of solid rock, addressed by coordinates within its chunk
+func NewLevel() World {
+	level := NewWorld(LevelSize, LevelSize)
+	for y := 0; y < LevelSize; y++ {
+		for x := 0; x < LevelSize; x++ {
+			level.NewTile("rock wall", x, y)
+		}
+	}
+	return level
+}
+
+// LevelOrigin is where in the world the chunk of an underground level starts
+func LevelOrigin(entrance Coordinates) Coordinates {
+	chunk := globalToChunkCoordinates(entrance.X, entrance.Y)
+	return Coordinates{chunk.ChunkX * chunkSize, chunk.ChunkY * chunkSize}
+}
+
+// SameChunk returns true if two locations lie in the same chunk, and so would share the levels beneath it
+func SameChunk(a, b Coordinates) bool {
+	chunkA, chunkB := globalToChunkCoordinates(a.X, a.Y), globalToChunkCoordinates(b.X, b.Y)
+	return chunkA.ChunkX == chunkB.ChunkX && chunkA.ChunkY == chunkB.ChunkY
+}
+
+// MarshalLevels writes out every level underground, one to a line after the surface
+func MarshalLevels(levels []World) ([]byte, error) {
+	buffer := bytes.NewBufferString("\"Levels\": [\n")
+	for i, level := range levels {
+		levelJson, err := json.Marshal(level[0][0])
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(levelJson)
+		if i < len(levels)-1 {
+			buffer.WriteString(",")
+		}
+		buffer.WriteString("\n")
+	}
+	buffer.WriteString("]\n")
+	return buffer.Bytes(), nil
+}
+
+// Depth is how many levels below ground the player is
+func (m Map) Depth() int {
+	return m.player.GetDepth()
+}
+
+func (m Map) Underground() bool {
+	return m.Depth() > Surface
+}
+
+// The mine or cave beneath the chunk of x, y, if there is one
+func (m Map) undergroundAt(x, y int) (Underground, bool) {
+	for _, u := range m.underground {
+		if SameChunk(u.Entrance, Coordinates{x, y}) {
+			return u, true
+		}
+	}
+	return Underground{}, false
+}
+
+// Line of the world file holding a level of a mine or cave
+func (m Map) levelLine(u Underground, depth int) int {
+	return m.verticalChunks()*m.horizontalChunks() + levelsHeader + u.FirstLevel + depth - 1
+}
+
+// IsActive returns true if a creature is on the same level as the player and close enough to be loaded
+func (m Map) IsActive(c Creature) bool {
+	x, y := c.GetCoordinates()
+	return c.GetDepth() == m.Depth() && m.InActiveChunks(x, y)
+}
+
+// Whether a location on the surface is loaded, which it never is while the player is underground
+func (m Map) surfaceActive(x, y int) bool {
+	return !m.Underground() && m.InActiveChunks(x, y)
+}
+
+// Stairs returns 1 if there are stairs leading down at x, y, -1 if they lead up, or 0 if there are none
+func (m Map) Stairs(x, y int) int {
+	if !m.IsValid(x, y) {
+		return 0
+	}
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	for _, terrain := range terrainData {
+		if terrain.Stairs != 0 && terrain.Icon == chunk.terrain[cY][cX] {
+			return terrain.Stairs
+		}
+	}
+	return 0
+}
+
+// ChangeDepth takes the player up or down the stairs they are standing on, onto the level above or below
+func (m *Map) ChangeDepth(direction int) {
+	pX, pY := m.player.GetCoordinates()
+	chunk, cX, cY := m.globalToChunkAndLocal(pX, pY)
+	chunk.c[cY][cX] = nil
+
+	m.SaveChunks()
+	m.player.SetDepth(m.Depth() + direction)
+	m.LoadActiveChunks()
+	m.AdjustViewer()
+}
+
+// UndergroundName is the name of the mine or cave the player is in, if they are underground
+func (m Map) UndergroundName() (string, bool) {
+	if !m.Underground() {
+		return "", false
+	}
+	pX, pY := m.player.GetCoordinates()
+	u, ok := m.undergroundAt(pX, pY)
+	return u.Name, ok
+}
+
+// DepthStatus describes where the player is underground for the status line, or returns an empty string on the surface
+func (m Map) DepthStatus() string {
+	name, ok := m.UndergroundName()
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s L%d", name, m.Depth())
+}
+
+// MineVein digs out an ore vein at x, y, returning the ore found if there was one
+func (m Map) MineVein(x, y int) (string, bool) {
+	if !m.IsValid(x, y) {
+		return "", false
+	}
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	for _, terrain := range terrainData {
+		if terrain.Ore != "" && terrain.Icon == chunk.terrain[cY][cX] {
+			chunk.newTile("rubble", cX, cY)
+			return terrain.Ore, true
+		}
+	}
+	return "", false
+}
+
+// How far c can see in the dark with whatever light it carries
+func (m Map) darkVision(c CanSee) int {
+	vision := darkVisionDistance
+	if light, ok := c.(LightSource); ok && light.LightRadius() > vision {
+		vision = light.LightRadius()
+	}
+	return vision
+}
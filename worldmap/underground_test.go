@@ -0,0 +1,56 @@
+package worldmap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLevelsAreWrittenOneToALine(t *testing.T) {
+	data, err := MarshalLevels([]World{NewLevel(), NewLevel()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header, 2 levels and a closing bracket but there were %d lines", len(lines))
+	}
+	if lines[0] != "\"Levels\": [" || lines[3] != "]" {
+		t.Errorf("Expected levels to be wrapped in a list but were wrapped in %s and %s", lines[0], lines[3])
+	}
+	if !strings.HasSuffix(lines[1], ",") || strings.HasSuffix(lines[2], ",") {
+		t.Error("Expected only the levels before the last to be followed by a comma")
+	}
+}
+
+func TestLevelOriginIsCornerOfChunk(t *testing.T) {
+	origin := LevelOrigin(Coordinates{70, 130})
+
+	if origin != (Coordinates{64, 128}) {
+		t.Errorf("Expected level to start at 64, 128 but started at %v", origin)
+	}
+	if !SameChunk(origin, Coordinates{127, 191}) || SameChunk(origin, Coordinates{128, 191}) {
+		t.Error("Expected level to cover only the chunk it starts in")
+	}
+}
+
+func TestMiningVeinLeavesRubble(t *testing.T) {
+	level := NewLevel()
+	level.NewTile("gold vein", 3, 2)
+	m := Map{width: LevelSize, height: LevelSize, player: &testCreature{2, 2}}
+	m.activeChunks[1][1] = level[0][0]
+
+	ore, ok := m.MineVein(3, 2)
+	if !ok || ore != "gold nugget" {
+		t.Fatalf("Expected to dig out a gold nugget but dug out %q", ore)
+	}
+	if !m.IsPassable(3, 2) {
+		t.Error("Expected the vein to be left as passable rubble")
+	}
+	if _, ok := m.MineVein(3, 2); ok {
+		t.Error("Expected nothing more to be dug out of a mined vein")
+	}
+	if _, ok := m.MineVein(1, 2); ok {
+		t.Error("Expected nothing to be dug out of plain rock")
+	}
+}
@@ -0,0 +1,53 @@
+package worldmap
+
+import (
+	"math/rand"
+
+	"github.com/onorton/cowboysindians/item"
+)
+
+// Chance each turn spent swimming of the current dragging a swimmer under
+const currentChance = 0.15
+
+var drowningDamage = item.NewDamage(4, 1, 0)
+
+// IsDeepWater returns true if x, y is too deep to wade across
+func (m Map) IsDeepWater(x, y int) bool {
+	if !m.IsValid(x, y) {
+		return false
+	}
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	return chunk.deepWater(cX, cY)
+}
+
+// NearWater returns true if there is water at or next to x, y to drink or fill up from
+func (m Map) NearWater(x, y int) bool {
+	for i := -1; i <= 1; i++ {
+		for j := -1; j <= 1; j++ {
+			if !m.IsValid(x+j, y+i) {
+				continue
+			}
+			chunk, cX, cY := m.globalToChunkAndLocal(x+j, y+i)
+			if chunk.water(cX, cY) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DragUnder hurts those swimming at x, y if the current pulls them under, returning whether it did
+func (m Map) DragUnder(x, y int, swimmers ...Creature) bool {
+	if !m.IsDeepWater(x, y) || rand.Float64() >= currentChance {
+		return false
+	}
+	for _, swimmer := range swimmers {
+		swimmer.TakeDamage(drowningDamage, item.Effects{}, 0)
+	}
+	return true
+}
+
+func (m Map) canSwim(c Creature, x, y int) bool {
+	swimmer, ok := c.(Swimmer)
+	return ok && swimmer.CanSwim() && m.IsDeepWater(x, y)
+}
@@ -0,0 +1,255 @@
+package worldmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"github.com/onorton/cowboysindians/item"
+	"github.com/onorton/cowboysindians/message"
+)
+
+// Number of tiles along each side of a region that shares the same weather
+const weatherRegionSize = 4 * chunkSize
+
+// Turns a spell of weather lasts in a region before it may change
+const minWeatherTime = 200
+const maxWeatherTime = 800
+
+// How far anyone can see in a dust storm
+const stormVisionDistance = 4
+
+// Penalty to hit when either the attacker or the target is caught in a dust storm
+const stormAccuracyPenalty = 4
+
+// Chance of getting bogged down each step across mud in the rain
+const mudChance = 0.25
+
+// Chance of rain soaking gunpowder so that a shot or a fuse fails
+const wetPowderChance = 0.2
+
+// Chance each turn of rain putting out a fire
+const rainDouseChance = 0.2
+
+type Conditions int
+
+const (
+	Clear Conditions = iota
+	Heat
+	Rain
+	DustStorm
+)
+
+func (c Conditions) String() string {
+	return [...]string{"Clear", "Heat", "Rain", "Dust storm"}[c]
+}
+
+// Chance of each of the conditions setting in once a spell of weather is over
+var weatherChances = map[Conditions]float64{
+	Clear:     0.55,
+	Heat:      0.2,
+	Rain:      0.15,
+	DustStorm: 0.1,
+}
+
+var weatherMessages = map[Conditions]string{
+	Clear:     "The weather clears.",
+	Heat:      "The sun beats down mercilessly.",
+	Rain:      "It begins to rain.",
+	DustStorm: "A dust storm blows in!",
+}
+
+// Weather tracks the conditions in each region of the world and how long they will last
+type Weather struct {
+	conditions [][]Conditions
+	remaining  [][]int
+}
+
+func NewWeather(width, height int) *Weather {
+	regionsX := (width + weatherRegionSize - 1) / weatherRegionSize
+	regionsY := (height + weatherRegionSize - 1) / weatherRegionSize
+
+	conditions := make([][]Conditions, regionsY)
+	remaining := make([][]int, regionsY)
+	for i := range conditions {
+		conditions[i] = make([]Conditions, regionsX)
+		remaining[i] = make([]int, regionsX)
+		for j := range remaining[i] {
+			remaining[i][j] = weatherTime()
+		}
+	}
+	return &Weather{conditions, remaining}
+}
+
+func weatherTime() int {
+	return minWeatherTime + rand.Intn(maxWeatherTime-minWeatherTime+1)
+}
+
+func randomConditions() Conditions {
+	r := rand.Float64()
+	for _, c := range []Conditions{Clear, Heat, Rain, DustStorm} {
+		if r < weatherChances[c] {
+			return c
+		}
+		r -= weatherChances[c]
+	}
+	return Clear
+}
+
+// Update moves the weather on by a turn, bringing in new conditions wherever a spell has ended
+func (w *Weather) Update() {
+	for i, row := range w.remaining {
+		for j := range row {
+			w.remaining[i][j]--
+			if w.remaining[i][j] <= 0 {
+				w.conditions[i][j] = randomConditions()
+				w.remaining[i][j] = weatherTime()
+			}
+		}
+	}
+}
+
+func (w *Weather) at(x, y int) Conditions {
+	rX, rY := x/weatherRegionSize, y/weatherRegionSize
+	if rY < 0 || rY >= len(w.conditions) || rX < 0 || rX >= len(w.conditions[rY]) {
+		return Clear
+	}
+	return w.conditions[rY][rX]
+}
+
+func (w *Weather) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+
+	conditionsValue, err := json.Marshal(w.conditions)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Conditions\":%s,", conditionsValue))
+
+	remainingValue, err := json.Marshal(w.remaining)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Remaining\":%s", remainingValue))
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (w *Weather) UnmarshalJSON(data []byte) error {
+
+	type weatherJson struct {
+		Conditions [][]Conditions
+		Remaining  [][]int
+	}
+
+	var v weatherJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	w.conditions = v.Conditions
+	w.remaining = v.Remaining
+	return nil
+}
+
+func (m Map) Weather() *Weather {
+	return m.weather
+}
+
+// UpdateWeather moves the weather on, letting the player know when it changes where they are
+func (m Map) UpdateWeather() {
+	pX, pY := m.player.GetCoordinates()
+	before := m.weather.at(pX, pY)
+	m.weather.Update()
+	if after := m.weather.at(pX, pY); after != before && !m.Underground() {
+		message.Enqueue(weatherMessages[after])
+	}
+}
+
+func (m Map) WeatherAt(x, y int) Conditions {
+	return m.weather.at(x, y)
+}
+
+// WeatherStatus describes the weather where the player is for the status line, or returns an empty string if it is clear
+func (m Map) WeatherStatus() string {
+	pX, pY := m.player.GetCoordinates()
+	if conditions := m.weather.at(pX, pY); conditions != Clear && !m.Underground() {
+		return conditions.String()
+	}
+	return ""
+}
+
+// Sheltered returns true if a location is indoors or underground, out of the weather
+func (m Map) Sheltered(x, y int) bool {
+	if m.Underground() {
+		return true
+	}
+	for _, town := range m.towns {
+		for _, b := range town.Buildings {
+			if b.Inside(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m Map) exposedTo(x, y int, conditions Conditions) bool {
+	return m.weather.at(x, y) == conditions && !m.Sheltered(x, y)
+}
+
+// StormPenalty is how much harder it is to hit a target at x1, y1 from x0, y0 because of dust storms
+func (m Map) StormPenalty(x0, y0, x1, y1 int) int {
+	if m.exposedTo(x0, y0, DustStorm) || m.exposedTo(x1, y1, DustStorm) {
+		return stormAccuracyPenalty
+	}
+	return 0
+}
+
+// StuckInMud returns true if someone stepping onto x, y gets bogged down in rain soaked ground
+func (m Map) StuckInMud(x, y int) bool {
+	if !m.IsValid(x, y) || !m.exposedTo(x, y, Rain) {
+		return false
+	}
+	chunk, cX, cY := m.globalToChunkAndLocal(x, y)
+	terrain := chunk.terrain[cY][cX]
+	muddy := terrain == terrainData["path"].Icon || terrain == terrainData["ground"].Icon
+	return muddy && rand.Float64() < mudChance
+}
+
+// PowderWet returns true if the rain has soaked gunpowder at x, y, so a shot misfires or a fuse goes out
+func (m Map) PowderWet(x, y int) bool {
+	return m.exposedTo(x, y, Rain) && rand.Float64() < wetPowderChance
+}
+
+// ApplyWeather makes creatures out in the heat grow thirsty faster
+func (m Map) ApplyWeather(attributes map[string]*Attribute, x, y int) {
+	if thirst, ok := attributes["thirst"]; ok && m.exposedTo(x, y, Heat) {
+		thirst.AddEffect(item.NewInstantEffect(1))
+	}
+}
+
+// NearestShelter finds the middle of the building closest to x, y, returning false if there are no buildings
+func (m Map) NearestShelter(x, y int) (Coordinates, bool) {
+	var nearest Coordinates
+	found := false
+	for _, town := range m.towns {
+		for _, b := range town.Buildings {
+			centre := Coordinates{(b.Area.X1() + b.Area.X2()) / 2, (b.Area.Y1() + b.Area.Y2()) / 2}
+			if !found || Distance(x, y, centre.X, centre.Y) < Distance(x, y, nearest.X, nearest.Y) {
+				nearest = centre
+				found = true
+			}
+		}
+	}
+	return nearest, found
+}
+
+// Foul returns true if the weather at x, y is bad enough to drive people indoors
+func (m Map) Foul(x, y int) bool {
+	conditions := m.weather.at(x, y)
+	return conditions == Rain || conditions == DustStorm
+}
@@ -0,0 +1,56 @@
+package worldmap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWeatherStartsClearEverywhere(t *testing.T) {
+	weather := NewWeather(1024, 512)
+
+	if len(weather.conditions) != 2 || len(weather.conditions[0]) != 4 {
+		t.Fatalf("Expected 4x2 weather regions but there were %dx%d", len(weather.conditions[0]), len(weather.conditions))
+	}
+	for _, location := range []Coordinates{{0, 0}, {300, 100}, {1023, 511}, {-1, 0}, {2000, 0}} {
+		if weather.at(location.X, location.Y) != Clear {
+			t.Errorf("Expected weather at %d, %d to be clear but was %s", location.X, location.Y, weather.at(location.X, location.Y))
+		}
+	}
+}
+
+func TestWeatherChangesOnceSpellIsOver(t *testing.T) {
+	weather := NewWeather(512, 256)
+	weather.conditions[0][1] = DustStorm
+	weather.remaining[0][1] = 1
+
+	weather.Update()
+
+	if weather.remaining[0][1] < minWeatherTime || weather.remaining[0][1] > maxWeatherTime {
+		t.Errorf("Expected a new spell of weather to last between %d and %d turns but was %d", minWeatherTime, maxWeatherTime, weather.remaining[0][1])
+	}
+	if weather.remaining[0][0] >= maxWeatherTime {
+		t.Errorf("Expected the spell in other regions to count down but was %d", weather.remaining[0][0])
+	}
+}
+
+func TestWeatherMarshalling(t *testing.T) {
+	weather := NewWeather(512, 256)
+	weather.conditions[0][1] = Rain
+	weather.remaining[0][1] = 42
+
+	data, err := json.Marshal(weather)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmarshalled := &Weather{}
+	if err := json.Unmarshal(data, unmarshalled); err != nil {
+		t.Fatal(err)
+	}
+
+	if unmarshalled.at(300, 0) != Rain || unmarshalled.at(0, 0) != Clear {
+		t.Errorf("Expected rain in the second region only but was %s and %s", unmarshalled.at(0, 0), unmarshalled.at(300, 0))
+	}
+	if unmarshalled.remaining[0][1] != 42 {
+		t.Errorf("Expected 42 turns remaining but there were %d", unmarshalled.remaining[0][1])
+	}
+}
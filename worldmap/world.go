@@ -53,6 +53,12 @@ func (world World) NewTile(tileType string, x, y int) {
 	chunk.newTile(tileType, cX, cY)
 }
 
+// TileIs returns true if the tile at x, y is of the given type
+func (world World) TileIs(tileType string, x, y int) bool {
+	chunk, cX, cY := world.globalToChunkAndLocal(x, y)
+	return chunk.terrain[cY][cX] == terrainData[tileType].Icon
+}
+
 func (world World) Door(x, y int) *doorComponent {
 	chunk, cX, cY := world.globalToChunkAndLocal(x, y)
 	return chunk.door[cY][cX]
@@ -142,3 +142,5 @@ func (c *testCreature) SetCoordinates(x, y int)                             { c.
 func (c *testCreature) GetVisionDistance() int                              { return 0 }
 func (c *testCreature) Standup()                                            {}
 func (c *testCreature) Crouch()                                             {}
+func (c *testCreature) GetDepth() int                                       { return Surface }
+func (c *testCreature) SetDepth(depth int)                                  {}
@@ -0,0 +1,265 @@
+package worldmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"github.com/onorton/cowboysindians/item"
+)
+
+var WoundKinds = []string{"bleeding", "broken arm", "broken leg", "head wound"}
+
+// Turns it takes for each kind of wound to heal by itself
+var woundDurations = map[string]int{
+	"bleeding":   5,
+	"broken arm": 300,
+	"broken leg": 300,
+	"head wound": 150,
+}
+
+var woundDescriptions = map[string]string{
+	"bleeding":   "Bleeding",
+	"broken arm": "Broken arm",
+	"broken leg": "Broken leg",
+	"head wound": "Head wound",
+}
+
+// Attribute penalised while a limb is broken
+var woundPenalties = map[string]string{
+	"broken arm": "str",
+	"broken leg": "dex",
+}
+
+// Hit points lost every turn while bleeding
+const bleedRate = 1
+
+type Wound struct {
+	kind string
+	// Turns left until it heals by itself
+	turns int
+}
+
+func (w Wound) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+
+	kindValue, err := json.Marshal(w.kind)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Kind\":%s,", kindValue))
+
+	turnsValue, err := json.Marshal(w.turns)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Turns\":%s", turnsValue))
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (w *Wound) UnmarshalJSON(data []byte) error {
+
+	type woundJson struct {
+		Kind  string
+		Turns int
+	}
+
+	var v woundJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	w.kind = v.Kind
+	w.turns = v.Turns
+
+	return nil
+}
+
+// Wounds a creature is suffering from, along with the scars left by old ones
+type Wounds struct {
+	wounds []Wound
+	scars  []string
+}
+
+func NewWounds() *Wounds {
+	return &Wounds{make([]Wound, 0), make([]string, 0)}
+}
+
+// Inflict gives a creature hit for an amount of damage a chance of being wounded.
+// Returns the kind of wound suffered, or an empty string if there wasn't one.
+func (w *Wounds) Inflict(attributes map[string]*Attribute, damage int) string {
+	// Harder hits are more likely to wound, while tougher creatures shrug them off
+	damage -= Bonus(attributes, "con")
+	if damage <= 0 || rand.Intn(10) >= damage {
+		return ""
+	}
+
+	// Where the creature was hit
+	kind := "bleeding"
+	switch location := rand.Intn(20); {
+	case location < 3:
+		kind = "head wound"
+	case location < 8 && damage >= 4:
+		kind = "broken arm"
+	case location >= 8 && location < 13 && damage >= 4:
+		kind = "broken leg"
+	}
+
+	turns := woundDurations[kind]
+	if kind == "bleeding" {
+		turns = 2 + rand.Intn(woundDurations[kind]-1)
+		attributes["hp"].AddEffect(item.NewOngoingEffect(-bleedRate))
+	}
+	w.wounds = append(w.wounds, Wound{kind, turns})
+	return kind
+}
+
+// Update penalises a creature for its wounds and heals them over time.
+// Returns the kinds of wounds that healed by themselves.
+func (w *Wounds) Update(attributes map[string]*Attribute) []string {
+	healed := make([]string, 0)
+	for i := 0; i < len(w.wounds); i++ {
+		kind := w.wounds[i].kind
+		w.wounds[i].turns--
+		if w.wounds[i].turns <= 0 {
+			w.heal(attributes, i, false)
+			healed = append(healed, kind)
+			i--
+			continue
+		}
+		if attribute, ok := woundPenalties[kind]; ok {
+			attributes[attribute].AddEffect(item.NewEffect(-2, 1, false))
+		}
+	}
+	return healed
+}
+
+// Treat heals any of the given kinds of wound, returning those treated
+func (w *Wounds) Treat(attributes map[string]*Attribute, kinds []string) []string {
+	treated := make([]string, 0)
+	for i := 0; i < len(w.wounds); i++ {
+		for _, kind := range kinds {
+			if w.wounds[i].kind == kind {
+				treated = append(treated, kind)
+				w.heal(attributes, i, true)
+				i--
+				break
+			}
+		}
+	}
+	return treated
+}
+
+// Removes a wound. Wounds that bled always leave a scar, but only
+// those not properly treated leave lasting damage.
+func (w *Wounds) heal(attributes map[string]*Attribute, i int, treated bool) {
+	kind := w.wounds[i].kind
+	w.wounds = append(w.wounds[:i], w.wounds[i+1:]...)
+
+	switch kind {
+	case "bleeding":
+		attributes["hp"].RemoveOngoing(-bleedRate)
+		w.scars = append(w.scars, kind)
+	case "broken arm", "broken leg":
+		if !treated {
+			attributes[woundPenalties[kind]].AddEffect(item.NewInstantEffect(-1))
+			w.scars = append(w.scars, kind)
+		}
+	case "head wound":
+		if !treated {
+			w.scars = append(w.scars, kind)
+		}
+	}
+}
+
+// Has returns true if suffering from a kind of wound
+func (w *Wounds) Has(kind string) bool {
+	for _, wound := range w.wounds {
+		if wound.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the number of wounds being suffered
+func (w *Wounds) Count() int {
+	return len(w.wounds)
+}
+
+// Stumbles returns true if a broken leg stops a creature from moving this turn
+func (w *Wounds) Stumbles() bool {
+	return w.Has("broken leg") && rand.Intn(2) == 0
+}
+
+// VisionDistance is how far a creature that could otherwise see distance can see
+func (w *Wounds) VisionDistance(distance int) int {
+	if w.Has("head wound") {
+		return distance / 2
+	}
+	return distance
+}
+
+// Status describes each kind of wound for the status line
+func (w *Wounds) Status() []string {
+	status := make([]string, 0)
+	for _, kind := range WoundKinds {
+		if w.Has(kind) {
+			status = append(status, woundDescriptions[kind])
+		}
+	}
+	return status
+}
+
+// Scars returns the kinds of wound that have left their mark
+func (w *Wounds) Scars() []string {
+	return w.scars
+}
+
+func (w *Wounds) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+
+	woundsValue, err := json.Marshal(w.wounds)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Wounds\":%s,", woundsValue))
+
+	scarsValue, err := json.Marshal(w.scars)
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fmt.Sprintf("\"Scars\":%s", scarsValue))
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (w *Wounds) UnmarshalJSON(data []byte) error {
+
+	type woundsJson struct {
+		Wounds []Wound
+		Scars  []string
+	}
+
+	var v woundsJson
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	w.wounds = v.Wounds
+	w.scars = v.Scars
+	if w.wounds == nil {
+		w.wounds = make([]Wound, 0)
+	}
+	if w.scars == nil {
+		w.scars = make([]string, 0)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package worldmap
+
+import (
+	"testing"
+
+	"github.com/onorton/cowboysindians/item"
+)
+
+func woundedAttributes() map[string]*Attribute {
+	return map[string]*Attribute{"hp": NewAttribute(10, 10), "str": NewAttribute(10, 10), "dex": NewAttribute(10, 10)}
+}
+
+func TestBleedingLosesHpUntilTreated(t *testing.T) {
+	attributes := woundedAttributes()
+	w := &Wounds{[]Wound{Wound{"bleeding", 5}}, []string{}}
+	attributes["hp"].AddEffect(item.NewOngoingEffect(-bleedRate))
+
+	attributes["hp"].Update()
+	if attributes["hp"].Value() != 8 {
+		t.Errorf("Hp should have been 8 after bleeding for two turns but was %d", attributes["hp"].Value())
+	}
+
+	w.Treat(attributes, []string{"bleeding"})
+	attributes["hp"].Update()
+	if attributes["hp"].Value() != 8 {
+		t.Errorf("Hp should have stayed at 8 once treated but was %d", attributes["hp"].Value())
+	}
+	if attributes["hp"].Maximum() != 10 {
+		t.Errorf("Maximum hp should have stayed at 10 but was %d", attributes["hp"].Maximum())
+	}
+	if len(w.Scars()) != 1 {
+		t.Errorf("Bleeding should have left a scar but there were %d", len(w.Scars()))
+	}
+}
+
+func TestBrokenLegHealsWithLimp(t *testing.T) {
+	attributes := woundedAttributes()
+	w := &Wounds{[]Wound{Wound{"broken leg", 2}}, []string{}}
+
+	w.Update(attributes)
+	if attributes["dex"].Value() != 8 {
+		t.Errorf("Dex should have been 8 with a broken leg but was %d", attributes["dex"].Value())
+	}
+
+	attributes["dex"].Update()
+	healed := w.Update(attributes)
+	if len(healed) != 1 || healed[0] != "broken leg" {
+		t.Errorf("Broken leg should have healed but %v healed", healed)
+	}
+	if attributes["dex"].Value() != 9 {
+		t.Errorf("Dex should have been 9 after a leg healed badly but was %d", attributes["dex"].Value())
+	}
+}
+
+func TestTreatedBrokenArmHealsCleanly(t *testing.T) {
+	attributes := woundedAttributes()
+	w := &Wounds{[]Wound{Wound{"broken arm", 300}}, []string{}}
+
+	treated := w.Treat(attributes, WoundKinds)
+	if len(treated) != 1 || w.Has("broken arm") {
+		t.Error("Broken arm should have been treated but wasn't")
+	}
+	if attributes["str"].Value() != 10 || len(w.Scars()) != 0 {
+		t.Error("Treated broken arm should not have left lasting damage")
+	}
+}